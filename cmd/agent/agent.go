@@ -0,0 +1,176 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/llmos-ai/llmos/utils/cli"
+	"github.com/spf13/cobra"
+	"golang.org/x/sync/errgroup"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/llmos-ai/llmos/pkg/applyinator"
+	"github.com/llmos-ai/llmos/pkg/applyinator/prober"
+	"github.com/llmos-ai/llmos/pkg/bootstrap"
+	"github.com/llmos-ai/llmos/pkg/bootstrap/kubectl"
+	"github.com/llmos-ai/llmos/pkg/bootstrap/plan"
+	"github.com/llmos-ai/llmos/pkg/cli/agent"
+	"github.com/llmos-ai/llmos/pkg/cli/health"
+	"github.com/llmos-ai/llmos/pkg/constants"
+)
+
+const (
+	sourceFile   = "file"
+	sourceSecret = "secret"
+)
+
+func NewAgent() *cobra.Command {
+	return cli.Command(&Agent{}, cobra.Command{
+		Short: "Run the node agent reconciling the plans and running their periodic instructions",
+		Long: "The agent applies a plan again whenever its checksum changes and runs its periodic instructions " +
+			"every periodSeconds. The plan source is a plan file or a directory of *.plan files, or the " +
+			"llmos-plan-<node> Secret in the llmos-system namespace which the agent writes the applied " +
+			"checksum, outputs and probe statuses back to. The plans are not applied while the " +
+			"restart-pending file exists in <data-dir>/agent/interlock. With --health-address the agent serves " +
+			"the node health on /healthz, /readyz (bootstrap and plan probes), /status (bootstrap phase, versions) " +
+			"and the prometheus metrics on /metrics.",
+	})
+}
+
+// Agent defines the agent command flags
+//
+//nolint:lll
+type Agent struct {
+	DataDir        string `usage:"Path to llmos state dir" default:"/var/lib/llmos" env:"LLMOS_DATA_DIR"`
+	Source         string `usage:"Plan source, file or secret" default:"file" env:"LLMOS_AGENT_SOURCE"`
+	Plans          string `usage:"Plan file or directory of *.plan files to reconcile with the file source" default:"/etc/llmos/plans" env:"LLMOS_AGENT_PLANS"`
+	NodeName       string `usage:"Node name of the plan Secret, defaults to the hostname" env:"LLMOS_NODE_NAME"`
+	Kubeconfig     string `usage:"Kubeconfig to watch the plan Secret with, defaults to the k8s runtime kubeconfig" env:"KUBECONFIG"`
+	Interval       string `usage:"Interval to reconcile the plans" default:"15s" short:"i" env:"LLMOS_AGENT_INTERVAL"`
+	Verify         bool   `usage:"Only apply the plans signed by a trusted key in their detached signatures" env:"LLMOS_PLAN_VERIFY"`
+	TrustedKeysDir string `usage:"Directory of the trusted ed25519 public keys (*.pub, *.pem) verifying the plans" default:"/etc/llmos/trusted-keys" env:"LLMOS_PLAN_TRUSTED_KEYS_DIR"`
+	HealthAddress  string `usage:"Address of the node health listener serving /healthz, /readyz, /status and /metrics, e.g. :9440, disabled if empty" env:"LLMOS_HEALTH_ADDRESS"`
+	HealthCert     string `usage:"TLS certificate of the node health listener, it serves HTTPS when set" env:"LLMOS_HEALTH_CERT"`
+	HealthKey      string `usage:"TLS key of the node health listener" env:"LLMOS_HEALTH_KEY"`
+	HealthClientCA string `usage:"CA verifying the client certificates required by the node health listener" env:"LLMOS_HEALTH_CLIENT_CA"`
+}
+
+func (a *Agent) Run(cmd *cobra.Command, _ []string) error {
+	interval, err := time.ParseDuration(a.Interval)
+	if err != nil {
+		return fmt.Errorf("parsing duration %s: %w", a.Interval, err)
+	}
+
+	var verifier *applyinator.Verifier
+	if a.Verify {
+		if verifier, err = applyinator.NewVerifierFromDir(a.TrustedKeysDir); err != nil {
+			return err
+		}
+	}
+
+	source, err := a.source()
+	if err != nil {
+		return err
+	}
+
+	nodeAgent := agent.New(agent.Options{
+		DataDir:  a.DataDir,
+		Source:   source,
+		Interval: interval,
+		Verifier: verifier,
+	})
+	if a.HealthAddress == "" {
+		return nodeAgent.Run(cmd.Context())
+	}
+
+	// the probes of the bootstrap plan are run besides the probes of the reconciled plans, the agent
+	// is expected to start after the bootstrap, e.g. by the ordering of their services
+	bootstrapProbes, err := a.bootstrapProbes()
+	if err != nil {
+		return err
+	}
+
+	group, ctx := errgroup.WithContext(cmd.Context())
+	group.Go(func() error {
+		return nodeAgent.Run(ctx)
+	})
+
+	probeSources := []health.ProbeSource{nodeAgent}
+	if len(bootstrapProbes) > 0 {
+		runner := prober.NewRunner(bootstrapProbes)
+		group.Go(func() error {
+			runner.Run(ctx)
+			return nil
+		})
+		probeSources = append(probeSources, health.RunnerSource(runner, bootstrapProbes))
+	}
+
+	server := health.New(health.Options{
+		Address:      a.HealthAddress,
+		CertFile:     a.HealthCert,
+		KeyFile:      a.HealthKey,
+		ClientCAFile: a.HealthClientCA,
+	}, bootstrap.New(bootstrap.Config{DataDir: a.DataDir}).Status, probeSources...)
+	group.Go(func() error {
+		return server.Run(ctx)
+	})
+	return group.Wait()
+}
+
+// bootstrapProbes returns the probes of the plan applied by the bootstrap, none if the node is not bootstrapped
+func (a *Agent) bootstrapProbes() (map[string]prober.Probe, error) {
+	data, err := os.ReadFile(plan.GetPlanFile(a.DataDir))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	p := applyinator.Plan{}
+	if err = json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("parsing bootstrap plan %s: %w", plan.GetPlanFile(a.DataDir), err)
+	}
+	return p.Probes, nil
+}
+
+func (a *Agent) source() (agent.Source, error) {
+	switch a.Source {
+	case sourceFile:
+		return &agent.FileSource{Path: a.Plans}, nil
+	case sourceSecret:
+	default:
+		return nil, fmt.Errorf("invalid plan source %s, must be %s or %s", a.Source, sourceFile, sourceSecret)
+	}
+
+	nodeName := a.NodeName
+	if nodeName == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return nil, err
+		}
+		nodeName = strings.ToLower(hostname)
+	}
+
+	kubeconfig, err := kubectl.GetKubeconfig(a.Kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+	restConfig, err := clientcmd.BuildConfigFromFlags("", kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+	client, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	return &agent.SecretSource{
+		Client:    client,
+		Namespace: constants.SystemNamespace,
+		Name:      agent.SecretName(nodeName),
+	}, nil
+}
@@ -1,12 +1,20 @@
 package bootstrap
 
 import (
+	"context"
+	"time"
+
 	"github.com/llmos-ai/llmos/utils/cli"
+	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 
 	"github.com/llmos-ai/llmos/pkg/bootstrap"
+	"github.com/llmos-ai/llmos/pkg/utils/metrics"
+	"github.com/llmos-ai/llmos/pkg/utils/tracing"
 )
 
+const traceShutdownTimeout = 10 * time.Second
+
 func NewBootstrap() *cobra.Command {
 	return cli.Command(&Bootstrap{}, cobra.Command{
 		Short: "Bootstrap LLMOS operator & Kubernetes",
@@ -26,9 +34,28 @@ type Bootstrap struct {
 	ClusterInit       bool   `usage:"Bootstrap cluster-init role" env:"LLMOS_CLUSTER_INIT"`
 	KubernetesVersion string `usage:"Default kubernetes version to bootstrap" env:"LLMOS_KUBERNETES_VERSION" default:"v1.31.3+k3s1"`
 	Mirror            string `usage:"Specify the mirror registry for installation" enum:"cn" env:"LLMOS_MIRROR"`
+	MaxAttempts       int    `usage:"Give up the bootstrap after this many failed attempts, it is retried forever if 0" env:"LLMOS_BOOTSTRAP_MAX_ATTEMPTS"`
+	MetricsTextfile   string `usage:"Write the bootstrap metrics to this file for the node-exporter textfile collector, e.g. /var/lib/node_exporter/textfile_collector/llmos.prom" env:"LLMOS_METRICS_TEXTFILE"`
+	TraceEndpoint     string `usage:"OTLP gRPC endpoint to export the bootstrap traces to, e.g. http://otel-collector:4317" env:"LLMOS_TRACE_ENDPOINT"`
+	TraceFile         string `usage:"Write the bootstrap traces as JSON lines to this file" env:"LLMOS_TRACE_FILE"`
 }
 
 func (b *Bootstrap) Run(cmd *cobra.Command, _ []string) error {
+	shutdownTracing, err := tracing.Setup(cmd.Context(), tracing.Options{
+		Endpoint: b.TraceEndpoint,
+		File:     b.TraceFile,
+	})
+	if err != nil {
+		return err
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), traceShutdownTimeout)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			logrus.Errorf("flushing traces: %v", err)
+		}
+	}()
+
 	boot := bootstrap.New(bootstrap.Config{
 		Force:             b.Force,
 		DataDir:           b.DataDir,
@@ -39,6 +66,14 @@ func (b *Bootstrap) Run(cmd *cobra.Command, _ []string) error {
 		ClusterInit:       b.ClusterInit,
 		KubernetesVersion: b.KubernetesVersion,
 		Mirror:            b.Mirror,
+		MaxAttempts:       b.MaxAttempts,
 	})
-	return boot.Run(cmd.Context())
+	// the bootstrap continues the trace of the caller passed with the TRACEPARENT env var, if any
+	err = boot.Run(tracing.ContextFromEnv(cmd.Context()))
+	if b.MetricsTextfile != "" {
+		if writeErr := metrics.WriteTextfile(b.MetricsTextfile); writeErr != nil {
+			logrus.Errorf("writing metrics textfile %s: %v", b.MetricsTextfile, writeErr)
+		}
+	}
+	return err
 }
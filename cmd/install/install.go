@@ -0,0 +1,56 @@
+package install
+
+import (
+	"github.com/llmos-ai/llmos/utils/cli"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/llmos-ai/llmos/pkg/cli/install"
+)
+
+func NewInstall() *cobra.Command {
+	return cli.Command(&Install{}, cobra.Command{
+		Use:   "install [flags] [-- bootstrap flags]",
+		Short: "Install the running llmos binary as the llmos bootstrap service",
+		Example: `  # install a cluster-init node
+  llmos install -- --cluster-init
+  # install an agent joining the cluster
+  LLMOS_TOKEN=xxx LLMOS_SERVER=https://server-url:6443 llmos install`,
+	})
+}
+
+// Install defines the command to install llmos as a service
+//
+//nolint:lll
+type Install struct {
+	Root         string `usage:"Target root directory of the installation" default:"/"`
+	BinDir       string `usage:"Directory to install llmos binary and uninstall script to (default /usr/local/bin)" env:"INSTALL_LLMOS_BIN_DIR"`
+	SystemdDir   string `usage:"Directory to install systemd service to" default:"/etc/systemd/system" env:"INSTALL_LLMOS_SYSTEMD_DIR"`
+	Init         string `usage:"Process supervisor of the service, auto detected if not set" enum:"systemd,openrc"`
+	SkipEnable   bool   `usage:"Do not enable or start the llmos service" env:"INSTALL_LLMOS_SKIP_ENABLE"`
+	SkipStart    bool   `usage:"Do not start the llmos service" env:"INSTALL_LLMOS_SKIP_START"`
+	ForceRestart bool   `usage:"Always restart the llmos service" env:"INSTALL_LLMOS_FORCE_RESTART"`
+}
+
+func (i *Install) Run(cmd *cobra.Command, args []string) error {
+	skipEnable := i.SkipEnable
+	if i.Root != "/" && !skipEnable {
+		logrus.Infof("Skipping enable llmos service, installing to root %s", i.Root)
+		skipEnable = true
+	}
+
+	installer, err := install.New(install.Options{
+		Root:         i.Root,
+		BinDir:       i.BinDir,
+		SystemdDir:   i.SystemdDir,
+		Init:         i.Init,
+		Args:         args,
+		SkipEnable:   skipEnable,
+		SkipStart:    i.SkipStart,
+		ForceRestart: i.ForceRestart,
+	})
+	if err != nil {
+		return err
+	}
+	return installer.Install(cmd.Context())
+}
@@ -0,0 +1,51 @@
+package logs
+
+import (
+	"github.com/llmos-ai/llmos/utils/cli"
+	"github.com/spf13/cobra"
+
+	"github.com/llmos-ai/llmos/pkg/bootstrap/plan"
+	"github.com/llmos-ai/llmos/pkg/cli/agent"
+	"github.com/llmos-ai/llmos/pkg/cli/logs"
+)
+
+func NewLogs() *cobra.Command {
+	return cli.Command(&Logs{}, cobra.Command{
+		Use:   "logs [instruction]",
+		Short: "Print the output logs of the plan instructions",
+		Long: "Without an instruction the instructions of the last applied plan are listed with their attempts " +
+			"and last exit code. The instruction is selected by its index, name or log file, e.g. 0, install-k3s " +
+			"or periodic-0-check, and the last attempt is printed unless --attempt is set. The logs are kept " +
+			"in <data-dir>/plan/logs/<checksum>, or in <data-dir>/agent/logs/<checksum> for the agent plans.",
+		Args: cobra.MaximumNArgs(1),
+	})
+}
+
+// Logs defines the logs command flags
+//
+//nolint:lll
+type Logs struct {
+	DataDir  string `usage:"Path to llmos state dir" default:"/var/lib/llmos" env:"LLMOS_DATA_DIR"`
+	Agent    bool   `usage:"Read the logs of the plans applied by the agent instead of the bootstrap plan"`
+	Checksum string `usage:"Checksum of the plan, defaults to the last applied plan"`
+	Attempt  int    `usage:"Attempt of the instruction to print, defaults to the last attempt" short:"a"`
+	Follow   bool   `usage:"Follow the lines appended to the instruction log" short:"f"`
+}
+
+func (l *Logs) Run(cmd *cobra.Command, args []string) error {
+	dir := plan.GetLogsDir(l.DataDir)
+	if l.Agent {
+		dir = agent.LogsDir(l.DataDir)
+	}
+
+	opts := logs.Options{
+		Dir:      dir,
+		Checksum: l.Checksum,
+		Attempt:  l.Attempt,
+		Follow:   l.Follow,
+	}
+	if len(args) > 0 {
+		opts.Instruction = args[0]
+	}
+	return logs.Run(cmd.Context(), cmd.OutOrStdout(), opts)
+}
@@ -0,0 +1,29 @@
+package ping
+
+import (
+	"fmt"
+
+	"github.com/llmos-ai/llmos/utils/cli"
+	"github.com/spf13/cobra"
+
+	"github.com/llmos-ai/llmos/pkg/cli/ping"
+)
+
+func NewPing() *cobra.Command {
+	return cli.Command(&Ping{}, cobra.Command{
+		Use:    "ping [server-url]",
+		Short:  "Check if the server URL is ready to accept nodes",
+		Args:   cobra.ExactArgs(1),
+		Hidden: true,
+	})
+}
+
+type Ping struct{}
+
+func (p *Ping) Run(cmd *cobra.Command, args []string) error {
+	if err := ping.Ping(cmd.Context(), args[0]); err != nil {
+		return err
+	}
+	fmt.Println("pong")
+	return nil
+}
@@ -5,21 +5,32 @@ import (
 	"time"
 
 	"github.com/llmos-ai/llmos/utils/cli"
+	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 
+	"github.com/llmos-ai/llmos/pkg/applyinator"
 	"github.com/llmos-ai/llmos/pkg/cli/probe"
 )
 
 func NewProbe() *cobra.Command {
 	return cli.Command(&Probe{}, cobra.Command{
-		Short:  "Run plan probes",
+		Short:  "Run plan probes until they are healthy and print their report",
 		Hidden: true,
+		// a timeout is reported with the probe report instead of the usage
+		SilenceUsage: true,
 	})
 }
 
+// Probe defines the probe command flags
+//
+//nolint:lll
 type Probe struct {
-	Interval string `usage:"Polling interval to run probes" default:"2s" short:"i"`
-	File     string `usage:"Plan file" default:"/var/lib/llmos/plan/plan.json" short:"f"`
+	Interval       string `usage:"Polling interval to run probes" default:"2s" short:"i"`
+	Timeout        string `usage:"Fail with exit code 124 if the probes are not healthy in time, 0 waits forever" default:"15m" short:"t"`
+	Output         string `usage:"Output format of the final probe report, table or json" default:"table" short:"o"`
+	File           string `usage:"Plan file" default:"/var/lib/llmos/plan/plan.json" short:"f"`
+	Verify         bool   `usage:"Only load the plan if its detached signature <file>.sig is signed by a trusted key" env:"LLMOS_PLAN_VERIFY"`
+	TrustedKeysDir string `usage:"Directory of the trusted ed25519 public keys (*.pub, *.pem) verifying the plan" default:"/etc/llmos/trusted-keys" env:"LLMOS_PLAN_TRUSTED_KEYS_DIR"`
 }
 
 func (p *Probe) Run(cmd *cobra.Command, _ []string) error {
@@ -28,5 +39,32 @@ func (p *Probe) Run(cmd *cobra.Command, _ []string) error {
 		return fmt.Errorf("parsing duration %s: %w", p.Interval, err)
 	}
 
-	return probe.RunProbes(cmd.Context(), p.File, interval)
+	timeout, err := time.ParseDuration(p.Timeout)
+	if err != nil {
+		return fmt.Errorf("parsing duration %s: %w", p.Timeout, err)
+	}
+	if p.Output != "table" && p.Output != "json" {
+		return fmt.Errorf("unsupported output format %s, must be table or json", p.Output)
+	}
+
+	var verifier *applyinator.Verifier
+	if p.Verify {
+		if verifier, err = applyinator.NewVerifierFromDir(p.TrustedKeysDir); err != nil {
+			return err
+		}
+		logrus.Debugf("Verifying plan %s with trusted keys %v", p.File, verifier.KeyIDs())
+	}
+
+	report, err := probe.RunProbes(cmd.Context(), probe.Options{
+		PlanFile: p.File,
+		Interval: interval,
+		Timeout:  timeout,
+		Verifier: verifier,
+	})
+	if len(report.Probes) > 0 {
+		if printErr := report.Print(cmd.OutOrStdout(), p.Output); printErr != nil {
+			return printErr
+		}
+	}
+	return err
 }
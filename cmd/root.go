@@ -12,17 +12,31 @@ import (
 	"github.com/spf13/cobra"
 	"k8s.io/klog/v2"
 
+	"github.com/llmos-ai/llmos/cmd/agent"
 	"github.com/llmos-ai/llmos/cmd/bootstrap"
 	"github.com/llmos-ai/llmos/cmd/gettoken"
 	"github.com/llmos-ai/llmos/cmd/info"
+	"github.com/llmos-ai/llmos/cmd/install"
+	instructionlogs "github.com/llmos-ai/llmos/cmd/logs"
+	"github.com/llmos-ai/llmos/cmd/ping"
 	"github.com/llmos-ai/llmos/cmd/probe"
 	"github.com/llmos-ai/llmos/cmd/retry"
+	"github.com/llmos-ai/llmos/cmd/selfupdate"
+	"github.com/llmos-ai/llmos/cmd/snapshot"
+	"github.com/llmos-ai/llmos/cmd/supportbundle"
+	"github.com/llmos-ai/llmos/cmd/uninstall"
+	"github.com/llmos-ai/llmos/cmd/upgrade"
 	"github.com/llmos-ai/llmos/cmd/version"
+	"github.com/llmos-ai/llmos/pkg/utils/redact"
 )
 
+// llmos defines the global flags
+//
+//nolint:lll
 type llmos struct {
-	Debug      bool `usage:"Enable debug logging" env:"LLMOS_DEBUG"`
-	DebugLevel int  `usage:"Debug log level (valid 0-9) (default 7)" env:"LLMOS_DEBUG_LEVEL"`
+	Debug                bool `usage:"Enable debug logging" env:"LLMOS_DEBUG"`
+	DebugLevel           int  `usage:"Debug log level (valid 0-9) (default 7)" env:"LLMOS_DEBUG_LEVEL"`
+	InsecureDebugSecrets bool `usage:"Keep the secrets in the stamps, plan files and logs for local troubleshooting, do not use in production" env:"LLMOS_INSECURE_DEBUG_SECRETS"`
 }
 
 func (l *llmos) Run(cmd *cobra.Command, _ []string) error {
@@ -41,10 +55,19 @@ func NewRootCmd() *cobra.Command {
 
 	root.AddCommand(
 		bootstrap.NewBootstrap(),
+		agent.NewAgent(),
+		install.NewInstall(),
 		probe.NewProbe(),
 		retry.NewRetry(),
+		ping.NewPing(),
 		gettoken.NewGetToken(),
+		snapshot.NewSnapshot(),
+		uninstall.NewUninstall(),
+		upgrade.NewUpgrade(),
+		selfupdate.NewSelfUpdate(),
+		supportbundle.NewSupportBundle(),
 		info.NewInfo(),
+		instructionlogs.NewLogs(),
 		version.NewVersion(),
 	)
 	root.InitDefaultHelpCmd()
@@ -52,6 +75,11 @@ func NewRootCmd() *cobra.Command {
 }
 
 func (l *llmos) PersistentPre(_ *cobra.Command, _ []string) error {
+	redact.SetInsecureDebugSecrets(l.InsecureDebugSecrets)
+	if l.InsecureDebugSecrets {
+		logrus.Warn("Secrets are not redacted from the stamps, plan files and logs, do not use it in production")
+	}
+
 	if l.Debug || l.DebugLevel > 0 {
 		logging := flag.NewFlagSet("", flag.PanicOnError)
 		klog.InitFlags(logging)
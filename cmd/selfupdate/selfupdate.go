@@ -0,0 +1,48 @@
+package selfupdate
+
+import (
+	"fmt"
+
+	"github.com/llmos-ai/llmos/utils/cli"
+	"github.com/spf13/cobra"
+
+	"github.com/llmos-ai/llmos/pkg/cli/selfupdate"
+)
+
+func NewSelfUpdate() *cobra.Command {
+	return cli.Command(&SelfUpdate{}, cobra.Command{
+		Use:   "self-update",
+		Short: "Update the llmos binary to a release version",
+	})
+}
+
+// SelfUpdate defines the command to update the llmos binary
+//
+//nolint:lll
+type SelfUpdate struct {
+	Version   string `usage:"Release version to update to, defaults to the latest release"`
+	BaseURL   string `usage:"Base URL of the llmos releases" default:"https://github.com/llmos-ai/llmos/releases" env:"LLMOS_RELEASE_URL"`
+	NoRestart bool   `usage:"Do not restart the llmos service after the update"`
+}
+
+func (s *SelfUpdate) Run(cmd *cobra.Command, _ []string) error {
+	updater, err := selfupdate.New(selfupdate.Options{
+		BaseURL:   s.BaseURL,
+		Version:   s.Version,
+		NoRestart: s.NoRestart,
+	})
+	if err != nil {
+		return err
+	}
+
+	version, updated, err := updater.Update(cmd.Context())
+	if err != nil {
+		return err
+	}
+	if updated {
+		fmt.Printf("llmos is updated to %s\n", version)
+	} else {
+		fmt.Printf("llmos %s is up to date\n", version)
+	}
+	return nil
+}
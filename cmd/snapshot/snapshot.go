@@ -0,0 +1,112 @@
+package snapshot
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/llmos-ai/llmos/utils/cli"
+	"github.com/spf13/cobra"
+
+	"github.com/llmos-ai/llmos/pkg/bootstrap"
+	"github.com/llmos-ai/llmos/pkg/cli/snapshot"
+	"github.com/llmos-ai/llmos/pkg/utils/redact"
+)
+
+func NewSnapshot() *cobra.Command {
+	return cli.Command(&Snapshot{}, cobra.Command{
+		Short: "Manage etcd snapshots of the cluster",
+	},
+		cli.Command(&Save{}, cobra.Command{
+			Short: "Take an on-demand etcd snapshot",
+		}),
+		cli.Command(&List{}, cobra.Command{
+			Short:   "List local and S3 etcd snapshots",
+			Aliases: []string{"ls"},
+		}),
+		cli.Command(&Restore{}, cobra.Command{
+			Use:   "restore [snapshot-name]",
+			Short: "Restore the cluster from an etcd snapshot",
+			Long: "Restore stops the k8s runtime service, resets the cluster to the etcd snapshot and starts " +
+				"the service again. Other server nodes must be stopped and have their db directory removed " +
+				"before rejoining the restored cluster.",
+			Args: cobra.ExactArgs(1),
+		}),
+	)
+}
+
+type Snapshot struct{}
+
+func (s *Snapshot) Run(cmd *cobra.Command, _ []string) error {
+	return cmd.Help()
+}
+
+// Options defines the flags shared by the snapshot commands
+//
+//nolint:lll
+type Options struct {
+	DataDir string `usage:"Path to llmos state dir" default:"/var/lib/llmos" env:"LLMOS_DATA_DIR"`
+	Config  string `usage:"Custom config file path, used to restore the s3 secret redacted from the stamp" default:"/etc/llmos/config.yaml" short:"c" env:"LLMOS_CONFIG_FILE"`
+}
+
+func (o *Options) snapshotter() (*snapshot.Snapshotter, error) {
+	boot := bootstrap.New(bootstrap.Config{DataDir: o.DataDir, ConfigPath: o.Config})
+	cfg, err := boot.LoadDoneConfig()
+	if err != nil {
+		return nil, err
+	}
+	if snap := cfg.EtcdSnapshot; snap != nil && snap.S3 != nil && redact.IsRedacted(snap.S3.SecretKey) {
+		if cfg, err = boot.RestoreSecrets(cfg); err != nil {
+			return nil, err
+		}
+	}
+	return snapshot.New(&cfg)
+}
+
+type Save struct {
+	Options
+	Name string `usage:"Snapshot name prefix, defaults to the runtime default"`
+}
+
+func (s *Save) Run(cmd *cobra.Command, _ []string) error {
+	snapshotter, err := s.snapshotter()
+	if err != nil {
+		return err
+	}
+	return snapshotter.Save(cmd.Context(), s.Name)
+}
+
+type List struct {
+	Options
+}
+
+func (l *List) Run(cmd *cobra.Command, _ []string) error {
+	snapshotter, err := l.snapshotter()
+	if err != nil {
+		return err
+	}
+
+	snapshots, err := snapshotter.List(cmd.Context())
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tLOCATION\tSIZE\tCREATED")
+	for _, s := range snapshots {
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\n", s.Name, s.Location, s.Size, s.Created.Format("2006-01-02T15:04:05Z07:00"))
+	}
+	return w.Flush()
+}
+
+type Restore struct {
+	Options
+}
+
+func (r *Restore) Run(cmd *cobra.Command, args []string) error {
+	snapshotter, err := r.snapshotter()
+	if err != nil {
+		return err
+	}
+	return snapshotter.Restore(cmd.Context(), args[0])
+}
@@ -0,0 +1,36 @@
+package supportbundle
+
+import (
+	"fmt"
+
+	"github.com/llmos-ai/llmos/utils/cli"
+	"github.com/spf13/cobra"
+
+	"github.com/llmos-ai/llmos/pkg/cli/supportbundle"
+)
+
+func NewSupportBundle() *cobra.Command {
+	return cli.Command(&SupportBundle{}, cobra.Command{
+		Use:   "support-bundle",
+		Short: "Collect the node state into a tarball for troubleshooting, secrets are redacted",
+	})
+}
+
+type SupportBundle struct {
+	Config  string `usage:"Custom config file path" default:"/etc/llmos/config.yaml" short:"c" env:"LLMOS_CONFIG_FILE"`
+	DataDir string `usage:"Path to llmos state dir" default:"/var/lib/llmos" env:"LLMOS_DATA_DIR"`
+	Output  string `usage:"Output file of the bundle, defaults to llmos-support-bundle-<hostname>-<time>.tar.gz" short:"o"`
+}
+
+func (s *SupportBundle) Run(cmd *cobra.Command, _ []string) error {
+	output, err := supportbundle.New(supportbundle.Options{
+		DataDir:    s.DataDir,
+		ConfigPath: s.Config,
+		Output:     s.Output,
+	}).Collect(cmd.Context())
+	if err != nil {
+		return err
+	}
+	fmt.Printf("support bundle is written to %s\n", output)
+	return nil
+}
@@ -0,0 +1,44 @@
+package uninstall
+
+import (
+	"fmt"
+
+	"github.com/llmos-ai/llmos/utils/cli"
+	"github.com/spf13/cobra"
+
+	"github.com/llmos-ai/llmos/pkg/cli/uninstall"
+)
+
+func NewUninstall() *cobra.Command {
+	return cli.Command(&Uninstall{}, cobra.Command{
+		Short: "Uninstall the k8s runtime and files installed by the LLMOS bootstrap",
+	})
+}
+
+type Uninstall struct {
+	Root       string `usage:"Target root directory of the installation" default:"/"`
+	DataDir    string `usage:"Path to llmos state dir" default:"/var/lib/llmos" env:"LLMOS_DATA_DIR"`
+	DryRun     bool   `usage:"Print what would be removed without removing anything"`
+	KeepData   bool   `usage:"Keep the llmos state dir"`
+	KeepImages bool   `usage:"Keep the airgap images of the k8s runtime and llmos"`
+}
+
+func (u *Uninstall) Run(cmd *cobra.Command, _ []string) error {
+	actions, err := uninstall.New(uninstall.Options{
+		Root:       u.Root,
+		DataDir:    u.DataDir,
+		DryRun:     u.DryRun,
+		KeepData:   u.KeepData,
+		KeepImages: u.KeepImages,
+	}).Uninstall(cmd.Context())
+	for _, action := range actions {
+		fmt.Println(action)
+	}
+	if err != nil {
+		return err
+	}
+	if len(actions) == 0 {
+		fmt.Println("nothing to uninstall")
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package upgrade
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/llmos-ai/llmos/utils/cli"
+	"github.com/spf13/cobra"
+
+	"github.com/llmos-ai/llmos/pkg/cli/upgrade"
+)
+
+func NewUpgrade() *cobra.Command {
+	return cli.Command(&Upgrade{}, cobra.Command{
+		Short: "Upgrade the Kubernetes runtime and the LLMOS operator of the cluster",
+		Long: "Upgrade creates the system-upgrade-controller plans of the k8s runtime and updates the llmos-operator " +
+			"HelmChart, then waits for the cluster to report the new versions and updates the bootstrapped stamp.",
+	})
+}
+
+type Upgrade struct {
+	DataDir           string `usage:"Path to llmos state dir" default:"/var/lib/llmos" env:"LLMOS_DATA_DIR"`
+	KubernetesVersion string `usage:"Kubernetes version or channel to upgrade to"`
+	OperatorVersion   string `usage:"LLMOS operator version or channel to upgrade to"`
+	DryRun            bool   `usage:"Print the objects to create without applying them"`
+	Timeout           string `usage:"Timeout waiting for the upgrade to complete" default:"30m"`
+}
+
+func (u *Upgrade) Run(cmd *cobra.Command, _ []string) error {
+	timeout, err := time.ParseDuration(u.Timeout)
+	if err != nil {
+		return fmt.Errorf("parsing duration %s: %w", u.Timeout, err)
+	}
+
+	upgrader, err := upgrade.New(upgrade.Options{
+		DataDir:           u.DataDir,
+		KubernetesVersion: u.KubernetesVersion,
+		OperatorVersion:   u.OperatorVersion,
+		DryRun:            u.DryRun,
+		Timeout:           timeout,
+	})
+	if err != nil {
+		return err
+	}
+	return upgrader.Upgrade(cmd.Context())
+}
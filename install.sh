@@ -14,6 +14,9 @@ set -o noglob
 #     curl ... | LLMOS_TOKEN=xxx LLMOS_URL=https://server-url:6443 sh -
 #
 #
+# The downloaded binary installs itself, the service and environment files by
+# "llmos install", the flags of it can be set by the INSTALL_LLMOS_ variables.
+#
 # Environment variables:
 #   - LLMOS_*
 #     Environment variables which begin with LLMOS_ will be preserved for the
@@ -63,20 +66,6 @@ fatal()
 	exit 1
 }
 
-# --- fatal if no systemd ---
-verify_system() {
-	if [ -x /bin/systemctl ] || type systemctl > /dev/null 2>&1; then
-		HAS_SYSTEMD=true
-		return
-	fi
-	if [ -x /sbin/openrc-run ]; then
-		HAS_OPENRC=true
-		return
-	fi
-
-	fatal 'Can not find systemd or openrc to use as a process supervisor for LLMOS'
-}
-
 # --- add quotes to command arguments ---
 quote() {
     for arg in "$@"; do
@@ -84,24 +73,11 @@ quote() {
     done
 }
 
-# --- add indentation and trailing slash to quoted args ---
-quote_indent() {
-    printf ' \\\n'
-    for arg in "$@"; do
-        printf '\t%s \\\n' "$(quote "$arg")"
-    done
-}
-
 # --- escape most punctuation characters, except quotes, forward slash, and space ---
 escape() {
     printf '%s' "$@" | sed -e 's/\([][!#$%&()*;<=>?\_`{|}]\)/\\\1/g;'
 }
 
-# --- escape double quotes ---
-escape_dq() {
-    printf '%s' "$@" | sed -e 's/"/\\"/g'
-}
-
 # --- use sudo if we are not already root ---
 check_sudo() {
     if [ $(id -u) -ne 0 ]; then
@@ -118,20 +94,7 @@ check_sudo() {
 
 # --- define needed environment variables ---
 setup_env() {
-    SYSTEM_NAME=llmos
-
-    CMD_LLMOS_EXEC="$(quote_indent "$@")"
-
-    # --- check for invalid characters in system name ---
-    valid_chars=$(printf '%s' "${SYSTEM_NAME}" | sed -e 's/[][!#$%&()*;<=>?\_`{|}/[:space:]]/^/g;' )
-    if [ "${SYSTEM_NAME}" != "${valid_chars}"  ]; then
-        invalid_chars=$(printf '%s' "${valid_chars}" | sed -e 's/[^^]/ /g')
-        fatal "Invalid characters for system name:
-            ${SYSTEM_NAME}
-            ${invalid_chars}"
-    fi
-
-    # --- use binary install directory if defined or create default ---
+    # --- use binary install directory if defined or use the default ---
     if [ -n "${INSTALL_LLMOS_BIN_DIR}" ]; then
         BIN_DIR=${INSTALL_LLMOS_BIN_DIR}
     else
@@ -143,30 +106,7 @@ setup_env() {
             fi
         fi
     fi
-
-    # --- use systemd directory if defined or create default ---
-    if [ -n "${INSTALL_LLMOS_SYSTEMD_DIR}" ]; then
-        SYSTEMD_DIR="${INSTALL_LLMOS_SYSTEMD_DIR}"
-    else
-        SYSTEMD_DIR=/etc/systemd/system
-    fi
-
-    # --- set related files from system name ---
-    SERVICE_LLMOS=${SYSTEM_NAME}.service
-    UNINSTALL_LLMOS_SH=${UNINSTALL_LLMOS_SH:-${BIN_DIR}/${SYSTEM_NAME}-uninstall.sh}
-
-    # --- use service or environment location depending on systemd/openrc ---
-	if [ "${HAS_SYSTEMD}" = true ]; then
-		FILE_LLMOS_SERVICE=${SYSTEMD_DIR}/${SERVICE_LLMOS}
-		FILE_LLMOS_ENV=${SYSTEMD_DIR}/${SERVICE_LLMOS}.env
-    elif [ "${HAS_OPENRC}" = true ]; then
-		$SUDO mkdir -p /etc/llmos
-		FILE_LLMOS_SERVICE=/etc/init.d/${SYSTEM_NAME}
-		FILE_LLMOS_ENV=/etc/llmos/${SYSTEM_NAME}.env
-    fi
-
-    # --- get hash of config & exec for currently installed llmos ---
-    PRE_INSTALL_HASHES=$(get_installed_hashes)
+    LLMOS_BIN=${BIN_DIR}/llmos
 }
 
 # --- check if skip download environment variable set ---
@@ -333,12 +273,10 @@ verify_binary() {
     fi
 }
 
-# --- setup permissions and move binary to system directory ---
+# --- setup permissions of the downloaded binary, it installs itself by llmos install ---
 setup_binary() {
     chmod 755 ${TMP_BIN}
-    info "Installing llmos to ${BIN_DIR}/llmos"
-    $SUDO chown root:root ${TMP_BIN}
-    $SUDO mv -f ${TMP_BIN} ${BIN_DIR}/llmos
+    LLMOS_BIN=${TMP_BIN}
 }
 
 # --- download and verify llmos ---
@@ -365,207 +303,11 @@ download_and_verify() {
     setup_binary
 }
 
-# --- create uninstall script ---
-create_uninstall() {
-    info "Creating uninstall script ${UNINSTALL_LLMOS_SH}"
-    $SUDO tee ${UNINSTALL_LLMOS_SH} > /dev/null << EOF
-#!/bin/sh
-set -x
-[ \$(id -u) -eq 0 ] || exec sudo \$0 \$@
-
-LLMOS_DATA_DIR=\${LLMOS_DATA_DIR:-/var/lib/llmos}
-KUBE_UNINSTALL=
-
-if command -v systemctl; then
-    systemctl disable ${SYSTEM_NAME}
-    systemctl reset-failed ${SYSTEM_NAME}
-    systemctl daemon-reload
-fi
-if command -v rc-update; then
-    rc-update delete ${SYSTEM_NAME} default
-fi
-
-rm -f ${FILE_LLMOS_SERVICE}
-rm -f ${FILE_LLMOS_ENV}
-
-if [ -f /etc/systemd/system/k3s.service ]; then
-	KUBE_UNINSTALL=k3s-uninstall.sh
-elif [ -f /etc/systemd/system/k3s-agent.service ]; then
-	KUBE_UNINSTALL=k3s-agent-uninstall.sh
-elif [ -f /etc/systemd/system/rke2.service ]; then
-	KUBE_UNINSTALL=rke2-uninstall.sh
-elif [ -f /etc/systemd/system/rke2-agent.service ]; then
-	KUBE_UNINSTALL=rke2-agent-uninstall.sh
-else
-	warn "Kubernetes runtime not found, skipping uninstall k8s runtime."
-fi
-
-if [ -n "\${KUBE_UNINSTALL}" ]; then
-	info "Uninstalling k8s runtime by ${KUBE_UNINSTALL}"
-	$SUDO \${KUBE_UNINSTALL}
-
-	$SUDO rm -rf /var/lib/rancher /etc/rancher
-fi
-
-remove_uninstall() {
-    rm -f ${UNINSTALL_LLMOS_SH}
-}
-trap remove_uninstall EXIT
-
-rm -rf \${LLMOS_DATA_DIR} /var/lib/rook/*llmos*
-rm -f ${BIN_DIR}/llmos
-
-EOF
-    $SUDO chmod 755 ${UNINSTALL_LLMOS_SH}
-    $SUDO chown root:root ${UNINSTALL_LLMOS_SH}
-}
-
-# --- disable current service if loaded --
-systemd_disable() {
-    $SUDO systemctl disable ${SYSTEM_NAME} >/dev/null 2>&1 || true
-    $SUDO rm -f /etc/systemd/system/${SERVICE_LLMOS} || true
-    $SUDO rm -f /etc/systemd/system/${SERVICE_LLMOS}.env || true
-}
-
-# --- capture current env and create file containing LLMOS_ variables ---
-create_env_file() {
-    info "env: Creating environment file ${FILE_LLMOS_ENV}"
-    $SUDO touch "${FILE_LLMOS_ENV}"
-    $SUDO chmod 0600 "${FILE_LLMOS_ENV}"
-    env | grep '^LLMOS_' | $SUDO tee "${FILE_LLMOS_ENV}" >/dev/null
-    env | grep -Ei '^(NO|HTTP|HTTPS)_PROXY' | $SUDO tee -a "${FILE_LLMOS_ENV}" >/dev/null
-}
-
-# --- write systemd or openrc service file ---
-create_service_file() {
-    [ "${HAS_SYSTEMD}" = true ] && create_systemd_service_file
-    [ "${HAS_OPENRC}" = true ] && create_openrc_service_file
-    return 0
-}
-
-# --- write systemd service file ---
-create_systemd_service_file() {
-    info "systemd: Creating service file ${FILE_LLMOS_SERVICE}"
-    $SUDO tee ${FILE_LLMOS_SERVICE} >/dev/null << EOF
-[Unit]
-Description=LLMOS Bootstrap
-Documentation=https://github.com/llmos-ai/llmos
-Wants=network-online.target
-After=network-online.target
-
-[Install]
-WantedBy=multi-user.target
-
-[Service]
-Type=oneshot
-EnvironmentFile=-/etc/default/%N
-EnvironmentFile=-/etc/sysconfig/%N
-EnvironmentFile=-${FILE_LLMOS_ENV}
-KillMode=process
-# Having non-zero Limit*s causes performance problems due to accounting overhead
-# in the kernel. We recommend using cgroups to do container-local accounting.
-LimitNOFILE=1048576
-LimitNPROC=infinity
-LimitCORE=infinity
-TasksMax=infinity
-TimeoutStartSec=0
-ExecStart=${BIN_DIR}/llmos bootstrap ${CMD_LLMOS_EXEC}
-EOF
-}
-
-# --- write openrc service file ---
-create_openrc_service_file() {
-    LOG_FILE=/var/log/${SYSTEM_NAME}.log
-
-    info "openrc: Creating service file ${FILE_LLMOS_SERVICE}"
-    $SUDO tee "${FILE_LLMOS_SERVICE}" >/dev/null << EOF
-#!/sbin/openrc-run
-
-depend() {
-    after network-online
-    want cgroups
-}
-
-start_pre() {
-    rm -f /tmp/llmos.*
-}
-
-supervisor=supervise-daemon
-name=${SYSTEM_NAME}
-command="${BIN_DIR}/llmos"
-command_args="$(escape_dq "${CMD_LLMOS_EXEC}")
-    >>${LOG_FILE} 2>&1"
-
-output_log=${LOG_FILE}
-error_log=${LOG_FILE}
-
-pidfile="/var/run/${SYSTEM_NAME}.pid"
-respawn_delay=5
-respawn_max=0
-
-set -o allexport
-if [ -f /etc/environment ]; then . /etc/environment; fi
-if [ -f ${FILE_LLMOS_ENV} ]; then . ${FILE_LLMOS_ENV}; fi
-set +o allexport
-EOF
-    $SUDO chmod 0755 "${FILE_LLMOS_SERVICE}"
-
-    $SUDO tee /etc/logrotate.d/${SYSTEM_NAME} >/dev/null << EOF
-${LOG_FILE} {
-	missingok
-	notifempty
-	copytruncate
-}
-EOF
-}
-
-# --- get hashes of the current llmos bin and service files
-get_installed_hashes() {
-    $SUDO sha256sum ${BIN_DIR}/llmos ${FILE_LLMOS_SERVICE} ${FILE_LLMOS_ENV} 2>&1 || true
-}
-
-# --- enable and start systemd service ---
-systemd_enable() {
-    info "systemd: Enabling ${SYSTEM_NAME} unit"
-    $SUDO systemctl enable ${FILE_LLMOS_SERVICE} >/dev/null
-    $SUDO systemctl daemon-reload >/dev/null
-}
-
-systemd_start() {
-    info "systemd: Starting ${SYSTEM_NAME}"
-    $SUDO systemctl restart --no-block ${SYSTEM_NAME}
-    info "Run \"journalctl -u ${SYSTEM_NAME} -f\" to watch logs"
-}
-
-# --- enable and start openrc service ---
-openrc_enable() {
-    info "openrc: Enabling ${SYSTEM_NAME} service for default runlevel"
-    $SUDO rc-update add ${SYSTEM_NAME} default >/dev/null
-}
-
-openrc_start() {
-    info "openrc: Starting ${SYSTEM_NAME}"
-    $SUDO "${FILE_LLMOS_SERVICE}" restart
-}
-
-# --- startup systemd or openrc service ---
-service_enable_and_start() {
-    [ "${INSTALL_LLMOS_SKIP_ENABLE}" = true ] && return
-
-    [ "${HAS_SYSTEMD}" = true ] && systemd_enable
-	[ "${HAS_OPENRC}" = true ] && openrc_enable
-
-    [ "${INSTALL_LLMOS_SKIP_START}" = true ] && return
-
-    POST_INSTALL_HASHES=$(get_installed_hashes)
-    if [ "${PRE_INSTALL_HASHES}" = "${POST_INSTALL_HASHES}" ] && [ "${INSTALL_LLMOS_FORCE_RESTART}" != true ]; then
-        info 'No change detected so skipping service start'
-        return
-    fi
-
-    [ "${HAS_SYSTEMD}" = true ] && systemd_start
-	[ "${HAS_OPENRC}" = true ] && openrc_start
-    return 0
+# --- install the binary, service and environment files by llmos install ---
+install_llmos() {
+    info "Installing llmos service by ${LLMOS_BIN} install"
+    # --- preserve the environment, llmos install captures the LLMOS_ variables
+    ${SUDO:+$SUDO -E} "${LLMOS_BIN}" install --bin-dir "${BIN_DIR}" -- "$@"
 }
 
 # --- re-evaluate args to include env command ---
@@ -573,13 +315,8 @@ eval set -- $(escape "${INSTALL_LLMOS_EXEC}") $(quote "$@")
 
 # --- run the install process --
 {
-    verify_system
     check_sudo
-    setup_env "$@"
+    setup_env
     download_and_verify
-    create_uninstall
-    systemd_disable
-    create_env_file
-    create_service_file
-    service_enable_and_start
-}
\ No newline at end of file
+    install_llmos "$@"
+}
@@ -1,12 +1,14 @@
 package main
 
 import (
+	"errors"
 	"os"
 
 	"github.com/pterm/pterm"
 	"sigs.k8s.io/controller-runtime/pkg/manager/signals"
 
 	"github.com/llmos-ai/llmos/cmd"
+	"github.com/llmos-ai/llmos/pkg/cli/probe"
 )
 
 func main() {
@@ -15,6 +17,12 @@ func main() {
 	cmd.SilenceErrors = true
 	if err := cmd.ExecuteContext(ctx); err != nil {
 		pterm.Error.Println(err)
+		// the probe command exits with a distinct code when the probes time out, the exit codes of the
+		// failed child commands are not passed through so that they can not be mistaken for it
+		var timeoutErr *probe.TimeoutError
+		if errors.As(err, &timeoutErr) {
+			os.Exit(timeoutErr.ExitCode())
+		}
 		os.Exit(1)
 	}
 	os.Exit(0)
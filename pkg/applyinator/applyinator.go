@@ -21,10 +21,16 @@ import (
 	"time"
 
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 	"golang.org/x/sync/errgroup"
 
 	"github.com/llmos-ai/llmos/pkg/applyinator/image"
 	"github.com/llmos-ai/llmos/pkg/applyinator/prober"
+	"github.com/llmos-ai/llmos/pkg/utils/metrics"
+	"github.com/llmos-ai/llmos/pkg/utils/redact"
+	"github.com/llmos-ai/llmos/pkg/utils/tracing"
 )
 
 type Applyinator struct {
@@ -32,14 +38,19 @@ type Applyinator struct {
 	workDir         string
 	preserveWorkDir bool
 	appliedPlanDir  string
+	logsDir         string
 	interlockDir    string
 	imageUtil       *image.Utility
+	verifier        *Verifier
 }
 
 // CalculatedPlan is passed into Applyinator and is a Plan with checksum calculated
 type CalculatedPlan struct {
 	Plan     Plan
 	Checksum string
+	// Signature is the detached signature of the raw plan, it is verified on apply when a verifier is set
+	Signature []byte
+	raw       []byte
 }
 
 type Plan struct {
@@ -110,6 +121,11 @@ func NewApplyinator(workDir string, preserveWorkDir bool, appliedPlanDir, interl
 	}
 }
 
+// SetVerifier enables the plan verification, Apply refuses the plans which are not signed by a trusted key
+func (a *Applyinator) SetVerifier(verifier *Verifier) {
+	a.verifier = verifier
+}
+
 func CalculatePlan(rawPlan []byte) (CalculatedPlan, error) {
 	var cp CalculatedPlan
 	var plan Plan
@@ -135,6 +151,10 @@ type ApplyOutput struct {
 	OneTimeApplySucceeded  bool
 	PeriodicOutput         []byte
 	PeriodicApplySucceeded bool
+	// FailedInstruction is the name, or the index if it has no name, of the one-time instruction which
+	// failed the apply and FailedInstructionOutput its combined output
+	FailedInstruction       string
+	FailedInstructionOutput []byte
 }
 
 type ApplyInput struct {
@@ -149,12 +169,33 @@ type ApplyInput struct {
 // Apply accepts a context, calculated plan, a bool to indicate whether to run the onetime instructions, the existing onetimeinstruction output, and an input byte slice which is a base64+gzip json-marshalled map of PeriodicInstructionOutput
 // entries where the key is the PeriodicInstructionOutput.Name. It outputs a revised versions of the existing outputs, and if specified, runs the one time instructions. Notably, ApplyOutput.OneTimeApplySucceeded will be false if ApplyInput.RunOneTimeInstructions is false
 func (a *Applyinator) Apply(ctx context.Context, input ApplyInput) (ApplyOutput, error) {
+	ctx, span := tracing.Start(ctx, "applyinator.Apply", trace.WithAttributes(
+		attribute.String("llmos.plan.checksum", input.CalculatedPlan.Checksum),
+		attribute.Bool("llmos.plan.run_one_time_instructions", input.RunOneTimeInstructions),
+	))
+	output, err := a.apply(ctx, input)
+	span.SetAttributes(
+		attribute.Bool("llmos.plan.one_time_apply_succeeded", output.OneTimeApplySucceeded),
+		attribute.Bool("llmos.plan.periodic_apply_succeeded", output.PeriodicApplySucceeded),
+	)
+	tracing.End(span, err)
+	return output, err
+}
+
+func (a *Applyinator) apply(ctx context.Context, input ApplyInput) (ApplyOutput, error) {
 	logrus.Debugf("[Applyinator] Applying plan with checksum %s", input.CalculatedPlan.Checksum)
-	logrus.Tracef("[Applyinator] Applying plan - attempting to get lock")
 	output := ApplyOutput{
 		OneTimeOutput:  input.ExistingOneTimeOutput,
 		PeriodicOutput: input.ExistingPeriodicOutput,
 	}
+	if a.verifier != nil {
+		verified, err := a.verifier.VerifyPlan(input.CalculatedPlan)
+		if err != nil {
+			return output, fmt.Errorf("refusing to apply plan with checksum %s: %w", input.CalculatedPlan.Checksum, err)
+		}
+		input.CalculatedPlan = verified
+	}
+	logrus.Tracef("[Applyinator] Applying plan - attempting to get lock")
 	a.mu.Lock()
 	logrus.Tracef("[Applyinator] Applying plan - lock achieved")
 	defer a.mu.Unlock()
@@ -167,8 +208,8 @@ func (a *Applyinator) Apply(ctx context.Context, input ApplyInput) (ApplyOutput,
 		restartPendingInterlockFilePath := filepath.Join(a.interlockDir, restartPendingInterlockFile)
 		applyinatorActiveInterlockFilePath := filepath.Join(a.interlockDir, applyinatorActiveInterlockFile)
 		// First off, remove check and remove the active interlock as the applyinator is not actually active
-		if _, err := os.Stat(applyinatorActiveInterlockFile); err == nil {
-			err = os.Remove(applyinatorActiveInterlockFile)
+		if _, err := os.Stat(applyinatorActiveInterlockFilePath); err == nil {
+			err = os.Remove(applyinatorActiveInterlockFilePath)
 			if err != nil {
 				logrus.Errorf("unable to remove applyinator active interlock file %s: %v", applyinatorActiveInterlockFilePath, err)
 			}
@@ -228,6 +269,11 @@ func (a *Applyinator) Apply(ctx context.Context, input ApplyInput) (ApplyOutput,
 			logrus.Errorf("error while applying plan retention policy: %v", err)
 		}
 	}
+	if a.logsDir != "" && input.CalculatedPlan.Checksum != "" {
+		if err := a.logsRetentionPolicy(input.CalculatedPlan.Checksum, planRetentionPolicyCount); err != nil {
+			logrus.Errorf("error while applying instruction logs retention policy: %v", err)
+		}
+	}
 
 	if input.ReconcileFiles {
 		for _, file := range input.CalculatedPlan.Plan.Files {
@@ -269,10 +315,15 @@ func (a *Applyinator) Apply(ctx context.Context, input ApplyInput) (ApplyOutput,
 			logrus.Debugf("[Applyinator] Executing instruction %d attempt %d for plan %s", index, input.OneTimeInstructionAttempts, input.CalculatedPlan.Checksum)
 			executionInstructionDir := filepath.Join(executionDir, input.CalculatedPlan.Checksum+"_"+strconv.Itoa(index))
 			prefix := input.CalculatedPlan.Checksum + "_" + strconv.Itoa(index)
-			executeOutput, _, exitCode, err := a.execute(ctx, prefix, executionInstructionDir, instruction.CommonInstruction, true, input.OneTimeInstructionAttempts)
+			start := time.Now()
+			log := a.openInstructionLog(input.CalculatedPlan.Checksum, index, instruction.CommonInstruction, false)
+			executeOutput, _, exitCode, err := a.execute(ctx, prefix, executionInstructionDir, instruction.CommonInstruction, true, input.OneTimeInstructionAttempts, log)
+			metrics.ObserveInstruction(metrics.InstructionName(instruction.Name, index), metrics.InstructionOneTime, time.Since(start), exitCode)
 			if err != nil || exitCode != 0 {
 				logrus.Errorf("error executing instruction %d %s: %v", index, instruction.Name, err)
 				oneTimeApplySucceeded = false
+				output.FailedInstruction = metrics.InstructionName(instruction.Name, index)
+				output.FailedInstructionOutput = executeOutput
 			}
 			if instruction.Name == "" && instruction.SaveOutput {
 				logrus.Errorf("instruction does not have a name set, cannot save output data")
@@ -361,7 +412,10 @@ func (a *Applyinator) Apply(ctx context.Context, input ApplyInput) (ApplyOutput,
 		logrus.Debugf("[Applyinator] Executing periodic instruction %d for plan %s", index, input.CalculatedPlan.Checksum)
 		executionInstructionDir := filepath.Join(executionDir, input.CalculatedPlan.Checksum+"_"+strconv.Itoa(index))
 		prefix := input.CalculatedPlan.Checksum + "_" + strconv.Itoa(index)
-		stdout, stderr, exitCode, err := a.execute(ctx, prefix, executionInstructionDir, instruction.CommonInstruction, false, failures+1)
+		start := time.Now()
+		log := a.openInstructionLog(input.CalculatedPlan.Checksum, index, instruction.CommonInstruction, true)
+		stdout, stderr, exitCode, err := a.execute(ctx, prefix, executionInstructionDir, instruction.CommonInstruction, false, failures+1, log)
+		metrics.ObserveInstruction(metrics.InstructionName(instruction.Name, index), metrics.InstructionPeriodic, time.Since(start), exitCode)
 		if err != nil || exitCode != 0 {
 			periodicApplySucceeded = false
 		}
@@ -485,6 +539,11 @@ func (a *Applyinator) writePlanToDisk(now time.Time, plan *CalculatedPlan) error
 	}
 
 	file := now.Format(applyinatorDateCodeLayout) + appliedPlanFileSuffix
+	if redact.Enabled() {
+		redacted := *plan
+		redacted.Plan = RedactPlan(plan.Plan)
+		plan = &redacted
+	}
 	anpString, err := json.Marshal(plan)
 	if err != nil {
 		return err
@@ -507,7 +566,31 @@ func (a *Applyinator) writePlanToDisk(now time.Time, plan *CalculatedPlan) error
 	return writeContentToFile(filepath.Join(a.appliedPlanDir, file), os.Getuid(), os.Getgid(), 0600, anpString)
 }
 
-func (a *Applyinator) execute(ctx context.Context, prefix, executionDir string, instruction CommonInstruction, combinedOutput bool, attempt int) ([]byte, []byte, int, error) {
+// execute runs the instruction with its span, the output is also written to the instruction log which is
+// closed when it returns
+func (a *Applyinator) execute(ctx context.Context, prefix, executionDir string, instruction CommonInstruction, combinedOutput bool, attempt int, log *instructionLog) ([]byte, []byte, int, error) {
+	ctx, span := tracing.Start(ctx, "applyinator.execute", trace.WithAttributes(
+		attribute.String("llmos.instruction.name", instruction.Name),
+		attribute.String("llmos.instruction.image", instruction.Image),
+		attribute.String("llmos.instruction.command", instruction.Command),
+		attribute.Int("llmos.instruction.attempt", attempt),
+	))
+	stdout, stderr, exitCode, err := a.runInstruction(ctx, prefix, executionDir, instruction, combinedOutput, attempt, log)
+	if err != nil {
+		log.line("error", err.Error())
+	}
+	log.finish(exitCode)
+	span.SetAttributes(attribute.Int("llmos.instruction.exit_code", exitCode))
+	if err == nil && exitCode != 0 {
+		span.SetStatus(codes.Error, fmt.Sprintf("exit code %d", exitCode))
+	}
+	tracing.End(span, err)
+	return stdout, stderr, exitCode, err
+}
+
+// runInstruction stages the image of the instruction and runs its command, the span of the instruction
+// is passed to the command with the TRACEPARENT env var
+func (a *Applyinator) runInstruction(ctx context.Context, prefix, executionDir string, instruction CommonInstruction, combinedOutput bool, attempt int, log *instructionLog) ([]byte, []byte, int, error) {
 	if instruction.Image == "" {
 		logrus.Infof("[Applyinator] No image provided, creating empty working directory %s", executionDir)
 		if err := CreateDirectory(File{Directory: true, Path: executionDir}); err != nil {
@@ -516,7 +599,7 @@ func (a *Applyinator) execute(ctx context.Context, prefix, executionDir string,
 		}
 	} else {
 		logrus.Infof("[Applyinator] Extracting image %s to directory %s", instruction.Image, executionDir)
-		if err := a.imageUtil.Stage(executionDir, instruction.Image); err != nil {
+		if err := a.imageUtil.Stage(ctx, executionDir, instruction.Image); err != nil {
 			logrus.Errorf("error while staging: %v", err)
 			return nil, nil, -1, err
 		}
@@ -530,12 +613,13 @@ func (a *Applyinator) execute(ctx context.Context, prefix, executionDir string,
 	}
 
 	cmd := exec.CommandContext(ctx, command, instruction.Args...)
-	logrus.Infof("[Applyinator] Running command: %s %v", instruction.Command, instruction.Args)
+	logrus.Infof("[Applyinator] Running command: %s %v", instruction.Command, redact.LogArgs(instruction.Args))
 	cmd.Env = os.Environ()
 	cmd.Env = append(cmd.Env, instruction.Env...)
 	cmd.Env = append(cmd.Env, fmt.Sprintf("%s=%s", cattleAgentExecutionPwdEnvKey, executionDir))
 	cmd.Env = append(cmd.Env, fmt.Sprintf("%s=%d", cattleAgentAttemptKey, attempt))
 	cmd.Env = append(cmd.Env, "PATH="+os.Getenv("PATH")+":"+executionDir)
+	cmd.Env = append(cmd.Env, tracing.Env(ctx)...)
 	cmd.Dir = executionDir
 
 	stdout, err := cmd.StdoutPipe()
@@ -570,10 +654,10 @@ func (a *Applyinator) execute(ctx context.Context, prefix, executionDir string,
 	}
 
 	eg.Go(func() error {
-		return streamLogs("["+prefix+":stdout]", &stdoutBuffer, stdout, stdoutWriteLock)
+		return streamLogs("["+prefix+":stdout]", &stdoutBuffer, stdout, stdoutWriteLock, log, "stdout")
 	})
 	eg.Go(func() error {
-		return streamLogs("["+prefix+":stderr]", &stderrBuffer, stderr, stderrWriteLock)
+		return streamLogs("["+prefix+":stderr]", &stderrBuffer, stderr, stderrWriteLock, log, "stderr")
 	})
 
 	if err := cmd.Start(); err != nil {
@@ -590,16 +674,18 @@ func (a *Applyinator) execute(ctx context.Context, prefix, executionDir string,
 			exitCode = -1
 		}
 	}
-	logrus.Infof("[Applyinator] Command %s %v finished with err: %v and exit code: %d", instruction.Command, instruction.Args, err, exitCode)
+	logrus.Infof("[Applyinator] Command %s %v finished with err: %v and exit code: %d", instruction.Command, redact.LogArgs(instruction.Args), err, exitCode)
 	return stdoutBuffer.Bytes(), stderrBuffer.Bytes(), exitCode, err
 }
 
 // streamLogs accepts a prefix, outputBuffer, reader, and buffer lock and will scan input from the reader and write it
-// to the output buffer while also logging anything that comes from the reader with the prefix.
-func streamLogs(prefix string, outputBuffer *bytes.Buffer, reader io.Reader, lock *sync.Mutex) error {
+// to the output buffer while also logging anything that comes from the reader with the prefix and writing it to the
+// instruction log as the stream.
+func streamLogs(prefix string, outputBuffer *bytes.Buffer, reader io.Reader, lock *sync.Mutex, log *instructionLog, stream string) error {
 	scanner := bufio.NewScanner(reader)
 	for scanner.Scan() {
 		logrus.Infof("%s: %s", prefix, scanner.Text())
+		log.line(stream, scanner.Text())
 		lock.Lock()
 		outputBuffer.Write(append(scanner.Bytes(), []byte("\n")...))
 		lock.Unlock()
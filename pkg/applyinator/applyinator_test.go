@@ -0,0 +1,73 @@
+package applyinator
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+func TestApplyPropagatesTraceToInstructions(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	defer otel.SetTracerProvider(noop.NewTracerProvider())
+
+	dir := t.TempDir()
+	a := NewApplyinator(filepath.Join(dir, "work"), false, filepath.Join(dir, "applied"), "", nil)
+	output, err := a.Apply(context.Background(), ApplyInput{
+		CalculatedPlan: CalculatedPlan{Plan: Plan{OneTimeInstructions: []OneTimeInstruction{{
+			CommonInstruction: CommonInstruction{
+				Name:    "traceparent",
+				Command: "/bin/sh",
+				Args:    []string{"-c", "echo -n $TRACEPARENT"},
+			},
+			SaveOutput: true,
+		}}}},
+		RunOneTimeInstructions: true,
+	})
+	require.NoError(t, err)
+	require.True(t, output.OneTimeApplySucceeded)
+
+	buffer, err := generateByteBufferFromBytes(output.OneTimeOutput)
+	require.NoError(t, err)
+	outputs := map[string][]byte{}
+	require.NoError(t, json.Unmarshal(buffer.Bytes(), &outputs))
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 2)
+	execute, apply := spans[0], spans[1]
+	assert.Equal(t, "applyinator.execute", execute.Name)
+	assert.Equal(t, "applyinator.Apply", apply.Name)
+	assert.Equal(t, apply.SpanContext.SpanID(), execute.Parent.SpanID())
+
+	traceParent := strings.Split(string(outputs["traceparent"]), "-")
+	require.Len(t, traceParent, 4)
+	assert.Equal(t, execute.SpanContext.TraceID().String(), traceParent[1])
+	assert.Equal(t, execute.SpanContext.SpanID().String(), traceParent[2])
+}
+
+func TestApplyReportsFailedInstruction(t *testing.T) {
+	dir := t.TempDir()
+	a := NewApplyinator(filepath.Join(dir, "work"), false, filepath.Join(dir, "applied"), "", nil)
+	output, err := a.Apply(context.Background(), ApplyInput{
+		CalculatedPlan: CalculatedPlan{Plan: Plan{OneTimeInstructions: []OneTimeInstruction{
+			{CommonInstruction: CommonInstruction{Command: "/bin/sh", Args: []string{"-c", "true"}}},
+			{CommonInstruction: CommonInstruction{Command: "/bin/sh", Args: []string{"-c", "echo installing; exit 3"}}},
+		}}},
+		RunOneTimeInstructions: true,
+	})
+	require.NoError(t, err)
+	assert.False(t, output.OneTimeApplySucceeded)
+	assert.Equal(t, "1", output.FailedInstruction)
+	assert.Equal(t, "installing\n", string(output.FailedInstructionOutput))
+}
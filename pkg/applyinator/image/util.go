@@ -1,11 +1,13 @@
 package image
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"runtime"
+	"time"
 
 	"github.com/google/go-containerregistry/pkg/authn"
 	"github.com/google/go-containerregistry/pkg/name"
@@ -15,6 +17,11 @@ import (
 	"github.com/rancher/wharfie/pkg/registries"
 	"github.com/rancher/wharfie/pkg/tarfile"
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/llmos-ai/llmos/pkg/utils/metrics"
+	"github.com/llmos-ai/llmos/pkg/utils/tracing"
 )
 
 const (
@@ -63,7 +70,14 @@ func NewUtility(u *Utility) *Utility {
 	return u
 }
 
-func (u *Utility) Stage(destDir string, imgString string) error {
+// Stage extracts the files of the image to the directory, the image is read from the image tarballs
+// of the images dir or pulled from its registry
+func (u *Utility) Stage(ctx context.Context, destDir string, imgString string) (err error) {
+	ctx, span := tracing.Start(ctx, "image.Stage", trace.WithAttributes(attribute.String("llmos.image", imgString)))
+	defer func() {
+		tracing.End(span, err)
+	}()
+
 	if err := os.MkdirAll(destDir, 0755); err != nil {
 		return err
 	}
@@ -86,7 +100,10 @@ func (u *Utility) Stage(destDir string, imgString string) error {
 	}
 	img = i
 
-	if img == nil {
+	pulled := img == nil
+	span.SetAttributes(attribute.Bool("llmos.image.pulled", pulled))
+	start := time.Now()
+	if pulled {
 		registry, err := registries.GetPrivateRegistries(u.findRegistriesYaml())
 		if err != nil {
 			return err
@@ -128,9 +145,45 @@ func (u *Utility) Stage(destDir string, imgString string) error {
 	}
 
 	logrus.Debugf("[debug] Extracting image %s to %s. image: %+v", image.Name(), destDir, image)
+	if err = extractImage(ctx, img, destDir); err != nil {
+		return err
+	}
+	if pulled {
+		// the remote layers are only fetched while they are extracted
+		size := layersSize(img)
+		span.SetAttributes(attribute.Int64("llmos.image.pulled_bytes", size))
+		metrics.ObserveImagePull(image.Name(), size, time.Since(start))
+	}
+	return nil
+}
+
+func extractImage(ctx context.Context, img v1.Image, destDir string) (err error) {
+	_, span := tracing.Start(ctx, "image.extract")
+	defer func() {
+		tracing.End(span, err)
+	}()
 	return extractFiles(img, destDir)
 }
 
+// layersSize returns the compressed size of the layers of the image
+func layersSize(img v1.Image) int64 {
+	layers, err := img.Layers()
+	if err != nil {
+		logrus.Debugf("getting layers of image: %v", err)
+		return 0
+	}
+	var size int64
+	for _, layer := range layers {
+		layerSize, err := layer.Size()
+		if err != nil {
+			logrus.Debugf("getting size of image layer: %v", err)
+			continue
+		}
+		size += layerSize
+	}
+	return size
+}
+
 func (u *Utility) findRegistriesYaml() string {
 	if _, err := os.Stat(u.AgentRegistriesFile); err == nil {
 		return u.AgentRegistriesFile
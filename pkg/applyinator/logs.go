@@ -0,0 +1,349 @@
+package applyinator
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/llmos-ai/llmos/pkg/utils/redact"
+)
+
+// The instruction logs are appended to <logs dir>/<plan checksum>/<index>-<name>.log, the periodic
+// instructions to periodic-<index>-<name>.log. Every line is prefixed with its RFC3339 UTC timestamp,
+// the output lines are then prefixed with their stream and each execution is enclosed by the
+// "attempt <N> started: <command>" and "attempt <N> finished: exit code <code>" lines
+const (
+	InstructionLogSuffix = ".log"
+	periodicLogPrefix    = "periodic-"
+
+	// maxInstructionLogSize rotates the log to <file>.1 before an execution once it is exceeded, the
+	// previous backups are shifted up to maxInstructionLogBackups
+	maxInstructionLogSize    = 10 << 20
+	maxInstructionLogBackups = 3
+
+	logTimeLayout = "2006-01-02T15:04:05.000000000Z07:00"
+
+	// logTailChunk is the size of the chunks the log is read backwards in to find the last attempt
+	logTailChunk = 64 << 10
+)
+
+var (
+	attemptStarted  = regexp.MustCompile(`^attempt (\d+) started: `)
+	attemptFinished = regexp.MustCompile(`^attempt (\d+) finished: exit code (-?\d+)`)
+	unsafeLogChars  = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+)
+
+// SetLogsDir writes the output of every instruction execution to the log files under the directory,
+// they are kept for the last applied plans like the applied plans
+func (a *Applyinator) SetLogsDir(dir string) {
+	a.logsDir = dir
+}
+
+// InstructionLogFileName returns the log file name of the one-time or periodic instruction
+func InstructionLogFileName(index int, name string, periodic bool) string {
+	file := strconv.Itoa(index)
+	if name = unsafeLogChars.ReplaceAllString(name, "_"); name != "" {
+		file += "-" + name
+	}
+	if periodic {
+		file = periodicLogPrefix + file
+	}
+	return file + InstructionLogSuffix
+}
+
+// instructionLog appends the timestamped output of an instruction execution to its log file
+type instructionLog struct {
+	mu      sync.Mutex
+	f       *os.File
+	attempt int
+}
+
+// openInstructionLog opens the log file of the instruction and starts the next attempt, a nil log is
+// returned if the logs are disabled or the file can not be opened since the logs never fail the apply
+func (a *Applyinator) openInstructionLog(checksum string, index int, instruction CommonInstruction,
+	periodic bool) *instructionLog {
+	if a.logsDir == "" || checksum == "" {
+		return nil
+	}
+
+	path := filepath.Join(a.logsDir, checksum, InstructionLogFileName(index, instruction.Name, periodic))
+	l, err := newInstructionLog(path)
+	if err != nil {
+		logrus.Errorf("error opening instruction log %s: %v", path, err)
+		return nil
+	}
+	l.write(fmt.Sprintf("attempt %d started: %s", l.attempt, redactLogText(instruction.Command+" "+
+		strings.Join(redact.LogArgs(instruction.Args), " "))))
+	return l
+}
+
+func newInstructionLog(path string) (*instructionLog, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+	if err := rotateInstructionLog(path); err != nil {
+		return nil, err
+	}
+
+	last, err := lastLogAttempt(path)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, err
+	}
+	return &instructionLog{f: f, attempt: last + 1}, nil
+}
+
+func rotateInstructionLog(path string) error {
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if info.Size() < maxInstructionLogSize {
+		return nil
+	}
+
+	for i := maxInstructionLogBackups - 1; i > 0; i-- {
+		if err = os.Rename(backupLogFile(path, i), backupLogFile(path, i+1)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return os.Rename(path, backupLogFile(path, 1))
+}
+
+// lastLogAttempt returns the number of the last attempt of the log file and its rotated backups, the
+// newest file with an attempt is read backwards from its end so that only the tail of the log is read
+func lastLogAttempt(path string) (int, error) {
+	files := InstructionLogFiles(path)
+	for i := len(files) - 1; i >= 0; i-- {
+		attempt, err := lastFileAttempt(files[i])
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return 0, err
+		}
+		if attempt > 0 {
+			return attempt, nil
+		}
+	}
+	return 0, nil
+}
+
+func lastFileAttempt(file string) (int, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	info, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+
+	// partial is the beginning of the first line of the chunk read before, it ends in the next chunk
+	var partial []byte
+	buf := make([]byte, logTailChunk)
+	for end := info.Size(); end > 0; {
+		start := max(end-logTailChunk, 0)
+		n, err := f.ReadAt(buf[:end-start], start)
+		if err != nil && err != io.EOF {
+			return 0, fmt.Errorf("reading instruction log %s: %w", file, err)
+		}
+
+		lines := bytes.Split(append(buf[:n:n], partial...), []byte("\n"))
+		first := 0
+		if start > 0 {
+			partial, first = append([]byte(nil), lines[0]...), 1
+		}
+		for i := len(lines) - 1; i >= first; i-- {
+			if attempt := parseLogAttempt(string(lines[i])); attempt > 0 {
+				return attempt, nil
+			}
+		}
+		end = start
+	}
+	return 0, nil
+}
+
+// parseLogAttempt returns the attempt of a started or finished line, 0 for the other lines
+func parseLogAttempt(line string) int {
+	_, text, _ := strings.Cut(line, " ")
+	m := attemptStarted.FindStringSubmatch(text)
+	if m == nil {
+		m = attemptFinished.FindStringSubmatch(text)
+	}
+	if m == nil {
+		return 0
+	}
+	attempt, _ := strconv.Atoi(m[1])
+	return attempt
+}
+
+func backupLogFile(path string, i int) string {
+	return path + "." + strconv.Itoa(i)
+}
+
+// line appends an output line of the stream, the secrets are redacted unless the redaction is disabled
+func (l *instructionLog) line(stream, text string) {
+	if l == nil {
+		return
+	}
+	l.write(stream + " " + redactLogText(text))
+}
+
+func redactLogText(text string) string {
+	if !redact.Enabled() {
+		return text
+	}
+	return redact.Text(text)
+}
+
+// write appends the line unbuffered so that it can be followed while the instruction runs
+func (l *instructionLog) write(text string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, err := l.f.WriteString(time.Now().UTC().Format(logTimeLayout) + " " + text + "\n"); err != nil {
+		logrus.Debugf("error writing instruction log %s: %v", l.f.Name(), err)
+	}
+}
+
+// finish ends the attempt with its exit code and closes the log file
+func (l *instructionLog) finish(exitCode int) {
+	if l == nil {
+		return
+	}
+	l.write(fmt.Sprintf("attempt %d finished: exit code %d", l.attempt, exitCode))
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := l.f.Close(); err != nil {
+		logrus.Errorf("error closing instruction log %s: %v", l.f.Name(), err)
+	}
+}
+
+// LogAttempt is an execution of an instruction read from its log, the exit code is only set once it
+// finished
+type LogAttempt struct {
+	Attempt  int
+	Lines    []string
+	ExitCode *int
+}
+
+// InstructionLogFiles returns the rotated backups of the log file followed by the log file, oldest first
+func InstructionLogFiles(path string) []string {
+	files := make([]string, 0, maxInstructionLogBackups+1)
+	for i := maxInstructionLogBackups; i > 0; i-- {
+		files = append(files, backupLogFile(path, i))
+	}
+	return append(files, path)
+}
+
+// ReadInstructionLog reads the attempts of the log file and its rotated backups, oldest first
+func ReadInstructionLog(path string) ([]LogAttempt, error) {
+	var attempts []LogAttempt
+	for _, file := range InstructionLogFiles(path) {
+		f, err := os.Open(file)
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		scanner := bufio.NewScanner(f)
+		scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			attempts = ParseLogLine(attempts, scanner.Text())
+		}
+		err = scanner.Err()
+		_ = f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("reading instruction log %s: %w", file, err)
+		}
+	}
+	return attempts, nil
+}
+
+// ParseLogLine adds the log line to the attempts, a started line begins a new attempt
+func ParseLogLine(attempts []LogAttempt, line string) []LogAttempt {
+	_, text, _ := strings.Cut(line, " ")
+	if m := attemptStarted.FindStringSubmatch(text); m != nil {
+		attempt, _ := strconv.Atoi(m[1])
+		return append(attempts, LogAttempt{Attempt: attempt, Lines: []string{line}})
+	}
+	if len(attempts) == 0 {
+		// the beginning of the attempt was rotated away
+		attempts = append(attempts, LogAttempt{})
+	}
+	last := &attempts[len(attempts)-1]
+	last.Lines = append(last.Lines, line)
+	if m := attemptFinished.FindStringSubmatch(text); m != nil {
+		exitCode, _ := strconv.Atoi(m[2])
+		last.ExitCode = &exitCode
+	}
+	return attempts
+}
+
+// logsRetentionPolicy removes the logs of the oldest plans, the logs of the current plan are marked
+// as the latest first
+func (a *Applyinator) logsRetentionPolicy(checksum string, retention int) error {
+	if err := os.MkdirAll(filepath.Join(a.logsDir, checksum), 0700); err != nil {
+		return err
+	}
+	now := time.Now()
+	if err := os.Chtimes(filepath.Join(a.logsDir, checksum), now, now); err != nil {
+		return err
+	}
+
+	entries, err := os.ReadDir(a.logsDir)
+	if err != nil {
+		return err
+	}
+	type planLogs struct {
+		name    string
+		modTime time.Time
+	}
+	var plans []planLogs
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		plans = append(plans, planLogs{name: entry.Name(), modTime: info.ModTime()})
+	}
+	if len(plans) <= retention {
+		return nil
+	}
+
+	sort.Slice(plans, func(i, j int) bool {
+		return plans[i].modTime.Before(plans[j].modTime)
+	})
+	for _, p := range plans[:len(plans)-retention] {
+		dir := filepath.Join(a.logsDir, p.name)
+		logrus.Infof("[Applyinator] Removing instruction logs (retention policy count: %d) %s", retention, dir)
+		if err := os.RemoveAll(dir); err != nil {
+			return err
+		}
+	}
+	return nil
+}
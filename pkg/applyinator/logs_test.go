@@ -0,0 +1,154 @@
+package applyinator
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyWritesInstructionLogs(t *testing.T) {
+	dir := t.TempDir()
+	a := NewApplyinator(filepath.Join(dir, "work"), false, filepath.Join(dir, "applied"), "", nil)
+	a.SetLogsDir(filepath.Join(dir, "logs"))
+
+	input := ApplyInput{
+		CalculatedPlan: CalculatedPlan{Checksum: "abc", Plan: Plan{OneTimeInstructions: []OneTimeInstruction{{
+			CommonInstruction: CommonInstruction{
+				Name:    "install k3s",
+				Command: "/bin/sh",
+				Args:    []string{"-c", "echo installing; echo token=K10abc::server:xyz >&2; exit 2"},
+			},
+		}}}},
+		RunOneTimeInstructions: true,
+	}
+	for i := 0; i < 2; i++ {
+		_, err := a.Apply(context.Background(), input)
+		require.NoError(t, err)
+	}
+
+	path := filepath.Join(dir, "logs", "abc", "0-install_k3s.log")
+	attempts, err := ReadInstructionLog(path)
+	require.NoError(t, err)
+	require.Len(t, attempts, 2)
+	assert.Equal(t, 2, attempts[1].Attempt)
+	require.NotNil(t, attempts[1].ExitCode)
+	assert.Equal(t, 2, *attempts[1].ExitCode)
+
+	lines := attempts[1].Lines
+	require.Len(t, lines, 4)
+	ts, text, _ := strings.Cut(lines[0], " ")
+	_, err = time.Parse(logTimeLayout, ts)
+	require.NoError(t, err)
+	assert.Equal(t, "attempt 2 started: /bin/sh -c echo installing; echo token=<redacted> >&2; exit 2", text)
+	assert.Contains(t, strings.Join(lines[1:3], "\n"), " stdout installing")
+	assert.Contains(t, strings.Join(lines[1:3], "\n"), " stderr token=<redacted>")
+	assert.True(t, strings.HasSuffix(lines[3], " attempt 2 finished: exit code 2"))
+}
+
+func TestInstructionLogRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "0-install.log")
+	for i := 1; i <= 2; i++ {
+		l, err := newInstructionLog(path)
+		require.NoError(t, err)
+		assert.Equal(t, i, l.attempt)
+		l.write(fmt.Sprintf("attempt %d started: /bin/true ", l.attempt))
+		l.finish(0)
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0600)
+	require.NoError(t, err)
+	_, err = f.WriteString(strings.Repeat("2024-01-01T00:00:00.000000000Z stdout filling the log\n",
+		maxInstructionLogSize/50))
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	l, err := newInstructionLog(path)
+	require.NoError(t, err)
+	assert.Equal(t, 3, l.attempt)
+	l.finish(1)
+
+	_, err = os.Stat(path + ".1")
+	require.NoError(t, err, "the full log is rotated to the first backup")
+	attempts, err := ReadInstructionLog(path)
+	require.NoError(t, err)
+	last := attempts[len(attempts)-1]
+	require.NotNil(t, last.ExitCode)
+	assert.Equal(t, 1, *last.ExitCode)
+}
+
+func TestLastLogAttempt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "0-install.log")
+	last, err := lastLogAttempt(path)
+	require.NoError(t, err)
+	assert.Equal(t, 0, last)
+
+	// the output of the unfinished attempt spans 3 chunks, the last chunk read cuts its started line
+	outputLine := func(size int) string {
+		return "2024-01-01T00:00:00.000000000Z stdout " + strings.Repeat("x", size-39) + "\n"
+	}
+	output := outputLine(1024-10) + strings.Repeat(outputLine(1024), 3*logTailChunk/1024-1)
+	require.Len(t, output, 3*logTailChunk-10)
+	require.NoError(t, os.WriteFile(backupLogFile(path, 1), []byte(
+		"2024-01-01T00:00:00.000000000Z attempt 6 started: /bin/true\n"+
+			"2024-01-01T00:00:00.000000000Z attempt 6 finished: exit code 0\n"+
+			"2024-01-01T00:00:00.000000000Z attempt 7 started: /bin/sh -c install\n"+output), 0600))
+	last, err = lastLogAttempt(path)
+	require.NoError(t, err)
+	assert.Equal(t, 7, last, "the rotated backup is read if the log is missing")
+
+	require.NoError(t, os.WriteFile(path, []byte(
+		"2024-01-01T00:00:00.000000000Z attempt 8 started: /bin/true\n"+
+			"2024-01-01T00:00:00.000000000Z attempt 8 finished: exit code 0\n"), 0600))
+	last, err = lastLogAttempt(path)
+	require.NoError(t, err)
+	assert.Equal(t, 8, last)
+
+	l, err := newInstructionLog(path)
+	require.NoError(t, err)
+	assert.Equal(t, 9, l.attempt)
+	l.finish(0)
+}
+
+func TestParseLogLine(t *testing.T) {
+	var attempts []LogAttempt
+	for _, line := range []string{
+		"2024-01-01T00:00:00.000000000Z stdout rotated away",
+		"2024-01-01T00:00:01.000000000Z attempt 3 started: /bin/sh -c exit 1",
+		"2024-01-01T00:00:02.000000000Z attempt 3 finished: exit code -1",
+	} {
+		attempts = ParseLogLine(attempts, line)
+	}
+	require.Len(t, attempts, 2)
+	assert.Equal(t, 0, attempts[0].Attempt)
+	assert.Nil(t, attempts[0].ExitCode)
+	assert.Equal(t, 3, attempts[1].Attempt)
+	require.NotNil(t, attempts[1].ExitCode)
+	assert.Equal(t, -1, *attempts[1].ExitCode)
+}
+
+func TestLogsRetentionPolicy(t *testing.T) {
+	dir := t.TempDir()
+	a := &Applyinator{logsDir: dir}
+	old := time.Now().Add(-time.Hour)
+	for _, checksum := range []string{"a", "b", "c"} {
+		require.NoError(t, os.Mkdir(filepath.Join(dir, checksum), 0700))
+		require.NoError(t, os.Chtimes(filepath.Join(dir, checksum), old, old))
+		old = old.Add(time.Minute)
+	}
+
+	require.NoError(t, a.logsRetentionPolicy("a", 2))
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	assert.Equal(t, []string{"a", "c"}, names, "the applied plan is kept as the latest")
+}
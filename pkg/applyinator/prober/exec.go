@@ -0,0 +1,62 @@
+package prober
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+
+	k8sprobe "k8s.io/kubernetes/pkg/probe"
+
+	"github.com/llmos-ai/llmos/pkg/utils/redact"
+)
+
+// ExecAction runs the command and succeeds when it exits with the expected exit code, the command
+// is killed when it runs longer than the timeout of the probe or the probe run is canceled
+type ExecAction struct {
+	Command          []string `json:"command,omitempty"`
+	ExpectedExitCode int      `json:"expectedExitCode,omitempty"` // default 0
+}
+
+// validateExec rejects the secret arguments of the command, e.g. --token=<token>, the secrets must not be
+// persisted with the plan since the probes are run from the plan file
+func validateExec(probe Probe) error {
+	redacted := redact.Args(probe.ExecAction.Command)
+	for i, arg := range probe.ExecAction.Command {
+		if redacted[i] != arg {
+			return fmt.Errorf("command argument %d of probe %s holds a secret, read it from a file instead", i, probe.Name)
+		}
+	}
+	return nil
+}
+
+type execProber struct {
+	action ExecAction
+}
+
+func (e *execProber) Probe(ctx context.Context) (k8sprobe.Result, string, error) {
+	if len(e.action.Command) == 0 {
+		return k8sprobe.Unknown, "", fmt.Errorf("exec probe has no command")
+	}
+
+	output, err := exec.CommandContext(ctx, e.action.Command[0], e.action.Command[1:]...).CombinedOutput()
+	if ctx.Err() != nil {
+		return k8sprobe.Failure, fmt.Sprintf("command is killed: %v", ctx.Err()), nil
+	}
+
+	exitCode := 0
+	if err != nil {
+		exitErr := &exec.ExitError{}
+		if !errors.As(err, &exitErr) {
+			// e.g. the command is not found, which is a failure of the probed component too
+			return k8sprobe.Failure, err.Error(), nil
+		}
+		exitCode = exitErr.ExitCode()
+	}
+
+	if exitCode != e.action.ExpectedExitCode {
+		return k8sprobe.Failure, fmt.Sprintf("command exited with %d, expected %d: %s", exitCode,
+			e.action.ExpectedExitCode, output), nil
+	}
+	return k8sprobe.Success, string(output), nil
+}
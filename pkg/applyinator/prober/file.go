@@ -0,0 +1,42 @@
+package prober
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+
+	k8sprobe "k8s.io/kubernetes/pkg/probe"
+)
+
+// FileAction succeeds when the file exists and, if set, contains the text
+type FileAction struct {
+	Path     string `json:"path,omitempty"`
+	Contains string `json:"contains,omitempty"`
+}
+
+type fileProber struct {
+	action FileAction
+}
+
+func (f *fileProber) Probe(_ context.Context) (k8sprobe.Result, string, error) {
+	if f.action.Path == "" {
+		return k8sprobe.Unknown, "", fmt.Errorf("file probe has no path")
+	}
+
+	if f.action.Contains == "" {
+		if _, err := os.Stat(f.action.Path); err != nil {
+			return k8sprobe.Failure, err.Error(), nil
+		}
+		return k8sprobe.Success, "", nil
+	}
+
+	data, err := os.ReadFile(f.action.Path)
+	if err != nil {
+		return k8sprobe.Failure, err.Error(), nil
+	}
+	if !bytes.Contains(data, []byte(f.action.Contains)) {
+		return k8sprobe.Failure, fmt.Sprintf("%s does not contain %q", f.action.Path, f.action.Contains), nil
+	}
+	return k8sprobe.Success, "", nil
+}
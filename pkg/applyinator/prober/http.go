@@ -0,0 +1,308 @@
+package prober
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"k8s.io/client-go/util/jsonpath"
+	k8sprobe "k8s.io/kubernetes/pkg/probe"
+	k8shttp "k8s.io/kubernetes/pkg/probe/http"
+
+	"github.com/llmos-ai/llmos/pkg/utils/redact"
+)
+
+// HTTPGetAction succeeds when the response status is expected, 200-399 by default, and the body
+// matches the regex and the JSON path assertion if set. Credentials should be read from the
+// header value files instead of being inlined in the plan
+type HTTPGetAction struct {
+	URL        string `json:"url,omitempty"`
+	Insecure   bool   `json:"insecure,omitempty"`
+	ClientCert string `json:"clientCert,omitempty"`
+	ClientKey  string `json:"clientKey,omitempty"`
+	CACert     string `json:"caCert,omitempty"`
+	// Method defaults to GET
+	Method  string       `json:"method,omitempty"`
+	Headers []HTTPHeader `json:"headers,omitempty"`
+	// ExpectedStatus lists the expected status codes, e.g. 200, 200-299 or 2xx
+	ExpectedStatus []string `json:"expectedStatus,omitempty"`
+	BodyRegex      string   `json:"bodyRegex,omitempty"`
+	// BodyJSONPath is a kubectl style JSON path, e.g. {.status.health}, which must exist in the body
+	// and equal BodyJSONValue if set
+	BodyJSONPath  string `json:"bodyJSONPath,omitempty"`
+	BodyJSONValue string `json:"bodyJSONValue,omitempty"`
+}
+
+// HTTPHeader is a request header, the Host header overrides the host of the request. The value is
+// read from ValueFile when set, e.g. a bearer token. The sensitive headers like Authorization must be
+// read from ValueFile since the plans holding the probes are persisted
+type HTTPHeader struct {
+	Name      string `json:"name,omitempty"`
+	Value     string `json:"value,omitempty"`
+	ValueFile string `json:"valueFile,omitempty"`
+}
+
+const (
+	// maxBodyLength is the length of the body read to match, the same as the kubelet probes
+	maxBodyLength = 10 * 1024
+	// maxOutputBodyLength is the length of the body reported in the probe output
+	maxOutputBodyLength = 256
+	maxRedirects        = 10
+)
+
+// validateHTTPGet rejects the URL credentials and the inline values of the sensitive headers, the
+// secrets must not be persisted with the plan since the probes are run from the plan file
+func validateHTTPGet(probe Probe) error {
+	probeURL, err := url.Parse(probe.HTTPGetAction.URL)
+	if err != nil {
+		return fmt.Errorf("invalid url of probe %s: %v", probe.Name, redact.Text(err.Error()))
+	}
+	if probeURL.User != nil {
+		return fmt.Errorf("url of probe %s must not contain credentials, read them from a header valueFile instead",
+			probe.Name)
+	}
+	for _, header := range probe.HTTPGetAction.Headers {
+		if header.Value != "" && redact.IsSensitiveName(header.Name) {
+			return fmt.Errorf("header %s of probe %s must be read from valueFile instead of an inline value",
+				header.Name, probe.Name)
+		}
+	}
+	return nil
+}
+
+type statusRange struct {
+	min, max int
+}
+
+var defaultStatusRanges = []statusRange{{min: http.StatusOK, max: http.StatusBadRequest - 1}}
+
+type httpProber struct {
+	probe Probe
+}
+
+func (h *httpProber) Probe(ctx context.Context) (k8sprobe.Result, string, error) {
+	action := h.probe.HTTPGetAction
+
+	statusRanges, err := parseStatusRanges(action.ExpectedStatus)
+	if err != nil {
+		return k8sprobe.Unknown, "", err
+	}
+	var bodyRegex *regexp.Regexp
+	if action.BodyRegex != "" {
+		if bodyRegex, err = regexp.Compile(action.BodyRegex); err != nil {
+			return k8sprobe.Unknown, "", fmt.Errorf("parsing body regex: %w", err)
+		}
+	}
+	headers, err := action.header()
+	if err != nil {
+		return k8sprobe.Unknown, "", err
+	}
+
+	probeURL, err := url.Parse(action.URL)
+	if err != nil {
+		return k8sprobe.Unknown, "", err
+	}
+	probeRequest, err := k8shttp.NewProbeRequest(probeURL, headers)
+	if err != nil {
+		return k8sprobe.Unknown, "", err
+	}
+	if action.Method != "" {
+		probeRequest.Method = strings.ToUpper(action.Method)
+	}
+	probeRequest = probeRequest.WithContext(ctx)
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig:   h.tlsConfig(),
+			DisableKeepAlives: true,
+			DialContext:       k8sprobe.ProbeDialer().DialContext,
+			Proxy:             http.ProxyURL(nil),
+		},
+		CheckRedirect: checkRedirect,
+	}
+	resp, err := client.Do(probeRequest)
+	if err != nil {
+		return k8sprobe.Failure, err.Error(), nil
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBodyLength))
+	if err != nil {
+		return k8sprobe.Failure, err.Error(), nil
+	}
+	output := fmt.Sprintf("HTTP %d: %s", resp.StatusCode, truncate(body, maxOutputBodyLength))
+
+	if !matchStatus(statusRanges, resp.StatusCode) {
+		return k8sprobe.Failure, "unexpected status, " + output, nil
+	}
+	if bodyRegex != nil && !bodyRegex.Match(body) {
+		return k8sprobe.Failure, fmt.Sprintf("body does not match %q, %s", action.BodyRegex, output), nil
+	}
+	if action.BodyJSONPath != "" {
+		value, err := jsonPathValue(body, action.BodyJSONPath)
+		if err != nil {
+			return k8sprobe.Failure, fmt.Sprintf("evaluating %s: %v, %s", action.BodyJSONPath, err, output), nil
+		}
+		if action.BodyJSONValue != "" && value != action.BodyJSONValue {
+			return k8sprobe.Failure, fmt.Sprintf("%s is %q, expected %q, %s", action.BodyJSONPath, value,
+				action.BodyJSONValue, output), nil
+		}
+	}
+	return k8sprobe.Success, output, nil
+}
+
+func (h *httpProber) tlsConfig() *tls.Config {
+	probe := h.probe
+	if probe.HTTPGetAction.Insecure {
+		return &tls.Config{InsecureSkipVerify: true} //nolint:gosec
+	}
+
+	tlsConfig := tls.Config{}
+	if probe.HTTPGetAction.ClientCert != "" && probe.HTTPGetAction.ClientKey != "" {
+		clientCert, err := tls.LoadX509KeyPair(probe.HTTPGetAction.ClientCert, probe.HTTPGetAction.ClientKey)
+		if err != nil {
+			logrus.Errorf("error loading x509 client cert/key for probe %s (%s/%s): %v", probe.Name, probe.HTTPGetAction.ClientCert, probe.HTTPGetAction.ClientKey, err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{clientCert}
+	}
+
+	caCertPool, err := GetSystemCertPool(probe.Name)
+	if err != nil || caCertPool == nil {
+		caCertPool = x509.NewCertPool()
+		logrus.Errorf("error loading system cert pool for probe (%s): %v", probe.Name, err)
+	}
+
+	if probe.HTTPGetAction.CACert != "" {
+		logrus.Debugf("[DoProbe] adding CA certificate [%s] for probe (%s)", probe.HTTPGetAction.CACert, probe.Name)
+		caCert, err := os.ReadFile(probe.HTTPGetAction.CACert)
+		if err != nil {
+			logrus.Errorf("error loading CA cert for probe (%s) %s: %v", probe.Name, probe.HTTPGetAction.CACert, err)
+		}
+		if !caCertPool.AppendCertsFromPEM(caCert) {
+			logrus.Errorf("error while appending ca cert to pool for probe %s", probe.Name)
+		}
+	}
+
+	tlsConfig.RootCAs = caCertPool
+	return &tlsConfig
+}
+
+func (a HTTPGetAction) header() (http.Header, error) {
+	header := http.Header{}
+	for _, h := range a.Headers {
+		value := h.Value
+		if h.ValueFile != "" {
+			data, err := os.ReadFile(h.ValueFile)
+			if err != nil {
+				return nil, fmt.Errorf("reading value of header %s: %w", h.Name, err)
+			}
+			value = strings.TrimSpace(string(data))
+		}
+		header.Add(h.Name, value)
+	}
+	return header, nil
+}
+
+// checkRedirect only follows the redirects to the same host like the kubelet probes, the response of
+// the other redirects is checked instead
+func checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= maxRedirects {
+		return fmt.Errorf("stopped after %d redirects", maxRedirects)
+	}
+	if req.URL.Hostname() != via[0].URL.Hostname() {
+		return http.ErrUseLastResponse
+	}
+	return nil
+}
+
+func parseStatusRanges(expected []string) ([]statusRange, error) {
+	if len(expected) == 0 {
+		return defaultStatusRanges, nil
+	}
+
+	ranges := make([]statusRange, 0, len(expected))
+	for _, e := range expected {
+		var r statusRange
+		var err error
+		switch {
+		case len(e) == 3 && strings.HasSuffix(strings.ToLower(e), "xx"):
+			r.min, err = strconv.Atoi(e[:1])
+			r.min *= 100
+			r.max = r.min + 99
+		case strings.Contains(e, "-"):
+			minStatus, maxStatus, _ := strings.Cut(e, "-")
+			if r.min, err = strconv.Atoi(strings.TrimSpace(minStatus)); err == nil {
+				r.max, err = strconv.Atoi(strings.TrimSpace(maxStatus))
+			}
+		default:
+			r.min, err = strconv.Atoi(strings.TrimSpace(e))
+			r.max = r.min
+		}
+		if err != nil || r.min < 100 || r.max > 599 || r.min > r.max {
+			return nil, fmt.Errorf("invalid expected status %q", e)
+		}
+		ranges = append(ranges, r)
+	}
+	return ranges, nil
+}
+
+func matchStatus(ranges []statusRange, status int) bool {
+	for _, r := range ranges {
+		if status >= r.min && status <= r.max {
+			return true
+		}
+	}
+	return false
+}
+
+func jsonPathValue(body []byte, path string) (string, error) {
+	if !strings.HasPrefix(path, "{") {
+		path = "{" + path + "}"
+	}
+	parser := jsonpath.New("probe")
+	if err := parser.Parse(path); err != nil {
+		return "", err
+	}
+
+	var data interface{}
+	if err := json.Unmarshal(body, &data); err != nil {
+		return "", fmt.Errorf("decoding body: %w", err)
+	}
+	buf := &bytes.Buffer{}
+	if err := parser.Execute(buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func truncate(body []byte, length int) string {
+	if len(body) <= length {
+		return string(body)
+	}
+	return string(body[:length]) + "..."
+}
+
+// GetSystemCertPool returns a x509.CertPool that contains the
+// root CA certificates if they are present at runtime
+func GetSystemCertPool(probeName string) (*x509.CertPool, error) {
+	caCertPool, err := x509.SystemCertPool()
+	if err != nil {
+		caCertPool = x509.NewCertPool()
+		logrus.Errorf("[GetSystemCertPoolUnix] error loading system cert pool for probe (%s): %v", probeName, err)
+	}
+	if caCertPool == nil {
+		return nil, fmt.Errorf("[GetSystemCertPoolWindows] x509 returned a nil certpool for probe (%s)", probeName)
+	}
+	return caCertPool, nil
+}
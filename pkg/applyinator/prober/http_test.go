@@ -0,0 +1,117 @@
+package prober
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	k8sprobe "k8s.io/kubernetes/pkg/probe"
+)
+
+func TestHTTPProberHeaders(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Host != "llmos.local" || r.Header.Get("Authorization") != "Bearer secret" ||
+			r.Header.Get("X-Probe") != "llmos" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(tokenFile, []byte("Bearer secret\n"), 0600))
+
+	action := HTTPGetAction{
+		URL:    server.URL,
+		Method: "post",
+		Headers: []HTTPHeader{
+			{Name: "Host", Value: "llmos.local"},
+			{Name: "Authorization", ValueFile: tokenFile},
+			{Name: "X-Probe", Value: "llmos"},
+		},
+	}
+	result, output, err := runProber(&httpProber{probe: Probe{HTTPGetAction: action}}, time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, k8sprobe.Success, result, output)
+	assert.Equal(t, "HTTP 200: ok", output)
+
+	action.Headers[1].ValueFile = tokenFile + ".missing"
+	_, _, err = runProber(&httpProber{probe: Probe{HTTPGetAction: action}}, time.Second)
+	assert.Error(t, err)
+
+	action.Headers[1] = HTTPHeader{Name: "Authorization", Value: "Bearer secret"}
+	assert.ErrorContains(t, Probe{Name: "api", HTTPGetAction: action}.Validate(),
+		"header Authorization of probe api must be read from valueFile")
+}
+
+func TestHTTPProberAssertions(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/unavailable", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	mux.HandleFunc("/error", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"status": {"health": "degraded", "members": [{"name": "etcd-0"}]}}`))
+	})
+	mux.HandleFunc("/redirect", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "http://llmos.invalid/", http.StatusFound)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	tests := []struct {
+		name   string
+		action HTTPGetAction
+		result k8sprobe.Result
+	}{
+		{name: "default status", action: HTTPGetAction{URL: "/unavailable"}, result: k8sprobe.Failure},
+		{
+			name:   "expected status class",
+			action: HTTPGetAction{URL: "/unavailable", ExpectedStatus: []string{"2xx", "5xx"}},
+			result: k8sprobe.Success,
+		},
+		{
+			name:   "expected status range",
+			action: HTTPGetAction{URL: "/unavailable", ExpectedStatus: []string{"200-204"}},
+			result: k8sprobe.Failure,
+		},
+		{name: "body regex", action: HTTPGetAction{URL: "/error", BodyRegex: `"health":\s*"ok"`}, result: k8sprobe.Failure},
+		{name: "body regex match", action: HTTPGetAction{URL: "/error", BodyRegex: `etcd-\d`}, result: k8sprobe.Success},
+		{
+			name:   "json path value",
+			action: HTTPGetAction{URL: "/error", BodyJSONPath: "{.status.health}", BodyJSONValue: "ok"},
+			result: k8sprobe.Failure,
+		},
+		{
+			name:   "json path match",
+			action: HTTPGetAction{URL: "/error", BodyJSONPath: ".status.members[0].name", BodyJSONValue: "etcd-0"},
+			result: k8sprobe.Success,
+		},
+		{name: "json path missing", action: HTTPGetAction{URL: "/error", BodyJSONPath: ".status.leader"}, result: k8sprobe.Failure},
+		{name: "non local redirect", action: HTTPGetAction{URL: "/redirect"}, result: k8sprobe.Success},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.action.URL = server.URL + tt.action.URL
+			result, output, err := runProber(&httpProber{probe: Probe{HTTPGetAction: tt.action}}, time.Second)
+			require.NoError(t, err)
+			assert.Equal(t, tt.result, result, output)
+		})
+	}
+}
+
+func TestParseStatusRanges(t *testing.T) {
+	ranges, err := parseStatusRanges([]string{"204", "3xx", "400-404"})
+	require.NoError(t, err)
+	assert.Equal(t, []statusRange{{204, 204}, {300, 399}, {400, 404}}, ranges)
+
+	for _, invalid := range []string{"abc", "6xx", "404-400", "99"} {
+		_, err = parseStatusRanges([]string{invalid})
+		assert.Error(t, err, invalid)
+	}
+}
@@ -0,0 +1,149 @@
+package prober
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/restmapper"
+	"k8s.io/client-go/tools/clientcmd"
+	k8sprobe "k8s.io/kubernetes/pkg/probe"
+)
+
+const (
+	defaultCondition       = "Ready"
+	defaultConditionStatus = "True"
+	defaultNamespace       = "default"
+)
+
+// KubernetesAction succeeds when the object has the condition with the status, e.g. the Available
+// condition of a Deployment. The kubeconfig defaults to the KUBECONFIG env and ~/.kube/config
+type KubernetesAction struct {
+	Kubeconfig string `json:"kubeconfig,omitempty"`
+	APIVersion string `json:"apiVersion,omitempty"`
+	Kind       string `json:"kind,omitempty"`
+	Namespace  string `json:"namespace,omitempty"`
+	Name       string `json:"name,omitempty"`
+	Condition  string `json:"condition,omitempty"` // default Ready
+	Status     string `json:"status,omitempty"`    // default True
+}
+
+type kubernetesClient struct {
+	dynamic dynamic.Interface
+	mapper  meta.RESTMapper
+}
+
+// the clients are kept per kubeconfig so the API discovery is not repeated on every probe
+var (
+	kubernetesClientsMu sync.Mutex
+	kubernetesClients   = map[string]*kubernetesClient{}
+)
+
+func getKubernetesClient(kubeconfig string) (*kubernetesClient, error) {
+	kubernetesClientsMu.Lock()
+	defer kubernetesClientsMu.Unlock()
+
+	if client, ok := kubernetesClients[kubeconfig]; ok {
+		return client, nil
+	}
+
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	rules.ExplicitPath = kubeconfig
+	restConfig, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, nil).ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("loading kubeconfig %s: %w", kubeconfig, err)
+	}
+	dynamicClient, err := dynamic.NewForConfig(restConfig)
+	if err != nil {
+		return nil, err
+	}
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(restConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	client := &kubernetesClient{
+		dynamic: dynamicClient,
+		mapper:  restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(discoveryClient)),
+	}
+	kubernetesClients[kubeconfig] = client
+	return client, nil
+}
+
+type kubernetesProber struct {
+	action KubernetesAction
+	client *kubernetesClient
+}
+
+func (k *kubernetesProber) Probe(ctx context.Context) (k8sprobe.Result, string, error) {
+	action := k.action
+	if action.Kind == "" || action.Name == "" {
+		return k8sprobe.Unknown, "", fmt.Errorf("kubernetes probe requires the kind and name of the object")
+	}
+
+	if k.client == nil {
+		client, err := getKubernetesClient(action.Kubeconfig)
+		if err != nil {
+			// the apiserver may not be reachable while the cluster is starting
+			return k8sprobe.Failure, err.Error(), nil
+		}
+		k.client = client
+	}
+
+	gv, err := schema.ParseGroupVersion(action.APIVersion)
+	if err != nil {
+		return k8sprobe.Unknown, "", err
+	}
+	mapping, err := k.client.mapper.RESTMapping(gv.WithKind(action.Kind).GroupKind(), gv.Version)
+	if err != nil {
+		return k8sprobe.Failure, err.Error(), nil
+	}
+
+	var resource dynamic.ResourceInterface = k.client.dynamic.Resource(mapping.Resource)
+	if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+		namespace := action.Namespace
+		if namespace == "" {
+			namespace = defaultNamespace
+		}
+		resource = k.client.dynamic.Resource(mapping.Resource).Namespace(namespace)
+	}
+	obj, err := resource.Get(ctx, action.Name, metav1.GetOptions{})
+	if err != nil {
+		return k8sprobe.Failure, err.Error(), nil
+	}
+	return checkCondition(obj, action)
+}
+
+func checkCondition(obj *unstructured.Unstructured, action KubernetesAction) (k8sprobe.Result, string, error) {
+	conditionType, status := action.Condition, action.Status
+	if conditionType == "" {
+		conditionType = defaultCondition
+	}
+	if status == "" {
+		status = defaultConditionStatus
+	}
+
+	conditions, _, err := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	if err != nil {
+		return k8sprobe.Failure, err.Error(), nil
+	}
+	for _, c := range conditions {
+		condition, ok := c.(map[string]interface{})
+		if !ok || condition["type"] != conditionType {
+			continue
+		}
+		if condition["status"] == status {
+			return k8sprobe.Success, "", nil
+		}
+		return k8sprobe.Failure, fmt.Sprintf("%s %s condition %s is %v, expected %s: %v", action.Kind,
+			obj.GetName(), conditionType, condition["status"], status, condition["message"]), nil
+	}
+	return k8sprobe.Failure, fmt.Sprintf("%s %s has no %s condition", action.Kind, obj.GetName(), conditionType), nil
+}
@@ -1,107 +1,144 @@
 package prober
 
 import (
-	"crypto/tls"
-	"crypto/x509"
+	"context"
 	"fmt"
-	"net/http"
-	"net/url"
-	"os"
 	"sync"
 	"time"
 
 	"github.com/sirupsen/logrus"
 	k8sprobe "k8s.io/kubernetes/pkg/probe"
-	k8shttp "k8s.io/kubernetes/pkg/probe/http"
+
+	"github.com/llmos-ai/llmos/pkg/utils/metrics"
 )
 
-type HTTPGetAction struct {
-	URL        string `json:"url,omitempty"`
-	Insecure   bool   `json:"insecure,omitempty"`
-	ClientCert string `json:"clientCert,omitempty"`
-	ClientKey  string `json:"clientKey,omitempty"`
-	CACert     string `json:"caCert,omitempty"`
-}
+const (
+	defaultTimeout = time.Second
+	defaultPeriod  = 10 * time.Second
+)
 
+// Probe checks the health of a component with exactly one of its actions
 type Probe struct {
-	Name                string        `json:"name,omitempty"`
-	InitialDelaySeconds int           `json:"initialDelaySeconds,omitempty"` // default 0
-	TimeoutSeconds      int           `json:"timeoutSeconds,omitempty"`      // default 1
-	SuccessThreshold    int           `json:"successThreshold,omitempty"`    // default 1
-	FailureThreshold    int           `json:"failureThreshold,omitempty"`    // default 3
-	HTTPGetAction       HTTPGetAction `json:"httpGet,omitempty"`
+	Name                string            `json:"name,omitempty"`
+	InitialDelaySeconds int               `json:"initialDelaySeconds,omitempty"` // default 0
+	TimeoutSeconds      int               `json:"timeoutSeconds,omitempty"`      // default 1
+	SuccessThreshold    int               `json:"successThreshold,omitempty"`    // default 1
+	FailureThreshold    int               `json:"failureThreshold,omitempty"`    // default 3
+	PeriodSeconds       int               `json:"periodSeconds,omitempty"`       // default 10, only used by the Runner
+	HTTPGetAction       HTTPGetAction     `json:"httpGet,omitempty"`
+	TCPSocketAction     *TCPSocketAction  `json:"tcpSocket,omitempty"`
+	ExecAction          *ExecAction       `json:"exec,omitempty"`
+	FileAction          *FileAction       `json:"file,omitempty"`
+	KubernetesAction    *KubernetesAction `json:"kubernetes,omitempty"`
+}
+
+// Prober runs the action of a probe once until the context is done, a failed check is reported as a
+// k8sprobe.Failure result and an error is only returned when the action can not be run at all
+type Prober interface {
+	Probe(ctx context.Context) (k8sprobe.Result, string, error)
+}
+
+// Validate checks that the probe has exactly one action
+func (p Probe) Validate() error {
+	_, err := newProber(p)
+	return err
+}
+
+func newProber(probe Probe) (Prober, error) {
+	var probers []Prober
+	if probe.HTTPGetAction.URL != "" {
+		if err := validateHTTPGet(probe); err != nil {
+			return nil, err
+		}
+		probers = append(probers, &httpProber{probe: probe})
+	}
+	if probe.TCPSocketAction != nil {
+		probers = append(probers, &tcpProber{action: *probe.TCPSocketAction})
+	}
+	if probe.ExecAction != nil {
+		if err := validateExec(probe); err != nil {
+			return nil, err
+		}
+		probers = append(probers, &execProber{action: *probe.ExecAction})
+	}
+	if probe.FileAction != nil {
+		probers = append(probers, &fileProber{action: *probe.FileAction})
+	}
+	if probe.KubernetesAction != nil {
+		probers = append(probers, &kubernetesProber{action: *probe.KubernetesAction})
+	}
+
+	switch len(probers) {
+	case 0:
+		return nil, fmt.Errorf("probe %s has no action", probe.Name)
+	case 1:
+		return probers[0], nil
+	default:
+		return nil, fmt.Errorf("probe %s must have exactly one action, got %d", probe.Name, len(probers))
+	}
 }
 
 type ProbeStatus struct {
 	Healthy      bool `json:"healthy,omitempty"`
 	SuccessCount int  `json:"successCount,omitempty"`
 	FailureCount int  `json:"failureCount,omitempty"`
+	// LastError is the failure reason, or the error running the probe, of the last run if it failed
+	LastError  string `json:"lastError,omitempty"`
+	LastOutput string `json:"lastOutput,omitempty"`
 }
 
-func DoProbe(probe Probe, probeStatus *ProbeStatus, initial bool) error {
+// DoProbe waits for the initial delay if initial and runs the probe once with its timeout, the
+// probe status is updated with the result. It returns the context error if the context is done
+func DoProbe(ctx context.Context, probe Probe, probeStatus *ProbeStatus, initial bool) error {
 	logrus.Tracef("Running probe %+v", probe)
 	if initial {
 		initialDelayDuration := time.Duration(probe.InitialDelaySeconds) * time.Second
 		logrus.Debugf("[Probe: %s] Sleeping for %.0f seconds before running probe", probe.Name, initialDelayDuration.Seconds())
-		time.Sleep(initialDelayDuration)
-	}
-
-	var k8sProber k8shttp.Prober
-
-	if probe.HTTPGetAction.Insecure {
-		k8sProber = k8shttp.New(false)
-	} else {
-		tlsConfig := tls.Config{}
-		if probe.HTTPGetAction.ClientCert != "" && probe.HTTPGetAction.ClientKey != "" {
-			clientCert, err := tls.LoadX509KeyPair(probe.HTTPGetAction.ClientCert, probe.HTTPGetAction.ClientKey)
-			if err != nil {
-				logrus.Errorf("error loading x509 client cert/key for probe %s (%s/%s): %v", probe.Name, probe.HTTPGetAction.ClientCert, probe.HTTPGetAction.ClientKey, err)
-			}
-			tlsConfig.Certificates = []tls.Certificate{clientCert}
+		if err := sleep(ctx, initialDelayDuration); err != nil {
+			return err
 		}
-
-		caCertPool, err := GetSystemCertPool(probe.Name)
-		if err != nil || caCertPool == nil {
-			caCertPool = x509.NewCertPool()
-			logrus.Errorf("error loading system cert pool for probe (%s): %v", probe.Name, err)
-		}
-
-		if probe.HTTPGetAction.CACert != "" {
-			logrus.Debugf("[DoProbe] adding CA certificate [%s] for probe (%s)", probe.HTTPGetAction.CACert, probe.Name)
-			caCert, err := os.ReadFile(probe.HTTPGetAction.CACert)
-			if err != nil {
-				logrus.Errorf("error loading CA cert for probe (%s) %s: %v", probe.Name, probe.HTTPGetAction.CACert, err)
-			}
-			if !caCertPool.AppendCertsFromPEM(caCert) {
-				logrus.Errorf("error while appending ca cert to pool for probe %s", probe.Name)
-			}
-		}
-
-		tlsConfig.RootCAs = caCertPool
-		k8sProber = k8shttp.NewWithTLSConfig(&tlsConfig, false)
 	}
 
-	probeURL, err := url.Parse(probe.HTTPGetAction.URL)
-	if err != nil {
-		return err
-	}
-
-	probeRequest, err := k8shttp.NewProbeRequest(probeURL, http.Header{})
+	p, err := newProber(probe)
 	if err != nil {
+		probeStatus.LastError = err.Error()
 		return err
 	}
 
 	probeDuration := time.Duration(probe.TimeoutSeconds) * time.Second
+	if probeDuration <= 0 {
+		probeDuration = defaultTimeout
+	}
 	logrus.Tracef("[Probe: %s] timeout duration: %.0f seconds", probe.Name, probeDuration.Seconds())
 
-	probeResult, output, err := k8sProber.Probe(probeRequest, probeDuration)
+	probeCtx, cancel := context.WithTimeout(ctx, probeDuration)
+	defer cancel()
+	probeResult, output, err := p.Probe(probeCtx)
+	if ctx.Err() != nil {
+		// the run is canceled, the result says nothing about the health of the probe
+		return ctx.Err()
+	}
 	if err != nil {
 		logrus.Errorf("error while running probe (%s): %v", probe.Name, err)
+		probeStatus.LastError = err.Error()
+		metrics.SetProbeHealthy(probe.Name, probeStatus.Healthy)
 		return err
 	}
 
 	logrus.Debugf("[Probe: %s] output was %s", probe.Name, output)
+	probeStatus.LastOutput = output
+	probeStatus.LastError = ""
+	if probeResult != k8sprobe.Success {
+		probeStatus.LastError = output
+	}
+	updateStatus(probe, probeStatus, probeResult)
+	metrics.SetProbeHealthy(probe.Name, probeStatus.Healthy)
+	return nil
+}
 
+// updateStatus applies the result to the status, the probe becomes healthy after SuccessThreshold
+// consecutive successes and unhealthy after FailureThreshold consecutive failures
+func updateStatus(probe Probe, probeStatus *ProbeStatus, probeResult k8sprobe.Result) {
 	var successThreshold, failureThreshold int
 
 	if probe.SuccessThreshold == 0 {
@@ -140,57 +177,123 @@ func DoProbe(probe Probe, probeStatus *ProbeStatus, initial bool) error {
 		}
 		probeStatus.SuccessCount = 0
 	}
-
-	return nil
 }
 
-// GetSystemCertPool returns a x509.CertPool that contains the
-// root CA certificates if they are present at runtime
-func GetSystemCertPool(probeName string) (*x509.CertPool, error) {
-	caCertPool, err := x509.SystemCertPool()
-	if err != nil {
-		caCertPool = x509.NewCertPool()
-		logrus.Errorf("[GetSystemCertPoolUnix] error loading system cert pool for probe (%s): %v", probeName, err)
-	}
-	if caCertPool == nil {
-		return nil, fmt.Errorf("[GetSystemCertPoolWindows] x509 returned a nil certpool for probe (%s)", probeName)
-	}
-	return caCertPool, nil
-}
-
-func DoProbes(probes map[string]Probe, probeStatuses map[string]ProbeStatus, initial bool) {
+// DoProbes runs each probe once in parallel, the initial delay of each probe is waited for
+// independently. The statuses are only written by DoProbes until it returns
+func DoProbes(ctx context.Context, probes map[string]Probe, probeStatuses map[string]ProbeStatus, initial bool) {
 	var wg sync.WaitGroup
 	var mu sync.Mutex
 
 	for probeName, probe := range probes {
+		mu.Lock()
+		probeStatus, ok := probeStatuses[probeName]
+		mu.Unlock()
+		if !ok {
+			logrus.Tracef("[Prober] (%s) probe status was not present in map, initializing", probeName)
+		}
+
 		wg.Add(1)
-		go func(probeName string, probe Probe) {
+		go func(probeName string, probe Probe, probeStatus ProbeStatus) {
 			defer wg.Done()
 			logrus.Debugf("[Prober] (%s) running probe", probeName)
 
-			mu.Lock()
-			defer mu.Unlock()
-
-			//defer logrus.Tracef("[Prober] (%s) retrieving existing probe status from map if existing", probeName)
-			probeStatus, ok := probeStatuses[probeName]
-			//mu.Unlock()
-			if !ok {
-				logrus.Tracef("[Prober] (%s) probe status was not present in map, initializing", probeName)
-				probeStatus = ProbeStatus{}
-			}
-
 			probe.Name = probeName
-			if err := DoProbe(probe, &probeStatus, initial); err != nil {
+			if err := DoProbe(ctx, probe, &probeStatus, initial); err != nil {
+				if ctx.Err() != nil {
+					return
+				}
 				logrus.Errorf("error running probe %s", probeName)
 			}
 
-			//mu.Lock()
 			logrus.Tracef("[Prober] (%s) writing probe status to map", probeName)
+			mu.Lock()
 			probeStatuses[probeName] = probeStatus
-			//mu.Unlock()
-		}(probeName, probe)
+			mu.Unlock()
+		}(probeName, probe, probeStatus)
 	}
 
 	// wait for all probes to complete
 	wg.Wait()
 }
+
+// Runner runs each probe on its own period, every PeriodSeconds after its initial delay, until the
+// context is canceled. The statuses can be read while it runs
+type Runner struct {
+	probes map[string]Probe
+
+	mu       sync.RWMutex
+	statuses map[string]ProbeStatus
+}
+
+func NewRunner(probes map[string]Probe) *Runner {
+	return &Runner{
+		probes:   probes,
+		statuses: map[string]ProbeStatus{},
+	}
+}
+
+// Run blocks until the context is canceled and all probes are stopped
+func (r *Runner) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for probeName, probe := range r.probes {
+		probe.Name = probeName
+		wg.Add(1)
+		go func(probe Probe) {
+			defer wg.Done()
+			r.run(ctx, probe)
+		}(probe)
+	}
+	wg.Wait()
+}
+
+func (r *Runner) run(ctx context.Context, probe Probe) {
+	period := time.Duration(probe.PeriodSeconds) * time.Second
+	if period <= 0 {
+		period = defaultPeriod
+	}
+
+	probeStatus := ProbeStatus{}
+	for initial := true; ; initial = false {
+		err := DoProbe(ctx, probe, &probeStatus, initial)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			logrus.Errorf("error running probe %s: %v", probe.Name, err)
+		}
+		r.mu.Lock()
+		r.statuses[probe.Name] = probeStatus
+		r.mu.Unlock()
+
+		if sleep(ctx, period) != nil {
+			return
+		}
+	}
+}
+
+// Statuses returns a copy of the statuses of the probes which have run
+func (r *Runner) Statuses() map[string]ProbeStatus {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	statuses := make(map[string]ProbeStatus, len(r.statuses))
+	for name, status := range r.statuses {
+		statuses[name] = status
+	}
+	return statuses
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
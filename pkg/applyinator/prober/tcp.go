@@ -0,0 +1,34 @@
+package prober
+
+import (
+	"context"
+	"strings"
+
+	k8sprobe "k8s.io/kubernetes/pkg/probe"
+)
+
+const unixSocketPrefix = "unix://"
+
+// TCPSocketAction succeeds when a connection to the address can be opened, the address is either
+// host:port or unix:///path/to/socket, e.g. the containerd socket
+type TCPSocketAction struct {
+	Address string `json:"address,omitempty"`
+}
+
+type tcpProber struct {
+	action TCPSocketAction
+}
+
+func (t *tcpProber) Probe(ctx context.Context) (k8sprobe.Result, string, error) {
+	network, address := "tcp", t.action.Address
+	if strings.HasPrefix(address, unixSocketPrefix) {
+		network, address = "unix", strings.TrimPrefix(address, unixSocketPrefix)
+	}
+
+	conn, err := k8sprobe.ProbeDialer().DialContext(ctx, network, address)
+	if err != nil {
+		return k8sprobe.Failure, err.Error(), nil
+	}
+	_ = conn.Close()
+	return k8sprobe.Success, "", nil
+}
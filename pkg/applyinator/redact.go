@@ -0,0 +1,42 @@
+package applyinator
+
+import (
+	"encoding/base64"
+
+	"github.com/llmos-ai/llmos/pkg/utils/redact"
+)
+
+// RedactPlan redacts the instructions and the decoded content of the plan files. The probes are kept
+// as they are run from the persisted plan, their secrets are rejected by the probe validation instead
+func RedactPlan(plan Plan) Plan {
+	files := make([]File, 0, len(plan.Files))
+	for _, file := range plan.Files {
+		if content, err := base64.StdEncoding.DecodeString(file.Content); err == nil && len(content) > 0 {
+			file.Content = base64.StdEncoding.EncodeToString(redact.YAML(content))
+		}
+		files = append(files, file)
+	}
+	plan.Files = files
+
+	instructions := make([]OneTimeInstruction, 0, len(plan.OneTimeInstructions))
+	for _, instruction := range plan.OneTimeInstructions {
+		instruction.CommonInstruction = redactInstruction(instruction.CommonInstruction)
+		instructions = append(instructions, instruction)
+	}
+	plan.OneTimeInstructions = instructions
+
+	periodic := make([]PeriodicInstruction, 0, len(plan.PeriodicInstructions))
+	for _, instruction := range plan.PeriodicInstructions {
+		instruction.CommonInstruction = redactInstruction(instruction.CommonInstruction)
+		periodic = append(periodic, instruction)
+	}
+	plan.PeriodicInstructions = periodic
+
+	return plan
+}
+
+func redactInstruction(instruction CommonInstruction) CommonInstruction {
+	instruction.Env = redact.Env(instruction.Env)
+	instruction.Args = redact.Args(instruction.Args)
+	return instruction
+}
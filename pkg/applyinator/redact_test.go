@@ -0,0 +1,43 @@
+package applyinator
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/llmos-ai/llmos/pkg/applyinator/prober"
+)
+
+func TestRedactPlan(t *testing.T) {
+	plan := Plan{
+		Files: []File{{Path: "/etc/rancher/k3s/config.yaml",
+			Content: base64.StdEncoding.EncodeToString([]byte("token: abc\n"))}},
+		OneTimeInstructions: []OneTimeInstruction{{CommonInstruction: CommonInstruction{
+			Args: []string{"--token", "abc"},
+			Env:  []string{"LLMOS_TOKEN=abc"},
+		}}},
+		Probes: map[string]prober.Probe{
+			"api": {HTTPGetAction: prober.HTTPGetAction{
+				URL: "https://127.0.0.1:6443/readyz",
+				Headers: []prober.HTTPHeader{
+					{Name: "Authorization", ValueFile: "/var/lib/llmos/token"},
+					{Name: "Accept", Value: "application/json"},
+				},
+			}},
+			"etcd": {ExecAction: &prober.ExecAction{Command: []string{"etcdctl", "--password-file=/etc/etcd/password"}}},
+		},
+	}
+
+	redacted := RedactPlan(plan)
+	content, _ := base64.StdEncoding.DecodeString(redacted.Files[0].Content)
+	assert.Equal(t, "token: <redacted>\n", string(content))
+	assert.Equal(t, []string{"--token", "<redacted>"}, redacted.OneTimeInstructions[0].Args)
+	assert.Equal(t, []string{"LLMOS_TOKEN=<redacted>"}, redacted.OneTimeInstructions[0].Env)
+
+	// the probes are run from the persisted plan
+	assert.Equal(t, plan.Probes, redacted.Probes)
+
+	// the plan is not modified
+	assert.Equal(t, "abc", plan.OneTimeInstructions[0].Args[1])
+}
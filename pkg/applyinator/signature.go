@@ -0,0 +1,211 @@
+package applyinator
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// SignatureSuffix is appended to the plan file name to find its detached signature
+const SignatureSuffix = ".sig"
+
+var (
+	ErrUnsignedPlan = errors.New("plan is not signed")
+	ErrUntrustedKey = errors.New("plan is signed by an untrusted key")
+	ErrInvalidSig   = errors.New("plan signature is invalid")
+)
+
+// Signature is the detached ed25519 signature of the raw plan content, the KeyID selects the trusted key
+// to verify with so that multiple keys can be trusted while they are rotated
+type Signature struct {
+	KeyID     string `json:"keyID,omitempty"`
+	Signature []byte `json:"signature"`
+}
+
+// KeyID returns the ID of the public key, the first 16 hex characters of its sha256
+func KeyID(key ed25519.PublicKey) string {
+	sum := sha256.Sum256(key)
+	return fmt.Sprintf("%x", sum[:8])
+}
+
+// SignPlan returns the encoded detached signature of the raw plan
+func SignPlan(rawPlan []byte, key ed25519.PrivateKey) ([]byte, error) {
+	return json.Marshal(Signature{
+		KeyID:     KeyID(key.Public().(ed25519.PublicKey)),
+		Signature: ed25519.Sign(key, rawPlan),
+	})
+}
+
+// CalculateSignedPlan calculates the plan and keeps the raw content and its signature to verify the plan on apply
+func CalculateSignedPlan(rawPlan, signature []byte) (CalculatedPlan, error) {
+	cp, err := CalculatePlan(rawPlan)
+	if err != nil {
+		return cp, err
+	}
+	cp.Signature = signature
+	cp.raw = rawPlan
+	return cp, nil
+}
+
+// Verifier verifies the plan signatures with the trusted ed25519 public keys
+type Verifier struct {
+	keys map[string]ed25519.PublicKey
+}
+
+func NewVerifier(keys ...ed25519.PublicKey) (*Verifier, error) {
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("at least one trusted key is required to verify the plans")
+	}
+	v := &Verifier{keys: map[string]ed25519.PublicKey{}}
+	for _, key := range keys {
+		v.keys[KeyID(key)] = key
+	}
+	return v, nil
+}
+
+// NewVerifierFromDir trusts the public keys of the *.pub and *.pem files in the directory
+func NewVerifierFromDir(dir string) (*Verifier, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("reading trusted keys dir %s: %w", dir, err)
+	}
+
+	var keys []ed25519.PublicKey
+	for _, entry := range entries {
+		if entry.IsDir() || (!strings.HasSuffix(entry.Name(), ".pub") && !strings.HasSuffix(entry.Name(), ".pem")) {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		key, err := ParsePublicKey(data)
+		if err != nil {
+			return nil, fmt.Errorf("parsing trusted key %s: %w", entry.Name(), err)
+		}
+		keys = append(keys, key)
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no trusted keys found in %s", dir)
+	}
+	return NewVerifier(keys...)
+}
+
+// ParsePublicKey parses a PEM encoded PKIX ed25519 public key, e.g. generated by openssl, or a base64 raw key
+func ParsePublicKey(data []byte) (ed25519.PublicKey, error) {
+	if block, _ := pem.Decode(data); block != nil {
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		edKey, ok := key.(ed25519.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("public key is %T, only ed25519 keys are supported", key)
+		}
+		return edKey, nil
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil || len(raw) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid ed25519 public key")
+	}
+	return raw, nil
+}
+
+// KeyIDs returns the sorted IDs of the trusted keys
+func (v *Verifier) KeyIDs() []string {
+	ids := make([]string, 0, len(v.keys))
+	for id := range v.keys {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Verify verifies the detached signature of the raw plan, the signature is either encoded by SignPlan
+// or the raw, or base64 encoded, 64 bytes signature, e.g. from openssl pkeyutl, which is verified
+// with every trusted key
+func (v *Verifier) Verify(rawPlan, signature []byte) error {
+	if len(signature) == 0 {
+		return ErrUnsignedPlan
+	}
+
+	sig := Signature{}
+	if err := json.Unmarshal(signature, &sig); err != nil {
+		sig.Signature = signature
+		if raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(signature))); err == nil &&
+			len(raw) == ed25519.SignatureSize {
+			sig.Signature = raw
+		}
+	}
+
+	if sig.KeyID != "" {
+		key, ok := v.keys[sig.KeyID]
+		if !ok {
+			return fmt.Errorf("%w %s", ErrUntrustedKey, sig.KeyID)
+		}
+		if !ed25519.Verify(key, rawPlan, sig.Signature) {
+			return ErrInvalidSig
+		}
+		return nil
+	}
+
+	for _, key := range v.keys {
+		if ed25519.Verify(key, rawPlan, sig.Signature) {
+			return nil
+		}
+	}
+	return ErrInvalidSig
+}
+
+// VerifyPlan verifies the signature and the checksum of the calculated plan and decodes the plan
+// again from the verified content, so the changes made to the decoded plan are never applied
+func (v *Verifier) VerifyPlan(cp CalculatedPlan) (CalculatedPlan, error) {
+	if cp.raw == nil || len(cp.Signature) == 0 {
+		return cp, ErrUnsignedPlan
+	}
+	if err := v.Verify(cp.raw, cp.Signature); err != nil {
+		return cp, err
+	}
+	if checksum(cp.raw) != cp.Checksum {
+		return cp, fmt.Errorf("plan checksum %s does not match the signed content", cp.Checksum)
+	}
+
+	verified, err := CalculatePlan(cp.raw)
+	if err != nil {
+		return cp, err
+	}
+	verified.Signature = cp.Signature
+	verified.raw = cp.raw
+	return verified, nil
+}
+
+// ReadSignedPlan reads the plan file and verifies it with its detached signature file
+func (v *Verifier) ReadSignedPlan(planFile string) (Plan, error) {
+	rawPlan, err := os.ReadFile(planFile)
+	if err != nil {
+		return Plan{}, err
+	}
+	signature, err := os.ReadFile(planFile + SignatureSuffix)
+	if err != nil && !os.IsNotExist(err) {
+		return Plan{}, err
+	}
+
+	cp, err := CalculateSignedPlan(rawPlan, signature)
+	if err != nil {
+		return Plan{}, err
+	}
+	if cp, err = v.VerifyPlan(cp); err != nil {
+		return Plan{}, fmt.Errorf("verifying plan %s: %w", planFile, err)
+	}
+	return cp.Plan, nil
+}
@@ -0,0 +1,125 @@
+package applyinator
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const rawPlan = `{"files":[{"path":"/etc/llmos/test","content":"dGVzdA=="}]}`
+
+func newKey(t *testing.T) (ed25519.PublicKey, ed25519.PrivateKey) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	return pub, priv
+}
+
+func TestVerify(t *testing.T) {
+	oldPub, oldPriv := newKey(t)
+	newPub, newPriv := newKey(t)
+	_, untrusted := newKey(t)
+
+	// both keys are trusted while the signing key is rotated
+	verifier, err := NewVerifier(oldPub, newPub)
+	require.NoError(t, err)
+
+	for _, key := range []ed25519.PrivateKey{oldPriv, newPriv} {
+		sig, err := SignPlan([]byte(rawPlan), key)
+		require.NoError(t, err)
+		assert.NoError(t, verifier.Verify([]byte(rawPlan), sig))
+	}
+
+	// the raw and base64 signatures without a key ID are verified with every trusted key
+	raw := ed25519.Sign(newPriv, []byte(rawPlan))
+	assert.NoError(t, verifier.Verify([]byte(rawPlan), raw))
+	assert.NoError(t, verifier.Verify([]byte(rawPlan), []byte(base64.StdEncoding.EncodeToString(raw)+"\n")))
+
+	sig, err := SignPlan([]byte(rawPlan), untrusted)
+	require.NoError(t, err)
+	assert.ErrorIs(t, verifier.Verify([]byte(rawPlan), sig), ErrUntrustedKey)
+	assert.ErrorIs(t, verifier.Verify([]byte(rawPlan), ed25519.Sign(untrusted, []byte(rawPlan))), ErrInvalidSig)
+
+	sig, err = SignPlan([]byte(rawPlan), newPriv)
+	require.NoError(t, err)
+	assert.ErrorIs(t, verifier.Verify([]byte(rawPlan+" "), sig), ErrInvalidSig)
+	assert.ErrorIs(t, verifier.Verify([]byte(rawPlan), nil), ErrUnsignedPlan)
+}
+
+func TestVerifyPlan(t *testing.T) {
+	pub, priv := newKey(t)
+	verifier, err := NewVerifier(pub)
+	require.NoError(t, err)
+	sig, err := SignPlan([]byte(rawPlan), priv)
+	require.NoError(t, err)
+
+	cp, err := CalculateSignedPlan([]byte(rawPlan), sig)
+	require.NoError(t, err)
+
+	// the changes to the decoded plan are dropped by the verification
+	cp.Plan.Files[0].Path = "/etc/passwd"
+	verified, err := verifier.VerifyPlan(cp)
+	require.NoError(t, err)
+	assert.Equal(t, "/etc/llmos/test", verified.Plan.Files[0].Path)
+
+	cp.Checksum = checksum([]byte("{}"))
+	_, err = verifier.VerifyPlan(cp)
+	assert.ErrorContains(t, err, "does not match the signed content")
+
+	unsigned, err := CalculatePlan([]byte(rawPlan))
+	require.NoError(t, err)
+	_, err = verifier.VerifyPlan(unsigned)
+	assert.ErrorIs(t, err, ErrUnsignedPlan)
+}
+
+func TestApplyRefusesUnsignedPlan(t *testing.T) {
+	pub, _ := newKey(t)
+	verifier, err := NewVerifier(pub)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	a := NewApplyinator(filepath.Join(dir, "work"), false, filepath.Join(dir, "applied"), "", nil)
+	a.SetVerifier(verifier)
+
+	cp, err := CalculatePlan([]byte(rawPlan))
+	require.NoError(t, err)
+	_, err = a.Apply(context.Background(), ApplyInput{CalculatedPlan: cp, ReconcileFiles: true})
+	assert.ErrorIs(t, err, ErrUnsignedPlan)
+	assert.NoDirExists(t, filepath.Join(dir, "applied"))
+}
+
+func TestReadSignedPlan(t *testing.T) {
+	pub, priv := newKey(t)
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	require.NoError(t, err)
+
+	keysDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(keysDir, "controller.pem"),
+		pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(keysDir, "backup.pub"),
+		[]byte(base64.StdEncoding.EncodeToString(pub)), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(keysDir, "README"), []byte("ignored"), 0644))
+
+	verifier, err := NewVerifierFromDir(keysDir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{KeyID(pub)}, verifier.KeyIDs())
+
+	planFile := filepath.Join(t.TempDir(), "plan.json")
+	require.NoError(t, os.WriteFile(planFile, []byte(rawPlan), 0600))
+	_, err = verifier.ReadSignedPlan(planFile)
+	assert.ErrorIs(t, err, ErrUnsignedPlan)
+
+	sig, err := SignPlan([]byte(rawPlan), priv)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(planFile+SignatureSuffix, sig, 0600))
+	plan, err := verifier.ReadSignedPlan(planFile)
+	require.NoError(t, err)
+	assert.Equal(t, "/etc/llmos/test", plan.Files[0].Path)
+}
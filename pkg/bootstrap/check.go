@@ -1,19 +1,14 @@
 package bootstrap
 
 import (
-	"crypto/tls"
+	"context"
 	"fmt"
-	"io"
-	"net/http"
 	"net/url"
-	"strings"
-	"time"
-
-	"github.com/hashicorp/go-retryablehttp"
-	"github.com/sirupsen/logrus"
+	"slices"
 
 	"github.com/llmos-ai/llmos/pkg/bootstrap/config"
 	"github.com/llmos-ai/llmos/pkg/bootstrap/images"
+	"github.com/llmos-ai/llmos/pkg/cli/ping"
 )
 
 const (
@@ -38,14 +33,25 @@ func mergeConfigs(cfg Config, result config.Config) config.Config {
 		result.Mirror = cfg.Mirror
 	}
 
-	// Apply default values to the configuration
-	result.SetDefaults()
-
 	// Merge Kubernetes version
 	if result.KubernetesVersion == "" {
 		result.KubernetesVersion = cfg.KubernetesVersion
 	}
 
+	// Join the cluster via the registration address and add it to the TLS SANs, the registration port
+	// depends on the runtime of the cluster and is added once its version is resolved
+	if address := result.GetRegistrationAddress(); address != "" {
+		if !slices.Contains(result.SANS, address) {
+			result.SANS = append(result.SANS, address)
+		}
+		if result.Server == "" && result.Role != config.ClusterInitRole {
+			result.Server = result.GetRegistrationServer()
+		}
+	}
+
+	// Apply default values to the configuration
+	result.SetDefaults()
+
 	// Set runtime system default registry to mirror registry
 	if result.SystemDefaultRegistry == "" && result.Mirror == MirrorRegionCN {
 		result.SystemDefaultRegistry = images.AliSystemDefaultRegistry
@@ -63,8 +69,12 @@ func validateConfig(cfg *config.Config) error {
 		return fmt.Errorf("cluster-init role and server URL are mutually exclusive, please select only one")
 	}
 
+	if err := cfg.ValidateHA(); err != nil {
+		return fmt.Errorf("invalid registration address config: %v", err)
+	}
+
 	if cfg.Server != "" {
-		if err := validateServerURL(cfg.Server); err != nil {
+		if err := validateServerURL(cfg.Server, cfg.GetRegistrationAddress()); err != nil {
 			return fmt.Errorf("invalid server URL: %v", err)
 		}
 	}
@@ -73,6 +83,38 @@ func validateConfig(cfg *config.Config) error {
 		return fmt.Errorf("server URL is defined but token is not, skipping bootstrap")
 	}
 
+	if err := cfg.Network.Validate(); err != nil {
+		return fmt.Errorf("invalid network config: %v", err)
+	}
+
+	if err := cfg.Kubelet.Validate(); err != nil {
+		return fmt.Errorf("invalid kubelet config: %v", err)
+	}
+
+	if err := cfg.KubeAPIServer.Validate(); err != nil {
+		return fmt.Errorf("invalid kube-apiserver config: %v", err)
+	}
+
+	if err := cfg.ValidateComponentArgs(); err != nil {
+		return fmt.Errorf("invalid extraConfig: %v", err)
+	}
+
+	if err := validateDatastore(cfg); err != nil {
+		return fmt.Errorf("invalid datastore config: %v", err)
+	}
+
+	if err := cfg.EtcdSnapshot.Validate(); err != nil {
+		return fmt.Errorf("invalid etcd snapshot config: %v", err)
+	}
+
+	if err := cfg.ValidateProbes(); err != nil {
+		return fmt.Errorf("invalid probes config: %v", err)
+	}
+
+	if err := cfg.Notifications.Validate(); err != nil {
+		return fmt.Errorf("invalid notifications config: %v", err)
+	}
+
 	if cfg.Mirror != "" && cfg.Mirror != MirrorRegionCN {
 		return fmt.Errorf("invalid mirror %s, only [%s] is supported for now", cfg.Mirror, MirrorRegionCN)
 	}
@@ -80,7 +122,42 @@ func validateConfig(cfg *config.Config) error {
 	return nil
 }
 
-func validateServerURL(serverURL string) error {
+// validateNetworkRuntime validates the network settings and the extraConfig keys they set against the
+// runtime of the resolved kubernetes version, a joining node only knows it once it got the version of the
+// cluster. The agents ignore the network settings
+func validateNetworkRuntime(cfg *config.Config, k8sVersion string) error {
+	if cfg.Role == config.AgentRole {
+		return nil
+	}
+	runtime := config.GetRuntime(k8sVersion)
+	if err := cfg.Network.ValidateRuntime(runtime); err != nil {
+		return fmt.Errorf("invalid network config: %v", err)
+	}
+	if err := cfg.ValidateNetworkKeys(runtime); err != nil {
+		return fmt.Errorf("invalid extraConfig: %v", err)
+	}
+	return nil
+}
+
+func validateDatastore(cfg *config.Config) error {
+	if cfg.Datastore == nil {
+		return nil
+	}
+	if err := cfg.Datastore.Validate(); err != nil {
+		return err
+	}
+
+	if cfg.Role == config.AgentRole {
+		return fmt.Errorf("datastore is only used by the cluster-init and server nodes, remove it from the agent")
+	}
+	// the external datastore replaces the embedded etcd, there is no etcd to snapshot
+	if cfg.EtcdSnapshot != nil && !cfg.EtcdSnapshot.Disable {
+		return fmt.Errorf("etcdSnapshot requires the embedded etcd, disable it when using an external datastore")
+	}
+	return nil
+}
+
+func validateServerURL(serverURL, registrationAddress string) error {
 	parsedURL, err := url.Parse(serverURL)
 	if err != nil {
 		return fmt.Errorf("invalid server URL: %v", err)
@@ -91,42 +168,21 @@ func validateServerURL(serverURL string) error {
 		return fmt.Errorf("invalid server URL: scheme must be https")
 	}
 
-	// Check port
+	// Check port, the registration port of the registration address is added once the runtime of the
+	// cluster is resolved
 	port := parsedURL.Port()
+	if port == "" && registrationAddress != "" && parsedURL.Hostname() == registrationAddress {
+		return nil
+	}
 	if port != "6443" && port != "9345" {
 		return fmt.Errorf("invalid server URL: port must be 6443 or 9345")
 	}
 
-	retryClient := retryablehttp.NewClient()
-	retryClient.RetryMax = 3
-	retryClient.HTTPClient = &http.Client{
-		Timeout: 5 * time.Second,
-		Transport: &http.Transport{
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-		},
+	// The registration address might not be announced yet, the plan waits for it to be reachable
+	if registrationAddress != "" && parsedURL.Hostname() == registrationAddress {
+		return nil
 	}
 
 	// Check if the server url is ready
-	url := fmt.Sprintf("%s/ping", strings.TrimSuffix(serverURL, "/"))
-	resp, err := retryClient.Get(url)
-	if err != nil {
-		return fmt.Errorf("failed to check server URL: %v", err)
-	}
-	defer func() {
-		err = resp.Body.Close()
-		if err != nil {
-			logrus.Fatal(err)
-		}
-	}()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return fmt.Errorf("failed to validate server url: %v", err)
-	}
-
-	if string(body) != "pong" || resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("server url is not ready: %s", string(body))
-	}
-
-	return nil
+	return ping.Ping(context.Background(), serverURL)
 }
@@ -0,0 +1,261 @@
+package bootstrap
+
+import (
+	"context"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/llmos-ai/llmos/pkg/bootstrap/config"
+	"github.com/llmos-ai/llmos/pkg/bootstrap/plan"
+)
+
+func TestValidateDatastore(t *testing.T) {
+	datastore := &config.DatastoreConfig{Endpoint: "postgres://k3s@db:5432/k3s"}
+	tests := []struct {
+		name string
+		cfg  config.RuntimeConfig
+		err  string
+	}{
+		{name: "cluster-init", cfg: config.RuntimeConfig{Role: config.ClusterInitRole, Datastore: datastore}},
+		{
+			name: "server without server URL",
+			cfg:  config.RuntimeConfig{Role: config.ServerRole, Token: "secret", Datastore: datastore},
+		},
+		{
+			name: "invalid datastore",
+			cfg:  config.RuntimeConfig{Role: config.ClusterInitRole, Datastore: &config.DatastoreConfig{}},
+			err:  "invalid datastore config: datastore endpoint is required",
+		},
+		{
+			name: "agent",
+			cfg:  config.RuntimeConfig{Role: config.AgentRole, Token: "secret", Datastore: datastore},
+			err:  "datastore is only used by the cluster-init and server nodes",
+		},
+		{
+			name: "etcd snapshot",
+			cfg: config.RuntimeConfig{Role: config.ClusterInitRole, Datastore: datastore,
+				EtcdSnapshot: &config.EtcdSnapshotConfig{ScheduleCron: "0 */12 * * *"}},
+			err: "etcdSnapshot requires the embedded etcd",
+		},
+		{
+			name: "disabled etcd snapshot",
+			cfg: config.RuntimeConfig{Role: config.ClusterInitRole, Datastore: datastore,
+				EtcdSnapshot: &config.EtcdSnapshotConfig{Disable: true}},
+		},
+		{
+			name: "etcd snapshot without datastore",
+			cfg: config.RuntimeConfig{Role: config.ClusterInitRole,
+				EtcdSnapshot: &config.EtcdSnapshotConfig{ScheduleCron: "0 */12 * * *"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateConfig(&config.Config{RuntimeConfig: tt.cfg})
+			if tt.err == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tt.err)
+			}
+		})
+	}
+}
+
+func TestValidateNetworkRuntime(t *testing.T) {
+	canal := &config.NetworkConfig{CNI: config.CNICanal}
+	hostGW := &config.NetworkConfig{FlannelBackend: config.FlannelBackendHostGW}
+	tests := []struct {
+		name       string
+		flags      Config
+		network    *config.NetworkConfig
+		extra      map[string]interface{}
+		k8sVersion string
+		err        string
+	}{
+		{
+			name:       "canal on a k3s cluster",
+			flags:      Config{ClusterInit: true},
+			network:    canal,
+			k8sVersion: "v1.31.3+k3s1",
+			err:        "invalid network config: cni canal is not supported by k3s",
+		},
+		{
+			name:       "flannel backend on a rke2 cluster",
+			flags:      Config{ClusterInit: true},
+			network:    hostGW,
+			k8sVersion: "v1.31.3+rke2r1",
+			err:        "invalid network config: flannelBackend is not supported by rke2",
+		},
+		{
+			name:       "server joining a rke2 cluster with canal",
+			flags:      Config{Token: "secret", Server: "https://10.0.0.1:9345", Role: string(config.ServerRole)},
+			network:    canal,
+			k8sVersion: "v1.31.3+rke2r1",
+		},
+		{
+			name:       "server joining a k3s cluster with canal",
+			flags:      Config{Token: "secret", Server: "https://10.0.0.1:6443", Role: string(config.ServerRole)},
+			network:    canal,
+			k8sVersion: "v1.31.3+k3s1",
+			err:        "cni canal is not supported by k3s",
+		},
+		{
+			name:       "extraConfig set by the network config",
+			flags:      Config{ClusterInit: true},
+			network:    &config.NetworkConfig{CNI: config.CNICilium},
+			extra:      map[string]interface{}{"flannel-backend": "vxlan"},
+			k8sVersion: "v1.31.3+k3s1",
+			err:        "invalid extraConfig: extraConfig flannel-backend is also set by the network config",
+		},
+		{
+			name:       "agent",
+			flags:      Config{Token: "secret", Server: "https://10.0.0.1:6443"},
+			network:    hostGW,
+			k8sVersion: "v1.31.3+rke2r1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// the kubernetes version of the flags is the CLI default, the config has none
+			tt.flags.KubernetesVersion = "v1.31.3+k3s1"
+			cfg := mergeConfigs(tt.flags, config.Config{RuntimeConfig: config.RuntimeConfig{
+				Network:      tt.network,
+				ConfigValues: tt.extra,
+			}})
+			if cfg.Role != config.ClusterInitRole {
+				setClusterVersions(&cfg, tt.k8sVersion, "v0.2.0")
+			}
+			err := validateNetworkRuntime(&cfg, tt.k8sVersion)
+			if tt.err == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tt.err)
+			}
+		})
+	}
+}
+
+func TestValidateServerURL(t *testing.T) {
+	tests := []struct {
+		server              string
+		registrationAddress string
+		err                 string
+	}{
+		{server: "http://10.0.0.1:6443", err: "scheme must be https"},
+		{server: "https://10.0.0.1", err: "port must be 6443 or 9345"},
+		{server: "https://10.0.0.1:8443", err: "port must be 6443 or 9345"},
+		{server: "https://%zz", err: "invalid server URL"},
+		// the registration address is not pinged, it might not be announced yet
+		{server: "https://192.168.1.100:6443", registrationAddress: "192.168.1.100"},
+		{server: "https://lb.example.com:9345", registrationAddress: "lb.example.com"},
+		{server: "https://192.168.1.100", registrationAddress: "192.168.1.100"},
+		{server: "https://lb.example.com:8443", registrationAddress: "lb.example.com", err: "port must be 6443 or 9345"},
+	}
+	for _, tt := range tests {
+		err := validateServerURL(tt.server, tt.registrationAddress)
+		if tt.err == "" {
+			assert.NoError(t, err, tt.server)
+		} else {
+			assert.ErrorContains(t, err, tt.err, tt.server)
+		}
+	}
+}
+
+func TestMergeConfigsRegistrationAddress(t *testing.T) {
+	tests := []struct {
+		name   string
+		flags  Config
+		cfg    config.Config
+		role   config.Role
+		server string
+	}{
+		{
+			// the agent role is defaulted from the server derived of the registration address
+			name:  "agent joins the registration address",
+			flags: Config{Token: "secret"},
+			cfg: config.Config{
+				KubernetesVersion:   "v1.31.3+k3s1",
+				RegistrationAddress: "lb.example.com",
+			},
+			role:   config.AgentRole,
+			server: "https://lb.example.com",
+		},
+		{
+			name:  "server joins the vip",
+			flags: Config{Token: "secret", Role: string(config.ServerRole), KubernetesVersion: "v1.31.3+k3s1"},
+			cfg:   config.Config{VIP: &config.VIPConfig{Address: "192.168.1.100"}},
+			role:  config.ServerRole,
+			// the port is added once the kubernetes version of the cluster is resolved
+			server: "https://192.168.1.100",
+		},
+		{
+			name:  "server URL of the registration address without port",
+			flags: Config{Token: "secret", Server: "https://192.168.1.100"},
+			cfg: config.Config{
+				KubernetesVersion: "v1.31.3+k3s1",
+				VIP:               &config.VIPConfig{Address: "192.168.1.100"},
+			},
+			role:   config.AgentRole,
+			server: "https://192.168.1.100",
+		},
+		{
+			name:  "cluster-init",
+			flags: Config{ClusterInit: true, Mirror: MirrorRegionCN},
+			cfg: config.Config{
+				KubernetesVersion: "v1.31.3+k3s1",
+				VIP:               &config.VIPConfig{Address: "192.168.1.100"},
+			},
+			role: config.ClusterInitRole,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := mergeConfigs(tt.flags, tt.cfg)
+			assert.Equal(t, tt.role, result.Role)
+			assert.Equal(t, tt.server, result.Server)
+			assert.Contains(t, result.SANS, tt.cfg.GetRegistrationAddress())
+			assert.Contains(t, result.Labels, "llmos.ai/managed=true")
+			// the etcd metrics default depends on the role defaulted from the derived server
+			assert.Equal(t, tt.role != config.AgentRole, result.ConfigValues[config.EtcdExposeMetrics] == true)
+		})
+	}
+}
+
+func TestJoinRegistrationAddressPort(t *testing.T) {
+	tests := []struct {
+		name       string
+		k8sVersion string
+		server     string
+	}{
+		{name: "k3s cluster", k8sVersion: "v1.31.3+k3s1", server: "https://192.168.1.100:6443"},
+		{name: "rke2 cluster", k8sVersion: "v1.31.3+rke2r1", server: "https://192.168.1.100:9345"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// the kubernetes version of the flags is the CLI default, the config has none
+			flags := Config{Token: "secret", Role: string(config.ServerRole), KubernetesVersion: "v1.31.3+k3s1"}
+			cfg := mergeConfigs(flags, config.Config{VIP: &config.VIPConfig{Address: "192.168.1.100"}})
+			require.NoError(t, validateConfig(&cfg))
+
+			setClusterVersions(&cfg, tt.k8sVersion, "v0.2.0")
+			assert.Equal(t, tt.server, cfg.Server)
+
+			// the runtime config and the wait instruction join the same registration port
+			p, err := plan.ToPlan(context.Background(), &cfg, t.TempDir())
+			require.NoError(t, err)
+			var runtimeConfig string
+			for _, file := range p.Files {
+				if strings.HasSuffix(file.Path, "40-llmos.yaml") {
+					data, err := base64.StdEncoding.DecodeString(file.Content)
+					require.NoError(t, err)
+					runtimeConfig = string(data)
+				}
+			}
+			assert.Contains(t, runtimeConfig, "server: "+tt.server)
+			assert.Equal(t, tt.server, cfg.GetRegistrationURL())
+		})
+	}
+}
@@ -0,0 +1,188 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/llmos-ai/llmos/utils/data/convert"
+)
+
+var (
+	argNameRegexp = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]*[a-z0-9])?$`)
+
+	evictionSignals = []string{"memory.available", "nodefs.available", "nodefs.inodesFree",
+		"imagefs.available", "imagefs.inodesFree", "containerfs.available", "containerfs.inodesFree", "pid.available"}
+	reservedResources = []string{"cpu", "memory", "ephemeral-storage", "pid"}
+)
+
+// KubeletConfig contains the typed kubelet tuning settings
+type KubeletConfig struct {
+	MaxPods                     int               `json:"maxPods,omitempty"`
+	EvictionHard                map[string]string `json:"evictionHard,omitempty"`
+	EvictionSoft                map[string]string `json:"evictionSoft,omitempty"`
+	EvictionSoftGracePeriod     map[string]string `json:"evictionSoftGracePeriod,omitempty"`
+	SystemReserved              map[string]string `json:"systemReserved,omitempty"`
+	KubeReserved                map[string]string `json:"kubeReserved,omitempty"`
+	ImageGCHighThresholdPercent int               `json:"imageGCHighThresholdPercent,omitempty"`
+	ImageGCLowThresholdPercent  int               `json:"imageGCLowThresholdPercent,omitempty"`
+	// ExtraArgs are passed as kubelet-arg without the leading dashes, e.g. {"v": "2"}
+	ExtraArgs map[string]string `json:"extraArgs,omitempty"`
+}
+
+// KubeAPIServerConfig contains the kube-apiserver tuning settings, only applies to the server nodes
+type KubeAPIServerConfig struct {
+	// ExtraArgs are passed as kube-apiserver-arg without the leading dashes, e.g. {"max-requests-inflight": "800"}
+	ExtraArgs map[string]string `json:"extraArgs,omitempty"`
+}
+
+// ContainerdConfig contains the containerd settings of the k8s runtime
+type ContainerdConfig struct {
+	// ConfigTemplate is the content of the runtime containerd config.toml.tmpl
+	ConfigTemplate string `json:"configTemplate,omitempty"`
+}
+
+// KubeletTypedArgs are the kubelet args managed by the typed fields of KubeletConfig
+var KubeletTypedArgs = []string{"max-pods", "eviction-hard", "eviction-soft", "eviction-soft-grace-period",
+	"system-reserved", "kube-reserved", "image-gc-high-threshold", "image-gc-low-threshold"}
+
+func (k *KubeletConfig) Validate() error {
+	if k == nil {
+		return nil
+	}
+
+	if k.MaxPods < 0 {
+		return fmt.Errorf("maxPods must not be negative")
+	}
+
+	for name, values := range map[string]map[string]string{
+		"evictionHard":            k.EvictionHard,
+		"evictionSoft":            k.EvictionSoft,
+		"evictionSoftGracePeriod": k.EvictionSoftGracePeriod,
+	} {
+		for signal := range values {
+			if !slices.Contains(evictionSignals, signal) {
+				return fmt.Errorf("invalid %s signal %s, supported signals are %v", name, signal, evictionSignals)
+			}
+		}
+	}
+
+	for signal := range k.EvictionSoft {
+		if _, ok := k.EvictionSoftGracePeriod[signal]; !ok {
+			return fmt.Errorf("evictionSoft signal %s requires a matching evictionSoftGracePeriod", signal)
+		}
+	}
+
+	for name, values := range map[string]map[string]string{
+		"systemReserved": k.SystemReserved,
+		"kubeReserved":   k.KubeReserved,
+	} {
+		for resource := range values {
+			if !slices.Contains(reservedResources, resource) {
+				return fmt.Errorf("invalid %s resource %s, supported resources are %v", name, resource, reservedResources)
+			}
+		}
+	}
+
+	if k.ImageGCHighThresholdPercent < 0 || k.ImageGCHighThresholdPercent > 100 ||
+		k.ImageGCLowThresholdPercent < 0 || k.ImageGCLowThresholdPercent > 100 {
+		return fmt.Errorf("image GC thresholds must be within 0-100")
+	}
+
+	if k.ImageGCHighThresholdPercent > 0 && k.ImageGCLowThresholdPercent > 0 &&
+		k.ImageGCLowThresholdPercent >= k.ImageGCHighThresholdPercent {
+		return fmt.Errorf("imageGCLowThresholdPercent must be lower than imageGCHighThresholdPercent")
+	}
+
+	return validateExtraArgs("kubelet", k.ExtraArgs, KubeletTypedArgs)
+}
+
+func (k *KubeAPIServerConfig) Validate() error {
+	if k == nil {
+		return nil
+	}
+	return validateExtraArgs("kube-apiserver", k.ExtraArgs, nil)
+}
+
+// ValidateComponentArgs rejects the kubelet-arg and kube-apiserver-arg of extraConfig setting an arg of the
+// typed kubelet and kube-apiserver config, the runtime would get the arg twice with an undefined winner
+func (cfg *RuntimeConfig) ValidateComponentArgs() error {
+	type componentArgs struct {
+		key, name string
+		args      []string
+	}
+	components := []componentArgs{{key: "kubelet-arg", name: "kubelet", args: cfg.Kubelet.argNames()}}
+	// kube-apiserver only runs on the server nodes
+	if cfg.Role != AgentRole {
+		components = append(components,
+			componentArgs{key: "kube-apiserver-arg", name: "kubeApiserver", args: cfg.KubeAPIServer.argNames()})
+	}
+
+	for _, c := range components {
+		for _, arg := range convert.ToStringSlice(cfg.ConfigValues[c.key]) {
+			name, _, _ := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+			if slices.Contains(c.args, name) {
+				return fmt.Errorf("extraConfig %s %s is also set by the %s config, please set it in one place only",
+					c.key, arg, c.name)
+			}
+		}
+	}
+	return nil
+}
+
+// argNames returns the names of the kubelet args set by the config
+func (k *KubeletConfig) argNames() []string {
+	if k == nil {
+		return nil
+	}
+
+	var names []string
+	for name, set := range map[string]bool{
+		"max-pods":                   k.MaxPods > 0,
+		"eviction-hard":              len(k.EvictionHard) > 0,
+		"eviction-soft":              len(k.EvictionSoft) > 0,
+		"eviction-soft-grace-period": len(k.EvictionSoftGracePeriod) > 0,
+		"system-reserved":            len(k.SystemReserved) > 0,
+		"kube-reserved":              len(k.KubeReserved) > 0,
+		"image-gc-high-threshold":    k.ImageGCHighThresholdPercent > 0,
+		"image-gc-low-threshold":     k.ImageGCLowThresholdPercent > 0,
+	} {
+		if set {
+			names = append(names, name)
+		}
+	}
+	for name := range k.ExtraArgs {
+		names = append(names, name)
+	}
+	return names
+}
+
+// argNames returns the names of the kube-apiserver args set by the config
+func (k *KubeAPIServerConfig) argNames() []string {
+	if k == nil {
+		return nil
+	}
+
+	names := make([]string, 0, len(k.ExtraArgs))
+	for name := range k.ExtraArgs {
+		names = append(names, name)
+	}
+	return names
+}
+
+func validateExtraArgs(component string, args map[string]string, typedArgs []string) error {
+	for name := range args {
+		if strings.HasPrefix(name, "-") {
+			return fmt.Errorf("invalid %s arg %s, arg names must not start with dashes", component, name)
+		}
+		if !argNameRegexp.MatchString(name) {
+			return fmt.Errorf("invalid %s arg %s, arg names must be lowercase alphanumerics separated by dashes",
+				component, name)
+		}
+		if slices.Contains(typedArgs, name) {
+			return fmt.Errorf("%s arg %s is managed by a typed field, please set it there instead", component, name)
+		}
+	}
+	return nil
+}
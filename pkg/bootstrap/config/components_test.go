@@ -0,0 +1,74 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateComponentArgs(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  RuntimeConfig
+		err  string
+	}{
+		{
+			name: "no typed config",
+			cfg:  RuntimeConfig{ConfigValues: map[string]interface{}{"kubelet-arg": []interface{}{"max-pods=200"}}},
+		},
+		{
+			name: "distinct args",
+			cfg: RuntimeConfig{
+				Kubelet:      &KubeletConfig{MaxPods: 110, ExtraArgs: map[string]string{"v": "2"}},
+				ConfigValues: map[string]interface{}{"kubelet-arg": []interface{}{"cpu-manager-policy=static"}},
+			},
+		},
+		{
+			name: "typed field",
+			cfg: RuntimeConfig{
+				Kubelet:      &KubeletConfig{MaxPods: 110},
+				ConfigValues: map[string]interface{}{"kubelet-arg": []interface{}{"max-pods=200"}},
+			},
+			err: "extraConfig kubelet-arg max-pods=200 is also set by the kubelet config",
+		},
+		{
+			name: "extra arg with dashes",
+			cfg: RuntimeConfig{
+				Kubelet:      &KubeletConfig{ExtraArgs: map[string]string{"v": "2"}},
+				ConfigValues: map[string]interface{}{"kubelet-arg": "--v=4"},
+			},
+			err: "extraConfig kubelet-arg --v=4 is also set by the kubelet config",
+		},
+		{
+			name: "kube-apiserver",
+			cfg: RuntimeConfig{
+				Role:          ServerRole,
+				KubeAPIServer: &KubeAPIServerConfig{ExtraArgs: map[string]string{"max-requests-inflight": "800"}},
+				ConfigValues: map[string]interface{}{
+					"kube-apiserver-arg": []string{"max-requests-inflight=400"},
+				},
+			},
+			err: "extraConfig kube-apiserver-arg max-requests-inflight=400 is also set by the kubeApiserver config",
+		},
+		{
+			name: "kube-apiserver on agent",
+			cfg: RuntimeConfig{
+				Role:          AgentRole,
+				KubeAPIServer: &KubeAPIServerConfig{ExtraArgs: map[string]string{"max-requests-inflight": "800"}},
+				ConfigValues: map[string]interface{}{
+					"kube-apiserver-arg": []string{"max-requests-inflight=400"},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cfg.ValidateComponentArgs()
+			if tt.err == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tt.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDatastoreValidate(t *testing.T) {
+	tests := []struct {
+		name      string
+		datastore *DatastoreConfig
+		err       string
+	}{
+		{name: "unset"},
+		{name: "endpoint", datastore: &DatastoreConfig{Endpoint: "postgres://k3s@db:5432/k3s"}},
+		{
+			name: "files",
+			datastore: &DatastoreConfig{Endpoint: "https://etcd:2379", CAFile: "/etc/etcd/ca.crt",
+				CertFile: "/etc/etcd/client.crt", KeyFile: "/etc/etcd/client.key"},
+		},
+		{
+			name:      "inline",
+			datastore: &DatastoreConfig{Endpoint: "https://etcd:2379", CA: "ca", Cert: "cert", Key: "key"},
+		},
+		{name: "no endpoint", datastore: &DatastoreConfig{CAFile: "/etc/etcd/ca.crt"}, err: "endpoint is required"},
+		{
+			name:      "ca file and inline",
+			datastore: &DatastoreConfig{Endpoint: "https://etcd:2379", CAFile: "/etc/etcd/ca.crt", CA: "ca"},
+			err:       "datastore ca and caFile are mutually exclusive",
+		},
+		{
+			name:      "cert without key",
+			datastore: &DatastoreConfig{Endpoint: "https://etcd:2379", Cert: "cert"},
+			err:       "cert and key must be specified together",
+		},
+		{
+			name:      "key file without cert",
+			datastore: &DatastoreConfig{Endpoint: "https://etcd:2379", KeyFile: "/etc/etcd/client.key"},
+			err:       "cert and key must be specified together",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.datastore.Validate()
+			if tt.err == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tt.err)
+			}
+		})
+	}
+}
+
+func TestRunsEtcd(t *testing.T) {
+	datastore := &DatastoreConfig{Endpoint: "postgres://k3s@db:5432/k3s"}
+	assert.True(t, (&RuntimeConfig{Role: ClusterInitRole}).RunsEtcd())
+	assert.True(t, (&RuntimeConfig{Role: ServerRole}).RunsEtcd())
+	assert.False(t, (&RuntimeConfig{Role: AgentRole}).RunsEtcd())
+	assert.False(t, (&RuntimeConfig{Role: ClusterInitRole, Datastore: datastore}).RunsEtcd())
+
+	cfg := &RuntimeConfig{Role: ServerRole, Datastore: datastore}
+	cfg.SetDefaults()
+	assert.NotContains(t, cfg.ConfigValues, EtcdExposeMetrics)
+
+	cfg = &RuntimeConfig{Role: ServerRole}
+	cfg.SetDefaults()
+	assert.Equal(t, true, cfg.ConfigValues[EtcdExposeMetrics])
+}
@@ -0,0 +1,107 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+)
+
+const (
+	DefaultKubeVIPImage = "ghcr.io/kube-vip/kube-vip:v0.8.7"
+
+	k3sRegistrationPort  = "6443"
+	rke2RegistrationPort = "9345"
+)
+
+// VIPConfig contains the settings of the virtual IP announced by kube-vip on the control-plane nodes
+type VIPConfig struct {
+	// Address is the virtual IP of the control-plane
+	Address string `json:"address,omitempty"`
+	// Interface is the network interface to announce the VIP, auto-detected by kube-vip if empty
+	Interface string `json:"interface,omitempty"`
+	// Image overrides the default kube-vip image
+	Image string `json:"image,omitempty"`
+}
+
+func (v *VIPConfig) Validate() error {
+	if v == nil {
+		return nil
+	}
+	if v.Address == "" {
+		return fmt.Errorf("vip address is required")
+	}
+	if net.ParseIP(v.Address) == nil {
+		return fmt.Errorf("invalid vip address %s, must be an IP address", v.Address)
+	}
+	return nil
+}
+
+func (v *VIPConfig) GetImage() string {
+	if v.Image != "" {
+		return v.Image
+	}
+	return DefaultKubeVIPImage
+}
+
+// GetRegistrationAddress returns the fixed address for the nodes to join the cluster,
+// it defaults to the VIP address when registrationAddress is not set
+func (c *Config) GetRegistrationAddress() string {
+	if c.RegistrationAddress != "" {
+		return c.RegistrationAddress
+	}
+	if c.VIP != nil {
+		return c.VIP.Address
+	}
+	return ""
+}
+
+// GetRegistrationURL returns the server URL of the registration address
+func (c *Config) GetRegistrationURL() string {
+	address := c.GetRegistrationAddress()
+	if address == "" {
+		return ""
+	}
+	return fmt.Sprintf("https://%s", net.JoinHostPort(address, registrationPort(c.KubernetesVersion)))
+}
+
+// GetRegistrationServer returns the server URL of the registration address without the port, the
+// registration port depends on the runtime of the cluster, see NormalizeServerURL
+func (c *Config) GetRegistrationServer() string {
+	address := c.GetRegistrationAddress()
+	if address == "" {
+		return ""
+	}
+	if strings.Contains(address, ":") {
+		address = "[" + address + "]"
+	}
+	return "https://" + address
+}
+
+// NormalizeServerURL adds the runtime registration port to the server URL if the server
+// points at the registration address without a port
+func (c *Config) NormalizeServerURL(server string) string {
+	address := c.GetRegistrationAddress()
+	parsedURL, err := url.Parse(server)
+	if address == "" || err != nil || parsedURL.Port() != "" || parsedURL.Hostname() != address {
+		return server
+	}
+	parsedURL.Host = net.JoinHostPort(address, registrationPort(c.KubernetesVersion))
+	return strings.TrimSuffix(parsedURL.String(), "/")
+}
+
+// ValidateHA validates the registration address and VIP settings
+func (c *Config) ValidateHA() error {
+	if strings.Contains(c.RegistrationAddress, "://") || strings.Contains(c.RegistrationAddress, "/") {
+		return fmt.Errorf("invalid registrationAddress %s, must be a hostname or IP address without scheme",
+			c.RegistrationAddress)
+	}
+	return c.VIP.Validate()
+}
+
+func registrationPort(kubernetesVersion string) string {
+	if GetRuntime(kubernetesVersion) == RuntimeRKE2 {
+		return rke2RegistrationPort
+	}
+	return k3sRegistrationPort
+}
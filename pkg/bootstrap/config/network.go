@@ -0,0 +1,264 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/llmos-ai/llmos/utils/data/convert"
+)
+
+var (
+	CNIFlannel CNI = "flannel"
+	CNICanal   CNI = "canal"
+	CNICalico  CNI = "calico"
+	CNICilium  CNI = "cilium"
+	CNINone    CNI = "none"
+
+	FlannelBackendVXLAN     = "vxlan"
+	FlannelBackendHostGW    = "host-gw"
+	FlannelBackendWireguard = "wireguard-native"
+	FlannelBackendNone      = "none"
+
+	supportedCNIs            = []CNI{CNIFlannel, CNICanal, CNICalico, CNICilium, CNINone}
+	supportedFlannelBackends = []string{FlannelBackendVXLAN, FlannelBackendHostGW, FlannelBackendWireguard,
+		FlannelBackendNone}
+)
+
+type CNI string
+
+// NetworkConfig contains the typed networking settings of the k8s runtime
+type NetworkConfig struct {
+	// CNI is the cluster network plugin, one of flannel, canal, calico, cilium or none
+	CNI CNI `json:"cni,omitempty"`
+	// FlannelBackend is the flannel backend, only applies when the flannel CNI is used
+	FlannelBackend string `json:"flannelBackend,omitempty"`
+	// ClusterCIDR is the pod network CIDRs, use one IPv4 and one IPv6 CIDR for dual-stack
+	ClusterCIDR []string `json:"clusterCidr,omitempty"`
+	// ServiceCIDR is the service network CIDRs, use one IPv4 and one IPv6 CIDR for dual-stack
+	ServiceCIDR []string `json:"serviceCidr,omitempty"`
+	// ClusterDNS is the coredns service IPs, must be within the service CIDRs
+	ClusterDNS []string `json:"clusterDns,omitempty"`
+	// ClusterDomain is the cluster domain, defaults to cluster.local
+	ClusterDomain string `json:"clusterDomain,omitempty"`
+	// DualStack requires both IPv4 and IPv6 CIDRs to be configured
+	DualStack bool `json:"dualStack,omitempty"`
+	// NodePortRange is the port range reserved for NodePort services, e.g. 30000-32767
+	NodePortRange string `json:"nodePortRange,omitempty"`
+}
+
+func (n *NetworkConfig) Validate() error {
+	if n == nil {
+		return nil
+	}
+
+	if n.CNI != "" && !slices.Contains(supportedCNIs, n.CNI) {
+		return fmt.Errorf("invalid cni %s, supported values are %v", n.CNI, supportedCNIs)
+	}
+
+	if n.FlannelBackend != "" {
+		if n.CNI != "" && n.CNI != CNIFlannel {
+			return fmt.Errorf("flannelBackend can only be set with the %s cni", CNIFlannel)
+		}
+		if !slices.Contains(supportedFlannelBackends, n.FlannelBackend) {
+			return fmt.Errorf("invalid flannelBackend %s, supported values are %v",
+				n.FlannelBackend, supportedFlannelBackends)
+		}
+	}
+
+	clusterNets, err := parseCIDRs("clusterCidr", n.ClusterCIDR, n.DualStack)
+	if err != nil {
+		return err
+	}
+
+	serviceNets, err := parseCIDRs("serviceCidr", n.ServiceCIDR, n.DualStack)
+	if err != nil {
+		return err
+	}
+
+	for _, c := range clusterNets {
+		for _, s := range serviceNets {
+			if c.Contains(s.IP) || s.Contains(c.IP) {
+				return fmt.Errorf("clusterCidr %s overlaps with serviceCidr %s", c, s)
+			}
+		}
+	}
+
+	if len(clusterNets) > 0 && len(serviceNets) > 0 && ipFamilies(clusterNets) != ipFamilies(serviceNets) {
+		return fmt.Errorf("clusterCidr and serviceCidr must have the same IP families")
+	}
+
+	for _, dns := range n.ClusterDNS {
+		ip := net.ParseIP(dns)
+		if ip == nil {
+			return fmt.Errorf("invalid clusterDns IP %s", dns)
+		}
+		if len(serviceNets) == 0 {
+			continue
+		}
+		if !ipInNets(ip, serviceNets) {
+			return fmt.Errorf("clusterDns %s is not within the serviceCidr %v", dns, n.ServiceCIDR)
+		}
+	}
+
+	if n.NodePortRange != "" {
+		if err = validatePortRange(n.NodePortRange); err != nil {
+			return fmt.Errorf("invalid nodePortRange %s: %w", n.NodePortRange, err)
+		}
+	}
+
+	return nil
+}
+
+// ValidateRuntime validates the CNI settings against the k8s runtime, k3s only bundles flannel and
+// rke2 does not configure the flannel backend
+func (n *NetworkConfig) ValidateRuntime(runtime Runtime) error {
+	if n == nil {
+		return nil
+	}
+
+	switch runtime {
+	case RuntimeK3S:
+		if n.CNI == CNICanal {
+			return fmt.Errorf("cni %s is not supported by %s", n.CNI, runtime)
+		}
+	case RuntimeRKE2:
+		if n.FlannelBackend != "" {
+			return fmt.Errorf("flannelBackend is not supported by %s", runtime)
+		}
+	}
+	return nil
+}
+
+// ValidateNetworkKeys rejects the extraConfig keys which are also set by the network config of the runtime
+func (cfg *RuntimeConfig) ValidateNetworkKeys(runtime Runtime) error {
+	keys := cfg.Network.configKeys(runtime)
+	for key := range cfg.ConfigValues {
+		// the extraConfig keys are normalized like the runtime config keys
+		name := strings.ReplaceAll(convert.ToYAMLKey(key), "_", "-")
+		if slices.Contains(keys, name) {
+			return fmt.Errorf("extraConfig %s is also set by the network config, please set it in one place only", key)
+		}
+	}
+	return nil
+}
+
+// configKeys returns the runtime config keys set by the network config
+func (n *NetworkConfig) configKeys(runtime Runtime) []string {
+	if n == nil {
+		return nil
+	}
+
+	var keys []string
+	for key, set := range map[string]bool{
+		"flannel-backend":         runtime == RuntimeK3S && (n.FlannelBackend != "" || (n.CNI != "" && n.CNI != CNIFlannel)),
+		"disable-network-policy":  runtime == RuntimeK3S && n.CNI != "" && n.CNI != CNIFlannel,
+		"cni":                     runtime == RuntimeRKE2 && n.CNI != "",
+		"cluster-cidr":            len(n.ClusterCIDR) > 0,
+		"service-cidr":            len(n.ServiceCIDR) > 0,
+		"cluster-dns":             len(n.ClusterDNS) > 0,
+		"cluster-domain":          n.ClusterDomain != "",
+		"service-node-port-range": n.NodePortRange != "",
+	} {
+		if set {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// parseCIDRs parses the CIDR list, it allows at most one CIDR per IP family and
+// requires both families when dual-stack is enabled
+func parseCIDRs(field string, cidrs []string, dualStack bool) ([]*net.IPNet, error) {
+	if len(cidrs) == 0 {
+		return nil, nil
+	}
+
+	if len(cidrs) > 2 {
+		return nil, fmt.Errorf("%s accepts at most two CIDRs, got %d", field, len(cidrs))
+	}
+
+	var (
+		result     []*net.IPNet
+		ipv4, ipv6 int
+	)
+	for _, cidr := range cidrs {
+		_, ipNet, err := net.ParseCIDR(strings.TrimSpace(cidr))
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s %s: %w", field, cidr, err)
+		}
+		if ipNet.IP.To4() != nil {
+			ipv4++
+		} else {
+			ipv6++
+		}
+		result = append(result, ipNet)
+	}
+
+	if ipv4 > 1 || ipv6 > 1 {
+		return nil, fmt.Errorf("%s must contain at most one IPv4 and one IPv6 CIDR", field)
+	}
+
+	if dualStack && (ipv4 == 0 || ipv6 == 0) {
+		return nil, fmt.Errorf("%s must contain both an IPv4 and an IPv6 CIDR when dualStack is enabled", field)
+	}
+
+	if !dualStack && ipv4 > 0 && ipv6 > 0 {
+		return nil, fmt.Errorf("%s contains both IPv4 and IPv6 CIDRs, dualStack must be enabled", field)
+	}
+
+	return result, nil
+}
+
+// ipFamilies returns a stable key of the IP families in use, e.g. "4", "6" or "46"
+func ipFamilies(nets []*net.IPNet) string {
+	var ipv4, ipv6 bool
+	for _, n := range nets {
+		if n.IP.To4() != nil {
+			ipv4 = true
+		} else {
+			ipv6 = true
+		}
+	}
+
+	families := ""
+	if ipv4 {
+		families += "4"
+	}
+	if ipv6 {
+		families += "6"
+	}
+	return families
+}
+
+func ipInNets(ip net.IP, nets []*net.IPNet) bool {
+	for _, n := range nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func validatePortRange(portRange string) error {
+	parts := strings.Split(portRange, "-")
+	if len(parts) != 2 {
+		return fmt.Errorf("must be in the format <start>-<end>")
+	}
+
+	start, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+	if err != nil {
+		return err
+	}
+	end, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+	if err != nil {
+		return err
+	}
+
+	if start < 1 || end > 65535 || start > end {
+		return fmt.Errorf("port range must be within 1-65535 and start must not exceed end")
+	}
+	return nil
+}
@@ -0,0 +1,196 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNetworkConfigValidate(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		network *NetworkConfig
+		wantErr bool
+	}{
+		{
+			name:    "nil network",
+			network: nil,
+		},
+		{
+			name: "valid ipv4",
+			network: &NetworkConfig{
+				CNI:           CNIFlannel,
+				ClusterCIDR:   []string{"10.42.0.0/16"},
+				ServiceCIDR:   []string{"10.43.0.0/16"},
+				ClusterDNS:    []string{"10.43.0.10"},
+				NodePortRange: "30000-32767",
+			},
+		},
+		{
+			name: "valid dual-stack",
+			network: &NetworkConfig{
+				DualStack:   true,
+				ClusterCIDR: []string{"10.42.0.0/16", "2001:cafe:42::/56"},
+				ServiceCIDR: []string{"10.43.0.0/16", "2001:cafe:43::/112"},
+				ClusterDNS:  []string{"10.43.0.10", "2001:cafe:43::a"},
+			},
+		},
+		{
+			name:    "unsupported cni",
+			network: &NetworkConfig{CNI: "weave"},
+			wantErr: true,
+		},
+		{
+			name:    "flannel backend with calico",
+			network: &NetworkConfig{CNI: CNICalico, FlannelBackend: FlannelBackendVXLAN},
+			wantErr: true,
+		},
+		{
+			name: "overlapping cidrs",
+			network: &NetworkConfig{
+				ClusterCIDR: []string{"10.42.0.0/16"},
+				ServiceCIDR: []string{"10.42.128.0/17"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "dual-stack without ipv6",
+			network: &NetworkConfig{
+				DualStack:   true,
+				ClusterCIDR: []string{"10.42.0.0/16"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "mixed families without dual-stack",
+			network: &NetworkConfig{
+				ClusterCIDR: []string{"10.42.0.0/16", "2001:cafe:42::/56"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "mismatched families",
+			network: &NetworkConfig{
+				ClusterCIDR: []string{"10.42.0.0/16"},
+				ServiceCIDR: []string{"2001:cafe:43::/112"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "cluster dns outside service cidr",
+			network: &NetworkConfig{
+				ServiceCIDR: []string{"10.43.0.0/16"},
+				ClusterDNS:  []string{"10.44.0.10"},
+			},
+			wantErr: true,
+		},
+		{
+			name:    "invalid node port range",
+			network: &NetworkConfig{NodePortRange: "32767-30000"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.network.Validate()
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestNetworkConfigValidateRuntime(t *testing.T) {
+	tests := []struct {
+		name    string
+		network *NetworkConfig
+		runtime Runtime
+		err     string
+	}{
+		{name: "unset", runtime: RuntimeK3S},
+		{name: "flannel on k3s", network: &NetworkConfig{CNI: CNIFlannel, FlannelBackend: FlannelBackendVXLAN},
+			runtime: RuntimeK3S},
+		{name: "canal on rke2", network: &NetworkConfig{CNI: CNICanal}, runtime: RuntimeRKE2},
+		{name: "canal on k3s", network: &NetworkConfig{CNI: CNICanal}, runtime: RuntimeK3S,
+			err: "cni canal is not supported by k3s"},
+		{name: "flannel backend on rke2", network: &NetworkConfig{FlannelBackend: FlannelBackendHostGW},
+			runtime: RuntimeRKE2, err: "flannelBackend is not supported by rke2"},
+		{name: "unknown runtime", network: &NetworkConfig{CNI: CNICanal}, runtime: RuntimeUnknown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.network.ValidateRuntime(tt.runtime)
+			if tt.err == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tt.err)
+			}
+		})
+	}
+}
+
+func TestValidateNetworkKeys(t *testing.T) {
+	tests := []struct {
+		name    string
+		network *NetworkConfig
+		values  map[string]interface{}
+		runtime Runtime
+		err     string
+	}{
+		{name: "unset", values: map[string]interface{}{"cluster-cidr": "10.42.0.0/16"}, runtime: RuntimeK3S},
+		{
+			name:    "distinct keys",
+			network: &NetworkConfig{ClusterCIDR: []string{"10.42.0.0/16"}},
+			values:  map[string]interface{}{"service-cidr": "10.43.0.0/16"},
+			runtime: RuntimeK3S,
+		},
+		{
+			name:    "cidr",
+			network: &NetworkConfig{ClusterCIDR: []string{"10.42.0.0/16"}},
+			values:  map[string]interface{}{"cluster-cidr": "10.52.0.0/16"},
+			runtime: RuntimeRKE2,
+			err:     "extraConfig cluster-cidr is also set by the network config",
+		},
+		{
+			name:    "normalized key",
+			network: &NetworkConfig{ClusterDomain: "llmos.local"},
+			values:  map[string]interface{}{"clusterDomain": "cluster.local"},
+			runtime: RuntimeK3S,
+			err:     "extraConfig clusterDomain is also set by the network config",
+		},
+		{
+			name:    "k3s cni",
+			network: &NetworkConfig{CNI: CNICalico},
+			values:  map[string]interface{}{"disable-network-policy": false},
+			runtime: RuntimeK3S,
+			err:     "extraConfig disable-network-policy is also set by the network config",
+		},
+		{
+			name:    "cni key of k3s",
+			network: &NetworkConfig{CNI: CNICalico},
+			values:  map[string]interface{}{"cni": "calico"},
+			runtime: RuntimeK3S,
+		},
+		{
+			name:    "rke2 cni",
+			network: &NetworkConfig{CNI: CNICalico},
+			values:  map[string]interface{}{"cni": "canal"},
+			runtime: RuntimeRKE2,
+			err:     "extraConfig cni is also set by the network config",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &RuntimeConfig{Network: tt.network, ConfigValues: tt.values}
+			err := cfg.ValidateNetworkKeys(tt.runtime)
+			if tt.err == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tt.err)
+			}
+		})
+	}
+}
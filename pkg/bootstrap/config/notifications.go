@@ -0,0 +1,70 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// The bootstrap events sent to the webhooks
+const (
+	NotificationEventStarted       = "started"
+	NotificationEventPhase         = "phase"
+	NotificationEventAttemptFailed = "attempt-failed"
+	NotificationEventSucceeded     = "succeeded"
+	NotificationEventFailed        = "failed"
+)
+
+// NotificationsConfig contains the webhooks notified of the bootstrap progress
+type NotificationsConfig struct {
+	Webhooks []WebhookConfig `json:"webhooks,omitempty"`
+}
+
+// WebhookConfig is an endpoint receiving the bootstrap events as JSON POST requests
+type WebhookConfig struct {
+	URL string `json:"url"`
+	// Headers are added to the requests, e.g. an Authorization header
+	Headers map[string]string `json:"headers,omitempty"`
+	// Secret signs the body with HMAC-SHA256 in the X-LLMOS-Signature header as sha256=<hex digest>
+	Secret string `json:"secret,omitempty"`
+	// Retries is the number of retries of a failed delivery with an exponential backoff, default 3,
+	// 0 disables the retries
+	Retries *int `json:"retries,omitempty"`
+	// Timeout of a delivery attempt, default 10s
+	Timeout string `json:"timeout,omitempty"`
+	// Events limits the webhook to the events, all events are sent if it is empty
+	Events []string `json:"events,omitempty"`
+}
+
+func (n *NotificationsConfig) Validate() error {
+	if n == nil {
+		return nil
+	}
+
+	for i, w := range n.Webhooks {
+		u, err := url.Parse(w.URL)
+		if err != nil {
+			return fmt.Errorf("webhook %d has an invalid url: %v", i, err)
+		}
+		if u.Scheme != "http" && u.Scheme != "https" {
+			return fmt.Errorf("webhook %d url %s must be http or https", i, u.Redacted())
+		}
+		if w.Retries != nil && *w.Retries < 0 {
+			return fmt.Errorf("webhook %d retries must not be negative", i)
+		}
+		if w.Timeout != "" {
+			if _, err = time.ParseDuration(w.Timeout); err != nil {
+				return fmt.Errorf("webhook %d has an invalid timeout: %v", i, err)
+			}
+		}
+		for _, event := range w.Events {
+			switch event {
+			case NotificationEventStarted, NotificationEventPhase, NotificationEventAttemptFailed,
+				NotificationEventSucceeded, NotificationEventFailed:
+			default:
+				return fmt.Errorf("webhook %d has unknown event %s", i, event)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,27 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateNotifications(t *testing.T) {
+	var n *NotificationsConfig
+	assert.NoError(t, n.Validate())
+
+	noRetries, negative := 0, -1
+	valid := WebhookConfig{URL: "https://hooks.example.com/llmos", Timeout: "5s", Events: []string{NotificationEventFailed}}
+	assert.NoError(t, (&NotificationsConfig{Webhooks: []WebhookConfig{valid}}).Validate())
+	valid.Retries = &noRetries
+	assert.NoError(t, (&NotificationsConfig{Webhooks: []WebhookConfig{valid}}).Validate())
+
+	for _, w := range []WebhookConfig{
+		{URL: "hooks.example.com/llmos"},
+		{URL: "https://hooks.example.com", Retries: &negative},
+		{URL: "https://hooks.example.com", Timeout: "5"},
+		{URL: "https://hooks.example.com", Events: []string{"ready"}},
+	} {
+		assert.Error(t, (&NotificationsConfig{Webhooks: []WebhookConfig{valid, w}}).Validate(), w)
+	}
+}
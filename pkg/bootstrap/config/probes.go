@@ -0,0 +1,79 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/llmos-ai/llmos/pkg/applyinator/prober"
+	"github.com/llmos-ai/llmos/pkg/bootstrap/role"
+)
+
+// The probe roles besides the node roles, they match the nodes by their role like the role package
+const (
+	ProbeRoleControlPlane = "control-plane"
+	ProbeRoleEtcd         = "etcd"
+	ProbeRoleWorker       = "worker"
+)
+
+// ProbeConfig is a user-defined probe added to the plan of the nodes, a probe named after a built-in
+// probe, e.g. kubelet, replaces it
+type ProbeConfig struct {
+	prober.Probe
+	// Roles limits the probe to the nodes matching one of the roles, e.g. server or control-plane,
+	// the probe runs on all nodes if it is empty
+	Roles []string `json:"roles,omitempty"`
+	// Disable removes the probe from the plan, e.g. to disable a built-in probe
+	Disable bool `json:"disable,omitempty"`
+}
+
+// AppliesTo returns whether the probe runs on the node, the etcd probes only run on the nodes running
+// the embedded etcd
+func (p *ProbeConfig) AppliesTo(cfg *RuntimeConfig) bool {
+	if len(p.Roles) == 0 {
+		return true
+	}
+
+	nodeRole := cfg.Role
+	for _, r := range p.Roles {
+		switch r {
+		case ProbeRoleControlPlane:
+			if role.IsControlPlane(string(nodeRole)) {
+				return true
+			}
+		case ProbeRoleEtcd:
+			if cfg.RunsEtcd() {
+				return true
+			}
+		case ProbeRoleWorker:
+			if role.IsWorker(string(nodeRole)) {
+				return true
+			}
+		default:
+			if Role(r) == nodeRole {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ValidateProbes validates the user-defined probes, a disabled probe only needs its name
+func (c *Config) ValidateProbes() error {
+	for name, p := range c.Probes {
+		for _, r := range p.Roles {
+			switch Role(r) {
+			case ClusterInitRole, ServerRole, AgentRole, ProbeRoleControlPlane, ProbeRoleEtcd, ProbeRoleWorker:
+			default:
+				return fmt.Errorf("probe %s has unknown role %s", name, r)
+			}
+		}
+		if p.Disable {
+			continue
+		}
+
+		p.Name = name
+		if err := p.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
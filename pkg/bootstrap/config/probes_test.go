@@ -0,0 +1,83 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadProbes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+probes:
+  inference-gateway:
+    roles: [worker]
+    timeoutSeconds: 3
+    httpGet:
+      url: http://127.0.0.1:8080/healthz
+      expectedStatus: ["2xx"]
+  gpu-device-plugin:
+    roles: [agent]
+    tcpSocket:
+      address: unix:///var/lib/kubelet/device-plugins/nvidia-gpu.sock
+  kube-scheduler:
+    disable: true
+`), 0600))
+
+	cfg, err := Load(path)
+	require.NoError(t, err)
+	require.Len(t, cfg.Probes, 3)
+
+	gateway := cfg.Probes["inference-gateway"]
+	assert.Equal(t, []string{ProbeRoleWorker}, gateway.Roles)
+	assert.Equal(t, 3, gateway.TimeoutSeconds)
+	assert.Equal(t, "http://127.0.0.1:8080/healthz", gateway.HTTPGetAction.URL)
+	assert.Equal(t, []string{"2xx"}, gateway.HTTPGetAction.ExpectedStatus)
+	require.NotNil(t, cfg.Probes["gpu-device-plugin"].TCPSocketAction)
+	assert.True(t, cfg.Probes["kube-scheduler"].Disable)
+	assert.NoError(t, cfg.ValidateProbes())
+}
+
+func TestProbeAppliesTo(t *testing.T) {
+	tests := []struct {
+		roles     []string
+		nodeRole  Role
+		datastore bool
+		applies   bool
+	}{
+		{nodeRole: AgentRole, applies: true},
+		{roles: []string{"agent"}, nodeRole: AgentRole, applies: true},
+		{roles: []string{"agent"}, nodeRole: ServerRole},
+		{roles: []string{ProbeRoleControlPlane}, nodeRole: ClusterInitRole, applies: true},
+		{roles: []string{ProbeRoleControlPlane}, nodeRole: AgentRole},
+		{roles: []string{ProbeRoleEtcd}, nodeRole: ServerRole, applies: true},
+		{roles: []string{ProbeRoleEtcd}, nodeRole: ServerRole, datastore: true},
+		{roles: []string{ProbeRoleControlPlane}, nodeRole: ServerRole, datastore: true, applies: true},
+		{roles: []string{ProbeRoleEtcd}, nodeRole: AgentRole},
+		{roles: []string{ProbeRoleWorker}, nodeRole: AgentRole, applies: true},
+		{roles: []string{"cluster-init", "agent"}, nodeRole: AgentRole, applies: true},
+	}
+	for _, tt := range tests {
+		p := ProbeConfig{Roles: tt.roles}
+		cfg := &RuntimeConfig{Role: tt.nodeRole}
+		if tt.datastore {
+			cfg.Datastore = &DatastoreConfig{Endpoint: "postgres://k3s@db:5432/k3s"}
+		}
+		assert.Equal(t, tt.applies, p.AppliesTo(cfg), "roles %v on %s, datastore %t", tt.roles, tt.nodeRole,
+			tt.datastore)
+	}
+}
+
+func TestValidateProbes(t *testing.T) {
+	cfg := &Config{Probes: map[string]ProbeConfig{"no-action": {}}}
+	assert.ErrorContains(t, cfg.ValidateProbes(), "probe no-action has no action")
+
+	cfg = &Config{Probes: map[string]ProbeConfig{"kubelet": {Disable: true}}}
+	assert.NoError(t, cfg.ValidateProbes())
+
+	cfg = &Config{Probes: map[string]ProbeConfig{"kubelet": {Disable: true, Roles: []string{"gpu"}}}}
+	assert.ErrorContains(t, cfg.ValidateProbes(), "unknown role gpu")
+}
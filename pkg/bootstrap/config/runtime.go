@@ -2,6 +2,8 @@ package config
 
 import (
 	"strings"
+
+	"github.com/llmos-ai/llmos/pkg/bootstrap/role"
 )
 
 const (
@@ -33,6 +35,12 @@ type RuntimeConfig struct {
 	Labels          []string               `json:"labels,omitempty"`
 	Token           string                 `json:"token,omitempty"`
 	ConfigValues    map[string]interface{} `json:"extraConfig,omitempty"`
+	Network         *NetworkConfig         `json:"network,omitempty"`
+	Kubelet         *KubeletConfig         `json:"kubelet,omitempty"`
+	KubeAPIServer   *KubeAPIServerConfig   `json:"kubeApiserver,omitempty"`
+	Containerd      *ContainerdConfig      `json:"containerd,omitempty"`
+	Datastore       *DatastoreConfig       `json:"datastore,omitempty"`
+	EtcdSnapshot    *EtcdSnapshotConfig    `json:"etcdSnapshot,omitempty"`
 	// SystemDefaultRegistry specify the mirror registry used for k8s runtime images
 	SystemDefaultRegistry string `json:"systemDefaultRegistry,omitempty"`
 }
@@ -54,11 +62,16 @@ func (cfg *RuntimeConfig) SetDefaults() {
 	}
 
 	// Enable etcd metrics by default
-	if cfg.ConfigValues[EtcdExposeMetrics] == nil && cfg.Role != AgentRole {
+	if cfg.ConfigValues[EtcdExposeMetrics] == nil && cfg.Role != AgentRole && cfg.RunsEtcd() {
 		cfg.ConfigValues[EtcdExposeMetrics] = true
 	}
 }
 
+// RunsEtcd returns whether the node runs the embedded etcd, the external datastore replaces it
+func (cfg *RuntimeConfig) RunsEtcd() bool {
+	return cfg.Datastore == nil && role.IsEtcd(string(cfg.Role))
+}
+
 func GetRuntime(kubernetesVersion string) Runtime {
 	if isRKE2(kubernetesVersion) {
 		return RuntimeRKE2
@@ -0,0 +1,68 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// EtcdSnapshotConfig contains the scheduled etcd snapshot settings of the embedded etcd
+type EtcdSnapshotConfig struct {
+	// ScheduleCron is the snapshot schedule in cron format, e.g. "0 */12 * * *"
+	ScheduleCron string `json:"scheduleCron,omitempty"`
+	// Retention is the number of snapshots to keep
+	Retention int `json:"retention,omitempty"`
+	// Dir is the local directory to save the snapshots to
+	Dir      string `json:"dir,omitempty"`
+	Compress bool   `json:"compress,omitempty"`
+	Disable  bool   `json:"disable,omitempty"`
+	// S3 uploads the snapshots to an S3 compatible storage
+	S3 *S3Config `json:"s3,omitempty"`
+}
+
+// S3Config contains the S3 compatible target of the etcd snapshots
+type S3Config struct {
+	Endpoint      string `json:"endpoint,omitempty"`
+	EndpointCA    string `json:"endpointCA,omitempty"`
+	SkipSSLVerify bool   `json:"skipSSLVerify,omitempty"`
+	Insecure      bool   `json:"insecure,omitempty"`
+	AccessKey     string `json:"accessKey,omitempty"`
+	SecretKey     string `json:"secretKey,omitempty"`
+	Bucket        string `json:"bucket,omitempty"`
+	Region        string `json:"region,omitempty"`
+	Folder        string `json:"folder,omitempty"`
+}
+
+func (e *EtcdSnapshotConfig) Validate() error {
+	if e == nil {
+		return nil
+	}
+
+	if e.Retention < 0 {
+		return fmt.Errorf("etcd snapshot retention must not be negative")
+	}
+
+	if e.ScheduleCron != "" && len(strings.Fields(e.ScheduleCron)) != 5 {
+		return fmt.Errorf("invalid etcd snapshot scheduleCron %q, must have 5 fields", e.ScheduleCron)
+	}
+
+	if e.S3 != nil {
+		if e.S3.Bucket == "" {
+			return fmt.Errorf("etcd snapshot s3 bucket is required")
+		}
+		if strings.Contains(e.S3.Endpoint, "://") {
+			return fmt.Errorf("etcd snapshot s3 endpoint %s must not contain a scheme, use insecure for http",
+				e.S3.Endpoint)
+		}
+	}
+
+	return nil
+}
+
+// GetSnapshotDir returns the local snapshot directory of the runtime
+func (e *EtcdSnapshotConfig) GetSnapshotDir(runtime Runtime) string {
+	if e != nil && e.Dir != "" {
+		return e.Dir
+	}
+	return filepath.Join("/var/lib/rancher", string(runtime), "server/db/snapshots")
+}
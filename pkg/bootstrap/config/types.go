@@ -49,6 +49,9 @@ type Config struct {
 	KubernetesVersion    string `json:"kubernetesVersion,omitempty"`
 	LLMOSOperatorVersion string `json:"llmosOperatorVersion,omitempty"`
 	ChartRepo            string `json:"chartRepo,omitempty"`
+	// RegistrationAddress is the fixed address (IP or DNS name) for the nodes to join the cluster
+	RegistrationAddress string     `json:"registrationAddress,omitempty"`
+	VIP                 *VIPConfig `json:"vip,omitempty"`
 
 	LLMOSOperatorValues map[string]interface{}           `json:"llmosOperatorValues,omitempty"`
 	PreInstructions     []applyinator.OneTimeInstruction `json:"preInstructions,omitempty"`
@@ -62,6 +65,10 @@ type Config struct {
 	Mirror                    string               `json:"mirror,omitempty"`
 	Registries                *registries.Registry `json:"registries,omitempty"`
 	ImageUtility              *image.Utility       `json:"imageUtility,omitempty"`
+	// Probes adds, replaces or disables the probes of the plan by their name
+	Probes map[string]ProbeConfig `json:"probes,omitempty"`
+	// Notifications sends the bootstrap progress to webhooks
+	Notifications *NotificationsConfig `json:"notifications,omitempty"`
 }
 
 func paths() (result []string) {
@@ -43,6 +43,19 @@ func GetRuntimeInstallerImage(imageOverride, registry, mirror, kubernetesVersion
 		string(config.GetRuntime(kubernetesVersion)), kubernetesVersion)
 }
 
+// GetRuntimeUpgradeImage returns the system-upgrade-controller image upgrading the k8s runtime,
+// the controller tags it with the version of the plan
+func GetRuntimeUpgradeImage(registry, mirror string, runtime config.Runtime) string {
+	if registry == "" {
+		if mirror != "" {
+			registry = AliSystemDefaultRegistry
+		} else {
+			registry = "docker.io"
+		}
+	}
+	return fmt.Sprintf("%s/rancher/%s-upgrade", registry, runtime)
+}
+
 func getInstallerImage(imageOverride, registry, imagePrefix, component, version string) string {
 	if imageOverride != "" {
 		return imageOverride
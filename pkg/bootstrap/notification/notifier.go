@@ -0,0 +1,326 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/llmos-ai/llmos/pkg/bootstrap/config"
+	"github.com/llmos-ai/llmos/pkg/utils/redact"
+)
+
+// The headers of the webhook requests, the signature is only set when the webhook has a secret
+const (
+	EventHeader     = "X-LLMOS-Event"
+	DeliveryHeader  = "X-LLMOS-Delivery"
+	SignatureHeader = "X-LLMOS-Signature"
+)
+
+const (
+	defaultRetries = 3
+	defaultTimeout = 10 * time.Second
+	defaultBackoff = time.Second
+	maxBackoff     = 30 * time.Second
+	maxOutputTail  = 4096
+	// queueSize bounds the events waiting for their delivery, the events are dropped once it is full
+	queueSize = 32
+)
+
+// Event is the body of the webhook requests
+type Event struct {
+	Event                string             `json:"event"`
+	Phase                string             `json:"phase,omitempty"`
+	Time                 time.Time          `json:"time"`
+	Attempt              int                `json:"attempt,omitempty"`
+	Node                 Node               `json:"node"`
+	KubernetesVersion    string             `json:"kubernetesVersion,omitempty"`
+	LLMOSOperatorVersion string             `json:"llmosOperatorVersion,omitempty"`
+	Error                string             `json:"error,omitempty"`
+	FailedInstruction    *FailedInstruction `json:"failedInstruction,omitempty"`
+}
+
+// Node identifies the node sending the event
+type Node struct {
+	Hostname   string `json:"hostname"`
+	Role       string `json:"role,omitempty"`
+	OSVersion  string `json:"osVersion,omitempty"`
+	Arch       string `json:"arch"`
+	CLIVersion string `json:"cliVersion"`
+}
+
+// FailedInstruction is the one-time instruction failing the plan and the redacted tail of its output
+type FailedInstruction struct {
+	Name       string `json:"name"`
+	OutputTail string `json:"outputTail,omitempty"`
+}
+
+// Notifier sends the events to the webhooks of the config, the deliveries are retried and their
+// failures are only logged so that they never fail the bootstrap
+type Notifier struct {
+	client *http.Client
+	// backoff is the delay before the first retry, it doubles with every retry
+	backoff time.Duration
+
+	mu       sync.Mutex
+	webhooks []config.WebhookConfig
+
+	// queue holds the events sent in the background by Send, they are delivered in order
+	queue   chan queuedEvent
+	start   sync.Once
+	pending sync.WaitGroup
+	close   sync.Once
+}
+
+// queuedEvent is delivered to the webhooks of the notifier at the time it was sent
+type queuedEvent struct {
+	ctx      context.Context
+	webhooks []config.WebhookConfig
+	event    Event
+}
+
+func New(cfg *config.NotificationsConfig) *Notifier {
+	n := &Notifier{
+		client:  &http.Client{},
+		backoff: defaultBackoff,
+		queue:   make(chan queuedEvent, queueSize),
+	}
+	n.SetWebhooks(cfg)
+	return n
+}
+
+// SetWebhooks replaces the webhooks of the events sent afterwards, e.g. once the config is reloaded,
+// the queued events are still delivered to the webhooks they were sent to
+func (n *Notifier) SetWebhooks(cfg *config.NotificationsConfig) {
+	var webhooks []config.WebhookConfig
+	if cfg != nil {
+		webhooks = cfg.Webhooks
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.webhooks = webhooks
+}
+
+func (n *Notifier) getWebhooks() []config.WebhookConfig {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.webhooks
+}
+
+func (n *Notifier) run() {
+	for q := range n.queue {
+		n.notify(q.ctx, q.webhooks, q.event)
+		n.pending.Done()
+	}
+}
+
+// Send queues the event to be delivered in the background, the events are delivered in the order they
+// are sent. Use Flush to wait for their delivery
+func (n *Notifier) Send(ctx context.Context, event Event) {
+	if n == nil {
+		return
+	}
+	webhooks := n.getWebhooks()
+	if len(webhooks) == 0 {
+		return
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now().UTC()
+	}
+
+	n.start.Do(func() {
+		go n.run()
+	})
+	n.pending.Add(1)
+	select {
+	case n.queue <- queuedEvent{ctx: ctx, webhooks: webhooks, event: event}:
+	default:
+		n.pending.Done()
+		logrus.Warnf("dropping %s notification, %d notifications are waiting for their delivery",
+			event.Event, queueSize)
+	}
+}
+
+// Flush waits for the delivery of the sent events, it gives up once the context is done and leaves
+// the remaining events to the background
+func (n *Notifier) Flush(ctx context.Context) error {
+	if n == nil {
+		return nil
+	}
+
+	done := make(chan struct{})
+	go func() {
+		n.pending.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close stops the background delivery once the sent events are delivered, the notifier must not send
+// any events afterwards
+func (n *Notifier) Close() {
+	if n == nil {
+		return
+	}
+	n.close.Do(func() {
+		close(n.queue)
+	})
+}
+
+// Notify sends the event to the webhooks subscribed to it in order, it returns once every delivery
+// succeeded or ran out of retries
+func (n *Notifier) Notify(ctx context.Context, event Event) {
+	if n == nil {
+		return
+	}
+	n.notify(ctx, n.getWebhooks(), event)
+}
+
+func (n *Notifier) notify(ctx context.Context, webhooks []config.WebhookConfig, event Event) {
+	if len(webhooks) == 0 {
+		return
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now().UTC()
+	}
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		logrus.Errorf("marshaling %s notification: %v", event.Event, err)
+		return
+	}
+	delivery := deliveryID()
+
+	for _, webhook := range webhooks {
+		if len(webhook.Events) > 0 && !slices.Contains(webhook.Events, event.Event) {
+			continue
+		}
+		if err := n.deliver(ctx, webhook, event.Event, delivery, body); err != nil {
+			logrus.Warnf("sending %s notification to webhook %s: %v", event.Event, redactedURL(webhook.URL), err)
+		}
+	}
+}
+
+func (n *Notifier) deliver(ctx context.Context, webhook config.WebhookConfig, event, delivery string,
+	body []byte) error {
+	retries := defaultRetries
+	if webhook.Retries != nil {
+		retries = *webhook.Retries
+	}
+	timeout := defaultTimeout
+	if webhook.Timeout != "" {
+		if d, err := time.ParseDuration(webhook.Timeout); err == nil {
+			timeout = d
+		}
+	}
+
+	backoff := n.backoff
+	for attempt := 0; ; attempt++ {
+		retry, err := n.send(ctx, webhook, timeout, event, delivery, body)
+		if err == nil {
+			return nil
+		}
+		if !retry || attempt >= retries {
+			return err
+		}
+		logrus.Debugf("retrying %s notification to webhook %s in %s: %v", event, redactedURL(webhook.URL), backoff, err)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, maxBackoff)
+	}
+}
+
+// send posts the body once, it returns whether a failed delivery can be retried
+func (n *Notifier) send(ctx context.Context, webhook config.WebhookConfig, timeout time.Duration,
+	event, delivery string, body []byte) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	for name, value := range webhook.Headers {
+		req.Header.Set(name, value)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, event)
+	req.Header.Set(DeliveryHeader, delivery)
+	if webhook.Secret != "" {
+		req.Header.Set(SignatureHeader, Sign([]byte(webhook.Secret), body))
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	err = fmt.Errorf("unexpected status %s", resp.Status)
+	// the other client errors, e.g. a wrong signature, fail the same way again
+	return resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests ||
+		resp.StatusCode == http.StatusRequestTimeout, err
+}
+
+// Sign returns the X-LLMOS-Signature header of the body, the receivers compute the HMAC-SHA256 of the
+// raw body with the shared secret and compare it in constant time
+func Sign(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// deliveryID identifies the deliveries of an event, the retries and all webhooks get the same ID so
+// that the receivers can drop the duplicates
+func deliveryID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%d", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
+
+// redactedURL hides the password and the query of the webhook URL in the logs, they often hold a token
+func redactedURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "<invalid url>"
+	}
+	u.RawQuery = ""
+	return u.Redacted()
+}
+
+// OutputTail returns the last bytes of the output with the secrets redacted, the output is redacted
+// before it is cut so that a partial secret is never kept
+func OutputTail(output []byte) string {
+	tail := redact.Text(string(output))
+	if len(tail) > maxOutputTail {
+		tail = tail[len(tail)-maxOutputTail:]
+	}
+	return tail
+}
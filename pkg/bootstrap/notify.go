@@ -0,0 +1,103 @@
+package bootstrap
+
+import (
+	"context"
+	"errors"
+	"os"
+	"runtime"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/llmos-ai/llmos/pkg/bootstrap/config"
+	"github.com/llmos-ai/llmos/pkg/bootstrap/notification"
+	"github.com/llmos-ai/llmos/pkg/bootstrap/plan"
+	"github.com/llmos-ai/llmos/pkg/utils/redact"
+	cliversion "github.com/llmos-ai/llmos/pkg/version"
+)
+
+// The phases of a bootstrap attempt sent with the phase event
+const (
+	NotificationPhaseResolvingVersions = "resolving-versions"
+	NotificationPhaseGeneratingPlan    = "generating-plan"
+	NotificationPhaseApplyingPlan      = "applying-plan"
+)
+
+// notifyFlushTimeout bounds the wait for the delivery of the notifications once the bootstrap finished,
+// they are also delivered when the bootstrap is canceled
+const notifyFlushTimeout = 30 * time.Second
+
+// errInvalidConfig terminates the bootstrap without retrying
+var errInvalidConfig = errors.New("invalid config")
+
+// setNotifier sets the webhooks of the config loaded by the attempt, the first attempt creates the notifier
+// and sends the started event. The run keeps one notifier so that the events queued by the previous
+// attempts are flushed too. An invalid notifications config is reported by validateConfig
+func (l *LLMOS) setNotifier(ctx context.Context, cfg *config.Config, attempt int) {
+	if cfg.Notifications.Validate() != nil {
+		return
+	}
+
+	first := l.notifier == nil
+	if first {
+		l.notifier = notification.New(cfg.Notifications)
+	} else {
+		l.notifier.SetWebhooks(cfg.Notifications)
+	}
+	hostname, _ := os.Hostname()
+	l.node = notification.Node{
+		Hostname:   hostname,
+		Role:       string(cfg.Role),
+		OSVersion:  getOSVersion(),
+		Arch:       runtime.GOARCH,
+		CLIVersion: cliversion.GetFriendlyVersion(),
+	}
+	if first {
+		l.notify(ctx, notification.Event{Event: config.NotificationEventStarted, Attempt: attempt})
+	}
+}
+
+// notify sends the event in the background so that a slow webhook never delays the bootstrap
+func (l *LLMOS) notify(ctx context.Context, event notification.Event) {
+	event.Node = l.node
+	l.notifier.Send(ctx, event)
+}
+
+// flushNotifications waits for the delivery of the sent events within the notifyFlushTimeout
+func (l *LLMOS) flushNotifications(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), notifyFlushTimeout)
+	defer cancel()
+	if err := l.notifier.Flush(ctx); err != nil {
+		logrus.Warnf("giving up waiting for the delivery of the notifications: %v", err)
+	}
+	l.notifier.Close()
+}
+
+func (l *LLMOS) notifyPhase(ctx context.Context, attempt int, phase, k8sVersion, operatorVersion string) {
+	l.notify(ctx, notification.Event{
+		Event:                config.NotificationEventPhase,
+		Phase:                phase,
+		Attempt:              attempt,
+		KubernetesVersion:    k8sVersion,
+		LLMOSOperatorVersion: operatorVersion,
+	})
+}
+
+// notifyFailure sends the failure of the attempt with the output tail of the failed instruction, if any
+func (l *LLMOS) notifyFailure(ctx context.Context, eventType string, attempt int, err error) {
+	event := notification.Event{
+		Event:   eventType,
+		Attempt: attempt,
+		Error:   redact.Text(err.Error()),
+	}
+	var instructionErr *plan.InstructionError
+	if errors.As(err, &instructionErr) {
+		event.FailedInstruction = &notification.FailedInstruction{
+			Name:       instructionErr.Instruction,
+			OutputTail: notification.OutputTail(instructionErr.Output),
+		}
+	}
+
+	// the failures are also delivered when the bootstrap is canceled
+	l.notify(context.WithoutCancel(ctx), event)
+}
@@ -5,6 +5,8 @@ import (
 	"fmt"
 
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/llmos-ai/llmos/pkg/applyinator"
 	"github.com/llmos-ai/llmos/pkg/bootstrap/config"
@@ -15,14 +17,16 @@ import (
 	"github.com/llmos-ai/llmos/pkg/bootstrap/role"
 	"github.com/llmos-ai/llmos/pkg/bootstrap/runtime"
 	"github.com/llmos-ai/llmos/pkg/bootstrap/version"
+	"github.com/llmos-ai/llmos/pkg/bootstrap/vip"
 	"github.com/llmos-ai/llmos/pkg/cli/probe"
+	"github.com/llmos-ai/llmos/pkg/utils/tracing"
 )
 
 type plan applyinator.Plan
 
 func toInitPlan(cfg *config.Config, dataDir string) (*applyinator.Plan, error) {
 	logrus.Info("generating init plan")
-	if err := assignTokenIfUnset(cfg); err != nil {
+	if err := assignTokenIfUnset(cfg, dataDir); err != nil {
 		return nil, err
 	}
 
@@ -53,7 +57,8 @@ func toJoinPlan(cfg *config.Config, dataDir string) (*applyinator.Plan, error) {
 	if !etcd && !controlPlane && !worker {
 		return nil, fmt.Errorf("invalid role (%s) defined", cfgRole)
 	}
-	if cfg.Server == "" {
+	// the servers sharing an external datastore join the cluster through the datastore
+	if cfg.Server == "" && (cfg.Datastore == nil || cfg.Role == config.AgentRole) {
 		return nil, fmt.Errorf("server is required in config for all roles besides cluster-init")
 	}
 	if cfg.Token == "" {
@@ -83,7 +88,12 @@ func toJoinPlan(cfg *config.Config, dataDir string) (*applyinator.Plan, error) {
 	return (*applyinator.Plan)(&p), nil
 }
 
-func ToPlan(_ context.Context, cfg *config.Config, dataDir string) (*applyinator.Plan, error) {
+func ToPlan(ctx context.Context, cfg *config.Config, dataDir string) (p *applyinator.Plan, err error) {
+	_, span := tracing.Start(ctx, "plan.ToPlan", trace.WithAttributes(attribute.String("llmos.role", string(cfg.Role))))
+	defer func() {
+		tracing.End(span, err)
+	}()
+
 	newCfg := *cfg
 	if newCfg.Role == config.ClusterInitRole {
 		return toInitPlan(&newCfg, dataDir)
@@ -98,6 +108,14 @@ func (p *plan) addInstructions(cfg *config.Config, dataDir string, initRole bool
 		return err
 	}
 
+	registrationURL := cfg.GetRegistrationURL()
+	// joining nodes need the registration address to be reachable before installing the runtime
+	if !initRole && registrationURL != "" {
+		if err = p.addInstruction(runtime.ToWaitRegistrationAddressInstruction(registrationURL)); err != nil {
+			return err
+		}
+	}
+
 	// add k8s runtime instruction
 	if err = p.addInstruction(runtime.ToInstruction(cfg, k8sVersion)); err != nil {
 		return err
@@ -108,6 +126,13 @@ func (p *plan) addInstructions(cfg *config.Config, dataDir string, initRole bool
 		return err
 	}
 
+	// the cluster-init node waits for the VIP to be announced once the runtime is up
+	if initRole && registrationURL != "" {
+		if err = p.addInstruction(runtime.ToWaitRegistrationAddressInstruction(registrationURL)); err != nil {
+			return err
+		}
+	}
+
 	if cfg.Role != config.AgentRole {
 		// Copy kubeconfig for cluster-init and server node
 		if err = p.addInstruction(runtime.CopyKubeConfigInstruction(k8sVersion)); err != nil {
@@ -217,7 +242,12 @@ func (p *plan) addFiles(cfg *config.Config, dataDir string) error {
 	}
 
 	// bootstrap config.yaml
-	if err = p.addFile(runtime.ToBootstrapFile(&cfg.RuntimeConfig, runtimeName, cfg.Server)); err != nil {
+	if err = p.addFile(runtime.ToBootstrapFile(&cfg.RuntimeConfig, runtimeName, cfg.Server, dataDir)); err != nil {
+		return err
+	}
+
+	// kube-vip manifests
+	if err = p.addFile(vip.ToFile(cfg, runtimeName)); err != nil {
 		return err
 	}
 
@@ -227,6 +257,14 @@ func (p *plan) addFiles(cfg *config.Config, dataDir string) error {
 		return err
 	}
 
+	// external datastore certificates
+	p.Files = append(p.Files, runtime.ToDatastoreFiles(&cfg.RuntimeConfig, dataDir)...)
+
+	// containerd config.toml.tmpl
+	if err = p.addFile(runtime.ToContainerdTemplateFile(&cfg.RuntimeConfig, runtimeName)); err != nil {
+		return err
+	}
+
 	// add token file
 	if err = p.addFile(runtime.ToTokenFile(cfg.Token, dataDir)); err != nil {
 		return err
@@ -255,7 +293,15 @@ func (p *plan) addJoinFiles(cfg *config.Config, dataDir string) error {
 	runtimeName := config.GetRuntime(k8sVersions)
 
 	// config.yaml
-	if err = p.addFile(runtime.ToBootstrapFile(&cfg.RuntimeConfig, runtimeName, cfg.Server)); err != nil {
+	if err = p.addFile(runtime.ToBootstrapFile(&cfg.RuntimeConfig, runtimeName, cfg.Server, dataDir)); err != nil {
+		return err
+	}
+
+	// external datastore certificates
+	p.Files = append(p.Files, runtime.ToDatastoreFiles(&cfg.RuntimeConfig, dataDir)...)
+
+	// containerd config.toml.tmpl
+	if err = p.addFile(runtime.ToContainerdTemplateFile(&cfg.RuntimeConfig, runtimeName)); err != nil {
 		return err
 	}
 
@@ -276,7 +322,7 @@ func (p *plan) addFile(file *applyinator.File, err error) error {
 }
 
 func (p *plan) addProbesForJoin(cfg *config.Config) {
-	p.Probes = probe.ProbesForJoin(&cfg.RuntimeConfig)
+	p.Probes = probe.WithConfigProbes(probe.ProbesForJoin(&cfg.RuntimeConfig), cfg)
 }
 
 func (p *plan) addProbes(cfg *config.Config) error {
@@ -284,6 +330,6 @@ func (p *plan) addProbes(cfg *config.Config) error {
 	if err != nil {
 		return err
 	}
-	p.Probes = probe.AllProbes(config.GetRuntime(k8sVersion))
+	p.Probes = probe.WithConfigProbes(probe.AllProbes(config.GetRuntime(k8sVersion)), cfg)
 	return nil
 }
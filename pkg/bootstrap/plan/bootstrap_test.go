@@ -0,0 +1,74 @@
+package plan
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/llmos-ai/llmos/pkg/applyinator"
+	"github.com/llmos-ai/llmos/pkg/bootstrap/config"
+)
+
+func planChecksum(t *testing.T, cfg *config.Config, dataDir string) string {
+	p, err := ToPlan(context.Background(), cfg, dataDir)
+	require.NoError(t, err)
+	raw, err := json.Marshal(p)
+	require.NoError(t, err)
+	cp, err := applyinator.CalculatePlan(raw)
+	require.NoError(t, err)
+	return cp.Checksum
+}
+
+func TestToPlanIsDeterministic(t *testing.T) {
+	for _, cfg := range []*config.Config{
+		{
+			KubernetesVersion:    "v1.31.3+k3s1",
+			LLMOSOperatorVersion: "v0.1.0",
+			RuntimeConfig: config.RuntimeConfig{
+				Role:     config.ClusterInitRole,
+				Token:    "K10abc::server:secret",
+				NodeName: "node-1",
+			},
+		},
+		{
+			KubernetesVersion: "v1.31.3+k3s1",
+			RuntimeConfig: config.RuntimeConfig{
+				Role:     config.ServerRole,
+				Server:   "https://10.0.0.1:6443",
+				Token:    "K10abc::server:secret",
+				NodeName: "node-2",
+			},
+		},
+	} {
+		dataDir := t.TempDir()
+		assert.Equal(t, planChecksum(t, cfg, dataDir), planChecksum(t, cfg, dataDir),
+			"the plan of the %s role changed between generations", cfg.Role)
+	}
+}
+
+func TestToPlanWithDatastore(t *testing.T) {
+	datastore := &config.DatastoreConfig{Endpoint: "postgres://k3s@db:5432/k3s", CA: "ca"}
+	cfg := &config.Config{
+		KubernetesVersion: "v1.31.3+k3s1",
+		RuntimeConfig: config.RuntimeConfig{
+			Role:      config.ServerRole,
+			Token:     "K10abc::server:secret",
+			Datastore: datastore,
+		},
+	}
+	dataDir := t.TempDir()
+	p, err := ToPlan(context.Background(), cfg, dataDir)
+	require.NoError(t, err)
+	assert.Contains(t, p.Files, applyinator.File{
+		Content:     "Y2E=",
+		Path:        datastore.GetCAFile(dataDir),
+		Permissions: "600",
+	})
+
+	cfg.Role = config.AgentRole
+	_, err = ToPlan(context.Background(), cfg, dataDir)
+	assert.ErrorContains(t, err, "server is required")
+}
@@ -16,11 +16,30 @@ import (
 	"github.com/llmos-ai/llmos/pkg/applyinator/image"
 	"github.com/llmos-ai/llmos/pkg/bootstrap/config"
 	"github.com/llmos-ai/llmos/pkg/bootstrap/version"
+	"github.com/llmos-ai/llmos/pkg/utils/redact"
+	"github.com/llmos-ai/llmos/pkg/utils/tracing"
 )
 
 const defaultInsAttempts = 3
 
-func Run(ctx context.Context, cfg *config.Config, plan *applyinator.Plan, dataDir string) error {
+// InstructionError is returned when a one-time instruction of the plan failed, it holds the combined
+// output of the instruction
+type InstructionError struct {
+	Instruction string
+	Output      []byte
+}
+
+func (e *InstructionError) Error() string {
+	return fmt.Sprintf("kubernetes runtime plan is not applied successfully, instruction %s failed, "+
+		"please check log for more details", e.Instruction)
+}
+
+func Run(ctx context.Context, cfg *config.Config, plan *applyinator.Plan, dataDir string) (err error) {
+	ctx, span := tracing.Start(ctx, "plan.Run")
+	defer func() {
+		tracing.End(span, err)
+	}()
+
 	k8sVersion, err := version.K8sVersion(cfg.KubernetesVersion)
 	if err != nil {
 		return err
@@ -30,10 +49,14 @@ func Run(ctx context.Context, cfg *config.Config, plan *applyinator.Plan, dataDi
 
 func RunWithKubernetesVersion(ctx context.Context, cfg *config.Config, k8sVersion string,
 	plan *applyinator.Plan, dataDir string) error {
+	persisted := *plan
+	if redact.Enabled() {
+		persisted = applyinator.RedactPlan(persisted)
+	}
 	logrus.Infof("Running plan for Kubernetes version %s, plan: %v, datadir: %s",
-		k8sVersion, plan.OneTimeInstructions, dataDir)
+		k8sVersion, persisted.OneTimeInstructions, dataDir)
 
-	if err := writePlan(plan, dataDir); err != nil {
+	if err := writePlan(&persisted, dataDir); err != nil {
 		return err
 	}
 
@@ -41,11 +64,20 @@ func RunWithKubernetesVersion(ctx context.Context, cfg *config.Config, k8sVersio
 	images := image.NewUtility(cfg.ImageUtility)
 	apply := applyinator.NewApplyinator(filepath.Join(dataDir, "plan", "work"),
 		false, filepath.Join(dataDir, "plan", "applied"), "", images)
+	apply.SetLogsDir(GetLogsDir(dataDir))
+
+	// the checksum names the directory of the instruction logs
+	raw, err := json.Marshal(plan)
+	if err != nil {
+		return err
+	}
+	cp, err := applyinator.CalculatePlan(raw)
+	if err != nil {
+		return err
+	}
 
 	output, err := apply.Apply(ctx, applyinator.ApplyInput{
-		CalculatedPlan: applyinator.CalculatedPlan{
-			Plan: *plan,
-		},
+		CalculatedPlan:             cp,
 		RunOneTimeInstructions:     true,
 		ReconcileFiles:             true,
 		OneTimeInstructionAttempts: defaultInsAttempts,
@@ -54,8 +86,10 @@ func RunWithKubernetesVersion(ctx context.Context, cfg *config.Config, k8sVersio
 	if err != nil {
 		return fmt.Errorf("failed to apply plan: %w", err)
 	} else if !output.OneTimeApplySucceeded {
-		return fmt.Errorf("kubernetes runtime plan is not applied successfully, " +
-			"please check log for more details")
+		return &InstructionError{
+			Instruction: output.FailedInstruction,
+			Output:      output.FailedInstructionOutput,
+		}
 	}
 
 	return saveOutput(output.OneTimeOutput, dataDir)
@@ -78,10 +112,34 @@ func saveOutput(data []byte, dataDir string) error {
 	if err != nil {
 		return err
 	}
-	_, err = io.Copy(f, in)
+	if !redact.Enabled() {
+		_, err = io.Copy(f, in)
+		return err
+	}
+
+	output, err := io.ReadAll(in)
+	if err != nil {
+		return err
+	}
+	if output, err = redactOutput(output); err != nil {
+		return err
+	}
+	_, err = f.Write(output)
 	return err
 }
 
+// redactOutput redacts the output of each one-time instruction as text and keeps the output format
+func redactOutput(data []byte) ([]byte, error) {
+	outputs := map[string][]byte{}
+	if err := json.Unmarshal(data, &outputs); err != nil {
+		return nil, err
+	}
+	for name, output := range outputs {
+		outputs[name] = []byte(redact.Text(string(output)))
+	}
+	return json.Marshal(outputs)
+}
+
 func writePlan(plan *applyinator.Plan, dataDir string) error {
 	planFile := GetPlanFile(dataDir)
 	if err := os.MkdirAll(filepath.Dir(planFile), 0755); err != nil {
@@ -112,3 +170,8 @@ func GetPlanFile(dataDir string) string {
 func GetPlanOutput(dataDir string) string {
 	return filepath.Join(dataDir, "plan", "plan-output.json")
 }
+
+// GetLogsDir returns the directory of the instruction logs, they are kept per plan checksum
+func GetLogsDir(dataDir string) string {
+	return filepath.Join(dataDir, "plan", "logs")
+}
@@ -2,6 +2,7 @@ package plan
 
 import (
 	"os"
+	"strings"
 
 	"github.com/llmos-ai/llmos/utils/data/convert"
 	"github.com/llmos-ai/llmos/utils/randomtoken"
@@ -10,14 +11,15 @@ import (
 	"github.com/llmos-ai/llmos/pkg/bootstrap/config"
 	"github.com/llmos-ai/llmos/pkg/bootstrap/runtime"
 	"github.com/llmos-ai/llmos/pkg/bootstrap/version"
+	"github.com/llmos-ai/llmos/pkg/utils/crypt"
 )
 
-func assignTokenIfUnset(cfg *config.Config) error {
+func assignTokenIfUnset(cfg *config.Config, dataDir string) error {
 	if cfg.Token != "" {
 		return nil
 	}
 
-	token, err := existingToken(cfg)
+	token, err := existingToken(cfg, dataDir)
 	if err != nil {
 		return err
 	}
@@ -33,14 +35,23 @@ func assignTokenIfUnset(cfg *config.Config) error {
 	return nil
 }
 
-func existingToken(cfg *config.Config) (string, error) {
+// existingToken returns the token of the previous bootstrap, the encrypted token file of the data dir
+// takes precedence over the runtime config file
+func existingToken(cfg *config.Config, dataDir string) (string, error) {
+	data, err := crypt.ForDataDir(dataDir).ReadFile(runtime.GetTokenFile(dataDir))
+	if err == nil {
+		return strings.TrimSpace(string(data)), nil
+	} else if !os.IsNotExist(err) {
+		return "", err
+	}
+
 	k8sVersion, err := version.K8sVersion(cfg.KubernetesVersion)
 	if err != nil {
 		return "", err
 	}
 
 	cfgFile := runtime.GetConfigLocation(config.GetRuntime(k8sVersion))
-	data, err := os.ReadFile(cfgFile)
+	data, err = os.ReadFile(cfgFile)
 	if os.IsNotExist(err) {
 		return "", nil
 	} else if err != nil {
@@ -0,0 +1,29 @@
+package plan
+
+import (
+	"encoding/base64"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/llmos-ai/llmos/pkg/bootstrap/config"
+	"github.com/llmos-ai/llmos/pkg/bootstrap/runtime"
+)
+
+func TestExistingToken(t *testing.T) {
+	dataDir := t.TempDir()
+	file, err := runtime.ToTokenFile("K10abc::server:secret", dataDir)
+	require.NoError(t, err)
+	assert.Equal(t, runtime.GetTokenFile(dataDir), file.Path)
+
+	content, err := base64.StdEncoding.DecodeString(file.Content)
+	require.NoError(t, err)
+	assert.NotContains(t, string(content), "secret")
+	require.NoError(t, os.WriteFile(file.Path, content, 0600))
+
+	cfg := &config.Config{}
+	require.NoError(t, assignTokenIfUnset(cfg, dataDir))
+	assert.Equal(t, "K10abc::server:secret", cfg.Token)
+}
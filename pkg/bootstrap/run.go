@@ -2,17 +2,25 @@ package bootstrap
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"time"
 
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"sigs.k8s.io/yaml"
 
 	"github.com/llmos-ai/llmos/pkg/bootstrap/config"
+	"github.com/llmos-ai/llmos/pkg/bootstrap/notification"
 	"github.com/llmos-ai/llmos/pkg/bootstrap/plan"
 	"github.com/llmos-ai/llmos/pkg/bootstrap/version"
+	"github.com/llmos-ai/llmos/pkg/utils/metrics"
+	"github.com/llmos-ai/llmos/pkg/utils/redact"
+	"github.com/llmos-ai/llmos/pkg/utils/tracing"
 	cliversion "github.com/llmos-ai/llmos/pkg/version"
 )
 
@@ -26,11 +34,17 @@ type Config struct {
 	Role              string
 	KubernetesVersion string
 	Mirror            string
+	// MaxAttempts gives up the bootstrap after the failed attempts, it is retried forever if zero
+	MaxAttempts int
 }
 
 // LLMOS is the main entrypoint to the llmos systemd service
 type LLMOS struct {
 	cfg Config
+
+	// notifier sends the progress to the webhooks of the config loaded by the last attempt
+	notifier *notification.Notifier
+	node     notification.Node
 }
 
 func New(cfg Config) *LLMOS {
@@ -38,7 +52,12 @@ func New(cfg Config) *LLMOS {
 		cfg: cfg,
 	}
 }
-func (l *LLMOS) Run(ctx context.Context) error {
+func (l *LLMOS) Run(ctx context.Context) (err error) {
+	ctx, span := tracing.Start(ctx, "bootstrap")
+	defer func() {
+		tracing.End(span, err)
+	}()
+
 	if done, err := l.done(); err != nil {
 		return fmt.Errorf("checking done stamp [%s]: %w", l.DoneStamp(), err)
 	} else if done {
@@ -46,64 +65,80 @@ func (l *LLMOS) Run(ctx context.Context) error {
 			"run with the --force flag to force bootstrap the system again.")
 		return nil
 	}
+	defer l.flushNotifications(ctx)
 
-	for {
-		err := l.execute(ctx)
+	for attempt := 1; ; attempt++ {
+		start := time.Now()
+		err = l.execute(ctx, attempt)
+		metrics.ObserveBootstrap(time.Since(start), err)
 		if err == nil {
 			return nil
 		}
+		if errors.Is(err, errInvalidConfig) || (l.cfg.MaxAttempts > 0 && attempt >= l.cfg.MaxAttempts) {
+			l.notifyFailure(ctx, config.NotificationEventFailed, attempt, err)
+			return err
+		}
+		if ctx.Err() != nil {
+			l.notifyFailure(ctx, config.NotificationEventFailed, attempt, err)
+			return ctx.Err()
+		}
+
+		l.notifyFailure(ctx, config.NotificationEventAttemptFailed, attempt, err)
 		logrus.Warnf("failed to bootstrap system, will retry: %v", err)
 		select {
 		case <-ctx.Done():
+			l.notifyFailure(ctx, config.NotificationEventFailed, attempt, err)
 			return ctx.Err()
 		case <-time.After(15 * time.Second):
 		}
 	}
 }
 
-func (l *LLMOS) execute(ctx context.Context) error {
+func (l *LLMOS) execute(ctx context.Context, attempt int) (err error) {
+	ctx, span := tracing.Start(ctx, "bootstrap.execute", trace.WithAttributes(attribute.Int("llmos.attempt", attempt)))
+	defer func() {
+		tracing.End(span, err)
+	}()
+
 	cfg, err := config.Load(l.cfg.ConfigPath)
 	if err != nil {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
 	cfg = mergeConfigs(l.cfg, cfg)
+	l.setNotifier(ctx, &cfg, attempt)
 
 	if err = validateConfig(&cfg); err != nil {
 		// terminate bootstrap if config is invalid
-		logrus.Fatalf("invalid config: %v", err)
+		return fmt.Errorf("%w: %v", errInvalidConfig, err)
 	}
 
 	if err = l.setWorking(cfg); err != nil {
 		return fmt.Errorf("failed to save working config to %s: %w", l.WorkingStamp(), err)
 	}
 
-	var k8sVersion, operatorVersion string
-	if cfg.Role == config.ClusterInitRole {
-		k8sVersion, err = version.K8sVersion(cfg.KubernetesVersion)
-		if err != nil {
-			return err
-		}
-
-		operatorVersion, err = version.OperatorVersion(cfg.ChartRepo, cfg.LLMOSOperatorVersion)
-		if err != nil {
-			return err
-		}
-	} else {
-		k8sVersion, operatorVersion, err = version.GetClusterK8sAndOperatorVersions(cfg.Server, cfg.Token)
-		if err != nil {
-			return err
-		}
-		cfg.KubernetesVersion = k8sVersion
-		cfg.LLMOSOperatorVersion = operatorVersion
+	span.SetAttributes(attribute.String("llmos.role", string(cfg.Role)))
+	l.notifyPhase(ctx, attempt, NotificationPhaseResolvingVersions, "", "")
+	k8sVersion, operatorVersion, err := resolveVersions(ctx, &cfg)
+	if err != nil {
+		return err
+	}
+	if err = validateNetworkRuntime(&cfg, k8sVersion); err != nil {
+		return fmt.Errorf("%w: %v", errInvalidConfig, err)
 	}
+	span.SetAttributes(
+		attribute.String("llmos.kubernetes_version", k8sVersion),
+		attribute.String("llmos.operator_version", operatorVersion),
+	)
 
 	logrus.Infof("Bootstrapping LLMOS %s(%s)", operatorVersion, k8sVersion)
 
+	l.notifyPhase(ctx, attempt, NotificationPhaseGeneratingPlan, k8sVersion, operatorVersion)
 	nodePlan, err := plan.ToPlan(ctx, &cfg, l.cfg.DataDir)
 	if err != nil {
 		return fmt.Errorf("generating plan: %w", err)
 	}
 
+	l.notifyPhase(ctx, attempt, NotificationPhaseApplyingPlan, k8sVersion, operatorVersion)
 	if err = plan.Run(ctx, &cfg, nodePlan, l.cfg.DataDir); err != nil {
 		return fmt.Errorf("running plan error: %w", err)
 	}
@@ -113,9 +148,50 @@ func (l *LLMOS) execute(ctx context.Context) error {
 	}
 
 	logrus.Infof("Successfully Bootstrapped LLMOS %s(%s)", operatorVersion, k8sVersion)
+	l.notify(ctx, notification.Event{
+		Event:                config.NotificationEventSucceeded,
+		Attempt:              attempt,
+		KubernetesVersion:    k8sVersion,
+		LLMOSOperatorVersion: operatorVersion,
+	})
 	return nil
 }
 
+// resolveVersions returns the kubernetes and operator versions to bootstrap, a joining node gets
+// them from the cluster. A server sharing an external datastore without a server URL has no node to
+// get them from, it resolves them like the cluster-init node
+func resolveVersions(ctx context.Context, cfg *config.Config) (k8sVersion, operatorVersion string, err error) {
+	_, span := tracing.Start(ctx, "bootstrap.resolveVersions")
+	defer func() {
+		tracing.End(span, err)
+	}()
+
+	if cfg.Role != config.ClusterInitRole && (cfg.Server != "" || cfg.Datastore == nil) {
+		k8sVersion, operatorVersion, err = version.GetClusterK8sAndOperatorVersions(cfg.Server, cfg.Token)
+		if err != nil {
+			return "", "", err
+		}
+		setClusterVersions(cfg, k8sVersion, operatorVersion)
+		return k8sVersion, operatorVersion, nil
+	}
+
+	if k8sVersion, err = version.K8sVersion(cfg.KubernetesVersion); err != nil {
+		return "", "", err
+	}
+	if operatorVersion, err = version.OperatorVersion(cfg.ChartRepo, cfg.LLMOSOperatorVersion); err != nil {
+		return "", "", err
+	}
+	return k8sVersion, operatorVersion, nil
+}
+
+// setClusterVersions records the versions of the joined cluster, the registration port of the server URL
+// depends on its runtime
+func setClusterVersions(cfg *config.Config, k8sVersion, operatorVersion string) {
+	cfg.KubernetesVersion = k8sVersion
+	cfg.LLMOSOperatorVersion = operatorVersion
+	cfg.Server = cfg.NormalizeServerURL(cfg.Server)
+}
+
 func (l *LLMOS) writeConfig(path string, cfg config.Config) error {
 	if err := os.MkdirAll(filepath.Dir(path), 0600); err != nil {
 		return fmt.Errorf("mkdir %s: %w", filepath.Dir(path), err)
@@ -135,6 +211,9 @@ func (l *LLMOS) writeConfig(path string, cfg config.Config) error {
 	if err != nil {
 		return err
 	}
+	if redact.Enabled() {
+		data = redact.YAML(data)
+	}
 	_, err = f.Write(data)
 	return err
 }
@@ -147,6 +226,74 @@ func (l *LLMOS) setDone(cfg config.Config) error {
 	return l.writeConfig(l.DoneStamp(), cfg)
 }
 
+// LoadDoneConfig returns the effective config recorded by the bootstrapped stamp, its secrets are
+// redacted unless the insecure debug secrets are enabled, see RestoreSecrets
+func (l *LLMOS) LoadDoneConfig() (config.Config, error) {
+	return l.loadConfig(l.DoneStamp())
+}
+
+// LoadWorkingConfig returns the config recorded by the working stamp of the last bootstrap attempt
+func (l *LLMOS) LoadWorkingConfig() (config.Config, error) {
+	return l.loadConfig(l.WorkingStamp())
+}
+
+// SaveDoneConfig records the config to the bootstrapped stamp, e.g. after the cluster is upgraded
+func (l *LLMOS) SaveDoneConfig(cfg config.Config) error {
+	return l.setDone(cfg)
+}
+
+// RestoreSecrets restores the secrets redacted from a stamp with the values of the config files, the
+// secrets only passed by the command line flags like --token can not be restored and keep the redacted
+// placeholder. The consumers of a secret must check it with redact.IsRedacted and fail clearly instead of
+// using the placeholder
+func (l *LLMOS) RestoreSecrets(cfg config.Config) (config.Config, error) {
+	source, err := config.Load(l.cfg.ConfigPath)
+	if err != nil {
+		return cfg, fmt.Errorf("failed to load config: %w", err)
+	}
+
+	obj, err := toObject(cfg)
+	if err != nil {
+		return cfg, err
+	}
+	sourceObj, err := toObject(source)
+	if err != nil {
+		return cfg, err
+	}
+
+	data, err := yaml.Marshal(redact.Restore(obj, sourceObj))
+	if err != nil {
+		return cfg, err
+	}
+	result := config.Config{}
+	if err = yaml.Unmarshal(data, &result); err != nil {
+		return cfg, err
+	}
+	return result, nil
+}
+
+func toObject(cfg config.Config) (interface{}, error) {
+	data, err := yaml.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+	var obj interface{}
+	err = yaml.Unmarshal(data, &obj)
+	return obj, err
+}
+
+func (l *LLMOS) loadConfig(path string) (config.Config, error) {
+	cfg := config.Config{}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cfg, fmt.Errorf("reading stamp [%s]: %w", path, err)
+	}
+	if err = yaml.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("parsing stamp [%s]: %w", path, err)
+	}
+	return cfg, nil
+}
+
 func (l *LLMOS) done() (bool, error) {
 	if l.cfg.Force {
 		_ = os.Remove(l.DoneStamp())
@@ -170,10 +317,13 @@ func (l *LLMOS) WorkingStamp() string {
 }
 
 func (l *LLMOS) Info(ctx context.Context) error {
+	return l.WriteInfo(ctx, os.Stdout)
+}
+
+// WriteInfo writes the installation versions to w
+func (l *LLMOS) WriteInfo(ctx context.Context, w io.Writer) error {
 	operatorVersion, k8sVersion, osVersion := l.getExistingVersions(ctx)
-	fmt.Printf(" OS Version: 	 %s\n", osVersion)
-	fmt.Printf(" LLMOS Operator: %s\n", operatorVersion)
-	fmt.Printf(" LLMOS Cli:	 %s\n", cliversion.GetFriendlyVersion())
-	fmt.Printf(" Kubernetes:	 %s\n\n", k8sVersion)
-	return nil
+	_, err := fmt.Fprintf(w, " OS Version: 	 %s\n LLMOS Operator: %s\n LLMOS Cli:	 %s\n Kubernetes:	 %s\n\n",
+		osVersion, operatorVersion, cliversion.GetFriendlyVersion(), k8sVersion)
+	return err
 }
@@ -0,0 +1,128 @@
+package bootstrap
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/llmos-ai/llmos/pkg/bootstrap/config"
+	"github.com/llmos-ai/llmos/pkg/bootstrap/notification"
+	"github.com/llmos-ai/llmos/pkg/utils/redact"
+)
+
+func TestStampRedaction(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.yaml")
+	require.NoError(t, os.WriteFile(configPath,
+		[]byte("token: cluster-secret\netcdSnapshot:\n  s3:\n    bucket: backups\n    secretKey: s3-secret\n"), 0600))
+
+	l := New(Config{DataDir: dir, ConfigPath: configPath})
+	cfg, err := config.Load(configPath)
+	require.NoError(t, err)
+	cfg.KubernetesVersion = "v1.31.3+k3s1"
+	require.NoError(t, l.SaveDoneConfig(cfg))
+
+	data, err := os.ReadFile(l.DoneStamp())
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "cluster-secret")
+	assert.NotContains(t, string(data), "s3-secret")
+
+	stamp, err := l.LoadDoneConfig()
+	require.NoError(t, err)
+	assert.Equal(t, redact.Placeholder, stamp.Token)
+	assert.Equal(t, "v1.31.3+k3s1", stamp.KubernetesVersion)
+	assert.Equal(t, "backups", stamp.EtcdSnapshot.S3.Bucket)
+
+	restored, err := l.RestoreSecrets(stamp)
+	require.NoError(t, err)
+	assert.Equal(t, "cluster-secret", restored.Token)
+	assert.Equal(t, "s3-secret", restored.EtcdSnapshot.S3.SecretKey)
+	assert.Equal(t, "v1.31.3+k3s1", restored.KubernetesVersion)
+}
+
+func TestStampInsecureDebugSecrets(t *testing.T) {
+	redact.SetInsecureDebugSecrets(true)
+	defer redact.SetInsecureDebugSecrets(false)
+
+	l := New(Config{DataDir: t.TempDir()})
+	cfg := config.Config{}
+	cfg.Token = "cluster-secret"
+	require.NoError(t, l.SaveDoneConfig(cfg))
+
+	stamp, err := l.LoadDoneConfig()
+	require.NoError(t, err)
+	assert.Equal(t, "cluster-secret", stamp.Token)
+}
+
+func TestRunNotifiesInvalidConfig(t *testing.T) {
+	var mu sync.Mutex
+	var events []notification.Event
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		event := notification.Event{}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&event))
+		mu.Lock()
+		events = append(events, event)
+		mu.Unlock()
+	}))
+	defer ts.Close()
+
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.yaml")
+	// neither the cluster-init role nor a server is set
+	require.NoError(t, os.WriteFile(configPath, []byte("notifications:\n  webhooks:\n    - url: "+ts.URL+"\n"), 0600))
+
+	err := New(Config{DataDir: dir, ConfigPath: configPath}).Run(context.Background())
+	assert.ErrorIs(t, err, errInvalidConfig)
+
+	require.Len(t, events, 2)
+	assert.Equal(t, config.NotificationEventStarted, events[0].Event)
+	assert.Equal(t, config.NotificationEventFailed, events[1].Event)
+	assert.Equal(t, 1, events[1].Attempt)
+	assert.Contains(t, events[1].Error, "neither cluster-init role nor server URL is defined")
+	assert.NotEmpty(t, events[1].Node.CLIVersion)
+}
+
+func TestResolveVersions(t *testing.T) {
+	datastore := &config.DatastoreConfig{Endpoint: "postgres://k3s@db:5432/k3s"}
+	tests := []struct {
+		name string
+		cfg  config.RuntimeConfig
+		err  string
+	}{
+		{name: "cluster-init", cfg: config.RuntimeConfig{Role: config.ClusterInitRole}},
+		{
+			name: "server with datastore",
+			cfg:  config.RuntimeConfig{Role: config.ServerRole, Token: "secret", Datastore: datastore},
+		},
+		{
+			name: "server without server URL",
+			cfg:  config.RuntimeConfig{Role: config.ServerRole, Token: "secret"},
+			err:  "server and token must be provided",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{
+				RuntimeConfig:        tt.cfg,
+				KubernetesVersion:    "v1.31.3+k3s1",
+				LLMOSOperatorVersion: "v0.2.0",
+			}
+			k8sVersion, operatorVersion, err := resolveVersions(context.Background(), cfg)
+			if tt.err != "" {
+				assert.ErrorContains(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "v1.31.3+k3s1", k8sVersion)
+			assert.Equal(t, "v0.2.0", operatorVersion)
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package runtime
+
+import (
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/llmos-ai/llmos/utils/data/convert"
+
+	"github.com/llmos-ai/llmos/pkg/applyinator"
+	"github.com/llmos-ai/llmos/pkg/bootstrap/config"
+)
+
+const (
+	kubeletArgKey       = "kubelet-arg"
+	kubeAPIServerArgKey = "kube-apiserver-arg"
+)
+
+// ToComponentArgs translates the typed kubelet and kube-apiserver settings into the
+// kubelet-arg and kube-apiserver-arg lists, args from extraConfig are preserved.
+func ToComponentArgs(cfg *config.RuntimeConfig) map[string]interface{} {
+	result := map[string]interface{}{}
+
+	if args := kubeletArgs(cfg.Kubelet); len(args) > 0 {
+		result[kubeletArgKey] = append(convert.ToStringSlice(cfg.ConfigValues[kubeletArgKey]), args...)
+	}
+
+	// kube-apiserver only runs on the server nodes
+	if cfg.KubeAPIServer != nil && cfg.Role != config.AgentRole {
+		if args := toArgs(cfg.KubeAPIServer.ExtraArgs); len(args) > 0 {
+			result[kubeAPIServerArgKey] = append(convert.ToStringSlice(cfg.ConfigValues[kubeAPIServerArgKey]), args...)
+		}
+	}
+
+	return result
+}
+
+func kubeletArgs(kubelet *config.KubeletConfig) []string {
+	if kubelet == nil {
+		return nil
+	}
+
+	var args []string
+	if kubelet.MaxPods > 0 {
+		args = append(args, "max-pods="+strconv.Itoa(kubelet.MaxPods))
+	}
+	if len(kubelet.EvictionHard) > 0 {
+		args = append(args, "eviction-hard="+joinMap(kubelet.EvictionHard, "<"))
+	}
+	if len(kubelet.EvictionSoft) > 0 {
+		args = append(args, "eviction-soft="+joinMap(kubelet.EvictionSoft, "<"))
+	}
+	if len(kubelet.EvictionSoftGracePeriod) > 0 {
+		args = append(args, "eviction-soft-grace-period="+joinMap(kubelet.EvictionSoftGracePeriod, "="))
+	}
+	if len(kubelet.SystemReserved) > 0 {
+		args = append(args, "system-reserved="+joinMap(kubelet.SystemReserved, "="))
+	}
+	if len(kubelet.KubeReserved) > 0 {
+		args = append(args, "kube-reserved="+joinMap(kubelet.KubeReserved, "="))
+	}
+	if kubelet.ImageGCHighThresholdPercent > 0 {
+		args = append(args, "image-gc-high-threshold="+strconv.Itoa(kubelet.ImageGCHighThresholdPercent))
+	}
+	if kubelet.ImageGCLowThresholdPercent > 0 {
+		args = append(args, "image-gc-low-threshold="+strconv.Itoa(kubelet.ImageGCLowThresholdPercent))
+	}
+
+	return append(args, toArgs(kubelet.ExtraArgs)...)
+}
+
+// toArgs converts the args map to a sorted list of name=value args
+func toArgs(args map[string]string) []string {
+	result := make([]string, 0, len(args))
+	for _, k := range sortedKeys(args) {
+		result = append(result, fmt.Sprintf("%s=%s", k, args[k]))
+	}
+	return result
+}
+
+// joinMap joins the map to a comma separated list, e.g. memory.available<500Mi,nodefs.available<10%
+func joinMap(values map[string]string, sep string) string {
+	result := make([]string, 0, len(values))
+	for _, k := range sortedKeys(values) {
+		result = append(result, k+sep+values[k])
+	}
+	return strings.Join(result, ",")
+}
+
+func sortedKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// ToContainerdTemplateFile returns the containerd config template of the runtime if defined
+func ToContainerdTemplateFile(cfg *config.RuntimeConfig, runtime config.Runtime) (*applyinator.File, error) {
+	if cfg.Containerd == nil || cfg.Containerd.ConfigTemplate == "" {
+		return nil, nil
+	}
+
+	return &applyinator.File{
+		Content:     base64.StdEncoding.EncodeToString([]byte(cfg.Containerd.ConfigTemplate)),
+		Path:        GetContainerdTemplateLocation(runtime),
+		Permissions: "600",
+	}, nil
+}
+
+func GetContainerdTemplateLocation(runtime config.Runtime) string {
+	return fmt.Sprintf("/var/lib/rancher/%s/agent/etc/containerd/config.toml.tmpl", runtime)
+}
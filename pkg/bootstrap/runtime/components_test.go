@@ -0,0 +1,110 @@
+package runtime
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/llmos-ai/llmos/pkg/bootstrap/config"
+)
+
+func TestToComponentArgs(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      config.RuntimeConfig
+		expected map[string]interface{}
+	}{
+		{
+			name:     "unset",
+			expected: map[string]interface{}{},
+		},
+		{
+			name: "kubelet",
+			cfg: config.RuntimeConfig{
+				Kubelet: &config.KubeletConfig{
+					MaxPods:                     200,
+					EvictionHard:                map[string]string{"nodefs.available": "10%", "memory.available": "500Mi"},
+					EvictionSoft:                map[string]string{"memory.available": "1Gi"},
+					EvictionSoftGracePeriod:     map[string]string{"memory.available": "1m30s"},
+					SystemReserved:              map[string]string{"memory": "1Gi", "cpu": "500m"},
+					KubeReserved:                map[string]string{"cpu": "250m"},
+					ImageGCHighThresholdPercent: 85,
+					ImageGCLowThresholdPercent:  80,
+					ExtraArgs:                   map[string]string{"v": "2", "cpu-manager-policy": "static"},
+				},
+			},
+			expected: map[string]interface{}{
+				"kubelet-arg": []string{
+					"max-pods=200",
+					"eviction-hard=memory.available<500Mi,nodefs.available<10%",
+					"eviction-soft=memory.available<1Gi",
+					"eviction-soft-grace-period=memory.available=1m30s",
+					"system-reserved=cpu=500m,memory=1Gi",
+					"kube-reserved=cpu=250m",
+					"image-gc-high-threshold=85",
+					"image-gc-low-threshold=80",
+					"cpu-manager-policy=static",
+					"v=2",
+				},
+			},
+		},
+		{
+			name: "extraConfig args are kept first",
+			cfg: config.RuntimeConfig{
+				Role:          config.ServerRole,
+				Kubelet:       &config.KubeletConfig{MaxPods: 110},
+				KubeAPIServer: &config.KubeAPIServerConfig{ExtraArgs: map[string]string{"max-requests-inflight": "800"}},
+				ConfigValues: map[string]interface{}{
+					"kubelet-arg":        []interface{}{"cpu-manager-policy=static"},
+					"kube-apiserver-arg": "audit-log-maxage=30",
+				},
+			},
+			expected: map[string]interface{}{
+				"kubelet-arg":        []string{"cpu-manager-policy=static", "max-pods=110"},
+				"kube-apiserver-arg": []string{"audit-log-maxage=30", "max-requests-inflight=800"},
+			},
+		},
+		{
+			name: "kube-apiserver on agent",
+			cfg: config.RuntimeConfig{
+				Role:          config.AgentRole,
+				KubeAPIServer: &config.KubeAPIServerConfig{ExtraArgs: map[string]string{"max-requests-inflight": "800"}},
+			},
+			expected: map[string]interface{}{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, ToComponentArgs(&tt.cfg))
+		})
+	}
+}
+
+func TestToContainerdTemplateFile(t *testing.T) {
+	file, err := ToContainerdTemplateFile(&config.RuntimeConfig{}, config.RuntimeK3S)
+	require.NoError(t, err)
+	assert.Nil(t, file)
+
+	file, err = ToContainerdTemplateFile(&config.RuntimeConfig{Containerd: &config.ContainerdConfig{}}, config.RuntimeK3S)
+	require.NoError(t, err)
+	assert.Nil(t, file)
+
+	template := "{{ template \"base\" . }}\n"
+	for runtime, path := range map[config.Runtime]string{
+		config.RuntimeK3S:  "/var/lib/rancher/k3s/agent/etc/containerd/config.toml.tmpl",
+		config.RuntimeRKE2: "/var/lib/rancher/rke2/agent/etc/containerd/config.toml.tmpl",
+	} {
+		file, err = ToContainerdTemplateFile(&config.RuntimeConfig{
+			Containerd: &config.ContainerdConfig{ConfigTemplate: template},
+		}, runtime)
+		require.NoError(t, err)
+		require.NotNil(t, file)
+		assert.Equal(t, path, file.Path)
+		assert.Equal(t, "600", file.Permissions)
+		content, err := base64.StdEncoding.DecodeString(file.Content)
+		require.NoError(t, err)
+		assert.Equal(t, template, string(content))
+	}
+}
@@ -3,6 +3,7 @@ package runtime
 import (
 	"encoding/base64"
 	"fmt"
+	"path/filepath"
 	"strings"
 
 	"github.com/llmos-ai/llmos/utils/data/convert"
@@ -10,6 +11,7 @@ import (
 
 	"github.com/llmos-ai/llmos/pkg/applyinator"
 	"github.com/llmos-ai/llmos/pkg/bootstrap/config"
+	"github.com/llmos-ai/llmos/pkg/utils/crypt"
 )
 
 var (
@@ -24,17 +26,30 @@ var (
 	}
 )
 
+// ToTokenFile returns the token file of the data dir, the token is encrypted by the node key. The
+// encryption is deterministic so that the plan checksum does not change between generations, the node
+// key is generated with the first plan since the following plans must encrypt the token with it
 func ToTokenFile(token, dataDir string) (*applyinator.File, error) {
-	tokenByte := []byte(fmt.Sprintf("%s\n", token))
+	encrypted, err := crypt.ForDataDir(dataDir).EncryptDeterministic([]byte(fmt.Sprintf("%s\n", token)))
+	if err != nil {
+		return nil, fmt.Errorf("encrypting token: %w", err)
+	}
+	tokenByte := append(encrypted, '\n')
 	return &applyinator.File{
 		Content:     base64.StdEncoding.EncodeToString(tokenByte),
-		Path:        fmt.Sprintf("%s/token", dataDir),
+		Path:        GetTokenFile(dataDir),
 		Permissions: "600",
 	}, nil
 }
 
-func ToBootstrapFile(config *config.RuntimeConfig, runtime config.Runtime, server string) (*applyinator.File, error) {
-	data, err := ToConfig(config, server)
+// GetTokenFile returns the path of the token file in the data dir
+func GetTokenFile(dataDir string) string {
+	return filepath.Join(dataDir, "token")
+}
+
+func ToBootstrapFile(config *config.RuntimeConfig, runtime config.Runtime,
+	server, dataDir string) (*applyinator.File, error) {
+	data, err := ToConfig(config, runtime, server, dataDir)
 	if err != nil {
 		return nil, err
 	}
@@ -44,7 +59,7 @@ func ToBootstrapFile(config *config.RuntimeConfig, runtime config.Runtime, serve
 	}, nil
 }
 
-func ToConfig(cfg *config.RuntimeConfig, server string) ([]byte, error) {
+func ToConfig(cfg *config.RuntimeConfig, runtime config.Runtime, server, dataDir string) ([]byte, error) {
 	configObjects := []interface{}{
 		cfg.ConfigValues,
 	}
@@ -61,6 +76,12 @@ func ToConfig(cfg *config.RuntimeConfig, server string) ([]byte, error) {
 		delete(mapData, "extraConfig")
 		delete(mapData, "role")
 		delete(mapData, "mirror")
+		delete(mapData, "network")
+		delete(mapData, "kubelet")
+		delete(mapData, "kubeApiserver")
+		delete(mapData, "containerd")
+		delete(mapData, "datastore")
+		delete(mapData, "etcdSnapshot")
 		if cfg.Role == config.AgentRole {
 			delete(mapData, "systemDefaultRegistry")
 		}
@@ -77,11 +98,32 @@ func ToConfig(cfg *config.RuntimeConfig, server string) ([]byte, error) {
 			result[newKey] = v
 		}
 
-		if len(server) == 0 {
+		// the external datastore replaces the embedded etcd cluster-init
+		if len(server) == 0 && cfg.Datastore == nil {
 			result["cluster-init"] = "true"
 		}
 	}
 
+	networkConfig, err := ToNetworkConfig(cfg, runtime)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range networkConfig {
+		result[k] = v
+	}
+
+	for k, v := range ToComponentArgs(cfg) {
+		result[k] = v
+	}
+
+	for k, v := range ToDatastoreConfig(cfg, dataDir) {
+		result[k] = v
+	}
+
+	for k, v := range ToEtcdSnapshotConfig(cfg) {
+		result[k] = v
+	}
+
 	return yaml.Marshal(result)
 }
 
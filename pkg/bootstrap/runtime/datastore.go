@@ -0,0 +1,56 @@
+package runtime
+
+import (
+	"encoding/base64"
+
+	"github.com/llmos-ai/llmos/pkg/applyinator"
+	"github.com/llmos-ai/llmos/pkg/bootstrap/config"
+)
+
+// ToDatastoreConfig returns the runtime datastore keys for the server nodes
+func ToDatastoreConfig(cfg *config.RuntimeConfig, dataDir string) map[string]interface{} {
+	result := map[string]interface{}{}
+	datastore := cfg.Datastore
+	if datastore == nil || cfg.Role == config.AgentRole {
+		return result
+	}
+
+	result["datastore-endpoint"] = datastore.Endpoint
+	for key, path := range map[string]string{
+		"datastore-cafile":   datastore.GetCAFile(dataDir),
+		"datastore-certfile": datastore.GetCertFile(dataDir),
+		"datastore-keyfile":  datastore.GetKeyFile(dataDir),
+	} {
+		if path != "" {
+			result[key] = path
+		}
+	}
+	return result
+}
+
+// ToDatastoreFiles returns the inline datastore certificates as plan files
+func ToDatastoreFiles(cfg *config.RuntimeConfig, dataDir string) []applyinator.File {
+	datastore := cfg.Datastore
+	if datastore == nil || cfg.Role == config.AgentRole {
+		return nil
+	}
+
+	var files []applyinator.File
+	for _, v := range []struct {
+		pem, path string
+	}{
+		{datastore.CA, datastore.GetCAFile(dataDir)},
+		{datastore.Cert, datastore.GetCertFile(dataDir)},
+		{datastore.Key, datastore.GetKeyFile(dataDir)},
+	} {
+		if v.pem == "" {
+			continue
+		}
+		files = append(files, applyinator.File{
+			Content:     base64.StdEncoding.EncodeToString([]byte(v.pem)),
+			Path:        v.path,
+			Permissions: "600",
+		})
+	}
+	return files
+}
@@ -0,0 +1,185 @@
+package runtime
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/yaml"
+
+	"github.com/llmos-ai/llmos/pkg/bootstrap/config"
+)
+
+const testDataDir = "/var/lib/llmos"
+
+func TestToDatastoreConfig(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      config.RuntimeConfig
+		expected map[string]interface{}
+	}{
+		{
+			name:     "embedded etcd",
+			cfg:      config.RuntimeConfig{Role: config.ClusterInitRole},
+			expected: map[string]interface{}{},
+		},
+		{
+			name: "endpoint only",
+			cfg: config.RuntimeConfig{
+				Role:      config.ClusterInitRole,
+				Datastore: &config.DatastoreConfig{Endpoint: "postgres://k3s@db:5432/k3s"},
+			},
+			expected: map[string]interface{}{"datastore-endpoint": "postgres://k3s@db:5432/k3s"},
+		},
+		{
+			name: "files",
+			cfg: config.RuntimeConfig{
+				Role: config.ServerRole,
+				Datastore: &config.DatastoreConfig{Endpoint: "https://etcd:2379", CAFile: "/etc/etcd/ca.crt",
+					CertFile: "/etc/etcd/client.crt", KeyFile: "/etc/etcd/client.key"},
+			},
+			expected: map[string]interface{}{
+				"datastore-endpoint": "https://etcd:2379",
+				"datastore-cafile":   "/etc/etcd/ca.crt",
+				"datastore-certfile": "/etc/etcd/client.crt",
+				"datastore-keyfile":  "/etc/etcd/client.key",
+			},
+		},
+		{
+			name: "inline",
+			cfg: config.RuntimeConfig{
+				Role:      config.ClusterInitRole,
+				Datastore: &config.DatastoreConfig{Endpoint: "https://etcd:2379", CA: "ca", Cert: "cert", Key: "key"},
+			},
+			expected: map[string]interface{}{
+				"datastore-endpoint": "https://etcd:2379",
+				"datastore-cafile":   "/var/lib/llmos/datastore/ca.crt",
+				"datastore-certfile": "/var/lib/llmos/datastore/client.crt",
+				"datastore-keyfile":  "/var/lib/llmos/datastore/client.key",
+			},
+		},
+		{
+			name: "agent",
+			cfg: config.RuntimeConfig{
+				Role:      config.AgentRole,
+				Datastore: &config.DatastoreConfig{Endpoint: "postgres://k3s@db:5432/k3s"},
+			},
+			expected: map[string]interface{}{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, ToDatastoreConfig(&tt.cfg, testDataDir))
+		})
+	}
+}
+
+func TestToDatastoreFiles(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      config.RuntimeConfig
+		expected map[string]string
+	}{
+		{
+			name: "embedded etcd",
+			cfg:  config.RuntimeConfig{Role: config.ClusterInitRole},
+		},
+		{
+			name: "files",
+			cfg: config.RuntimeConfig{
+				Role: config.ClusterInitRole,
+				Datastore: &config.DatastoreConfig{Endpoint: "https://etcd:2379", CAFile: "/etc/etcd/ca.crt",
+					CertFile: "/etc/etcd/client.crt", KeyFile: "/etc/etcd/client.key"},
+			},
+		},
+		{
+			name: "inline ca with cert files",
+			cfg: config.RuntimeConfig{
+				Role: config.ServerRole,
+				Datastore: &config.DatastoreConfig{Endpoint: "https://etcd:2379", CA: "ca",
+					CertFile: "/etc/etcd/client.crt", KeyFile: "/etc/etcd/client.key"},
+			},
+			expected: map[string]string{"/var/lib/llmos/datastore/ca.crt": "ca"},
+		},
+		{
+			name: "inline",
+			cfg: config.RuntimeConfig{
+				Role:      config.ClusterInitRole,
+				Datastore: &config.DatastoreConfig{Endpoint: "https://etcd:2379", CA: "ca", Cert: "cert", Key: "key"},
+			},
+			expected: map[string]string{
+				"/var/lib/llmos/datastore/ca.crt":     "ca",
+				"/var/lib/llmos/datastore/client.crt": "cert",
+				"/var/lib/llmos/datastore/client.key": "key",
+			},
+		},
+		{
+			name: "agent",
+			cfg: config.RuntimeConfig{
+				Role:      config.AgentRole,
+				Datastore: &config.DatastoreConfig{Endpoint: "https://etcd:2379", CA: "ca"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files := ToDatastoreFiles(&tt.cfg, testDataDir)
+			require.Len(t, files, len(tt.expected))
+			for _, file := range files {
+				content, err := base64.StdEncoding.DecodeString(file.Content)
+				require.NoError(t, err)
+				assert.Equal(t, tt.expected[file.Path], string(content), file.Path)
+				assert.Equal(t, "600", file.Permissions, file.Path)
+			}
+		})
+	}
+}
+
+func TestToConfigWithDatastore(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         config.RuntimeConfig
+		clusterInit bool
+	}{
+		{
+			name:        "embedded etcd",
+			cfg:         config.RuntimeConfig{Role: config.ClusterInitRole},
+			clusterInit: true,
+		},
+		{
+			name: "cluster-init with datastore",
+			cfg: config.RuntimeConfig{
+				Role:      config.ClusterInitRole,
+				Datastore: &config.DatastoreConfig{Endpoint: "postgres://k3s@db:5432/k3s"},
+			},
+		},
+		{
+			name: "server with datastore",
+			cfg: config.RuntimeConfig{
+				Role:      config.ServerRole,
+				Datastore: &config.DatastoreConfig{Endpoint: "postgres://k3s@db:5432/k3s"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.cfg.SetDefaults()
+			data, err := ToConfig(&tt.cfg, config.RuntimeK3S, "", testDataDir)
+			require.NoError(t, err)
+			result := map[string]interface{}{}
+			require.NoError(t, yaml.Unmarshal(data, &result))
+
+			if tt.clusterInit {
+				assert.Equal(t, "true", result["cluster-init"])
+				assert.Equal(t, true, result[config.EtcdExposeMetrics])
+				assert.NotContains(t, result, "datastore-endpoint")
+			} else {
+				assert.NotContains(t, result, "cluster-init")
+				assert.NotContains(t, result, config.EtcdExposeMetrics)
+				assert.NotContains(t, result, "datastore")
+				assert.Equal(t, tt.cfg.Datastore.Endpoint, result["datastore-endpoint"])
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package runtime
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/llmos-ai/llmos/pkg/bootstrap/config"
+)
+
+// Detect returns the k8s runtime of the kubernetes version, or the installed runtime
+// found on the node if the version is a channel
+func Detect(kubernetesVersion string) config.Runtime {
+	if runtime := config.GetRuntime(kubernetesVersion); runtime != config.RuntimeUnknown {
+		return runtime
+	}
+
+	for _, runtime := range []config.Runtime{config.RuntimeRKE2, config.RuntimeK3S} {
+		if _, err := os.Stat(fmt.Sprintf("/etc/rancher/%s", runtime)); err == nil {
+			return runtime
+		}
+	}
+	return config.RuntimeUnknown
+}
+
+// GetBinary returns the location of the runtime binary
+func GetBinary(runtime config.Runtime) string {
+	if path, err := exec.LookPath(string(runtime)); err == nil {
+		return path
+	}
+	// rke2 is installed to /opt/rke2 on systems with a read-only /usr
+	for _, path := range []string{
+		fmt.Sprintf("/usr/local/bin/%s", runtime),
+		fmt.Sprintf("/opt/%s/bin/%s", runtime, runtime),
+	} {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return fmt.Sprintf("/usr/local/bin/%s", runtime)
+}
+
+// GetServiceName returns the systemd service name of the runtime for the node role
+func GetServiceName(runtime config.Runtime, role config.Role) string {
+	agent := role == config.AgentRole
+	switch {
+	case runtime == config.RuntimeRKE2 && agent:
+		return "rke2-agent"
+	case runtime == config.RuntimeRKE2:
+		return "rke2-server"
+	case agent:
+		return fmt.Sprintf("%s-agent", runtime)
+	default:
+		return string(runtime)
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/llmos-ai/llmos/pkg/bootstrap/config"
 	"github.com/llmos-ai/llmos/pkg/bootstrap/images"
 	"github.com/llmos-ai/llmos/pkg/utils"
+	"github.com/llmos-ai/llmos/pkg/utils/redact"
 )
 
 const (
@@ -22,7 +23,7 @@ const (
 func ToInstruction(cfg *config.Config, k8sVersion string) (*applyinator.OneTimeInstruction, error) {
 	runtime := config.GetRuntime(k8sVersion)
 	env := addRuntimeEnvConfig(runtime, cfg, k8sVersion)
-	logrus.Debugf("added runtime %s instruction envs: %+v", runtime, env)
+	logrus.Debugf("added runtime %s instruction envs: %+v", runtime, redact.LogEnv(env))
 
 	return &applyinator.OneTimeInstruction{
 		CommonInstruction: applyinator.CommonInstruction{
@@ -72,7 +73,7 @@ func CopyKubeConfigInstruction(k8sVersion string) (*applyinator.OneTimeInstructi
 		CommonInstruction: applyinator.CommonInstruction{
 			Name: fmt.Sprintf("symlink-kubeconfig-%s", runtime),
 			Args: []string{"retry", "ln", "-sf", GetKubeconfigPath(runtime),
-				filepath.Join(llmosConfigPath, llmosKubeconfigFile)},
+				GetLLMOSKubeconfigPath()},
 			Command: cmd,
 		},
 		SaveOutput: true,
@@ -82,3 +83,8 @@ func CopyKubeConfigInstruction(k8sVersion string) (*applyinator.OneTimeInstructi
 func GetKubeconfigPath(runtime config.Runtime) string {
 	return fmt.Sprintf("/etc/rancher/%s/%s.yaml", runtime, runtime)
 }
+
+// GetLLMOSKubeconfigPath returns the kubeconfig symlink created by the bootstrap
+func GetLLMOSKubeconfigPath() string {
+	return filepath.Join(llmosConfigPath, llmosKubeconfigFile)
+}
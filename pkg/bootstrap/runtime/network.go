@@ -0,0 +1,62 @@
+package runtime
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/llmos-ai/llmos/pkg/bootstrap/config"
+)
+
+// ToNetworkConfig translates the typed network settings into the runtime config keys,
+// k3s configures the CNI via flannel-backend while rke2 uses the cni key.
+func ToNetworkConfig(cfg *config.RuntimeConfig, runtime config.Runtime) (map[string]interface{}, error) {
+	result := map[string]interface{}{}
+	network := cfg.Network
+	// network settings are cluster wide and only accepted by the server nodes
+	if network == nil || cfg.Role == config.AgentRole {
+		return result, nil
+	}
+
+	switch runtime {
+	case config.RuntimeK3S:
+		switch network.CNI {
+		case "", config.CNIFlannel:
+			if network.FlannelBackend != "" {
+				result["flannel-backend"] = network.FlannelBackend
+			}
+		case config.CNINone, config.CNICalico, config.CNICilium:
+			// k3s only bundles flannel, other CNIs are expected to be installed via manifests
+			result["flannel-backend"] = config.FlannelBackendNone
+			result["disable-network-policy"] = true
+		default:
+			return nil, fmt.Errorf("cni %s is not supported by %s", network.CNI, runtime)
+		}
+	case config.RuntimeRKE2:
+		if network.FlannelBackend != "" {
+			return nil, fmt.Errorf("flannelBackend is not supported by %s", runtime)
+		}
+		if network.CNI != "" {
+			result["cni"] = string(network.CNI)
+		}
+	default:
+		return nil, fmt.Errorf("unknown runtime %s", runtime)
+	}
+
+	if len(network.ClusterCIDR) > 0 {
+		result["cluster-cidr"] = strings.Join(network.ClusterCIDR, ",")
+	}
+	if len(network.ServiceCIDR) > 0 {
+		result["service-cidr"] = strings.Join(network.ServiceCIDR, ",")
+	}
+	if len(network.ClusterDNS) > 0 {
+		result["cluster-dns"] = strings.Join(network.ClusterDNS, ",")
+	}
+	if network.ClusterDomain != "" {
+		result["cluster-domain"] = network.ClusterDomain
+	}
+	if network.NodePortRange != "" {
+		result["service-node-port-range"] = network.NodePortRange
+	}
+
+	return result, nil
+}
@@ -0,0 +1,98 @@
+package runtime
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/llmos-ai/llmos/pkg/bootstrap/config"
+)
+
+func TestToNetworkConfig(t *testing.T) {
+	dualStack := &config.NetworkConfig{
+		ClusterCIDR:   []string{"10.42.0.0/16", "2001:cafe:42::/56"},
+		ServiceCIDR:   []string{"10.43.0.0/16", "2001:cafe:43::/112"},
+		ClusterDNS:    []string{"10.43.0.10", "2001:cafe:43::a"},
+		ClusterDomain: "llmos.local",
+		DualStack:     true,
+		NodePortRange: "30000-32767",
+	}
+	tests := []struct {
+		name     string
+		cfg      config.RuntimeConfig
+		runtime  config.Runtime
+		expected map[string]interface{}
+		err      string
+	}{
+		{
+			name:     "unset",
+			cfg:      config.RuntimeConfig{Role: config.ClusterInitRole},
+			runtime:  config.RuntimeK3S,
+			expected: map[string]interface{}{},
+		},
+		{
+			name: "k3s flannel backend",
+			cfg: config.RuntimeConfig{Role: config.ClusterInitRole,
+				Network: &config.NetworkConfig{CNI: config.CNIFlannel, FlannelBackend: config.FlannelBackendWireguard}},
+			runtime:  config.RuntimeK3S,
+			expected: map[string]interface{}{"flannel-backend": "wireguard-native"},
+		},
+		{
+			name:    "k3s external cni",
+			cfg:     config.RuntimeConfig{Role: config.ServerRole, Network: &config.NetworkConfig{CNI: config.CNICilium}},
+			runtime: config.RuntimeK3S,
+			expected: map[string]interface{}{
+				"flannel-backend":        "none",
+				"disable-network-policy": true,
+			},
+		},
+		{
+			name:    "k3s canal",
+			cfg:     config.RuntimeConfig{Role: config.ClusterInitRole, Network: &config.NetworkConfig{CNI: config.CNICanal}},
+			runtime: config.RuntimeK3S,
+			err:     "cni canal is not supported by k3s",
+		},
+		{
+			name:     "rke2 cni",
+			cfg:      config.RuntimeConfig{Role: config.ClusterInitRole, Network: &config.NetworkConfig{CNI: config.CNICanal}},
+			runtime:  config.RuntimeRKE2,
+			expected: map[string]interface{}{"cni": "canal"},
+		},
+		{
+			name: "rke2 flannel backend",
+			cfg: config.RuntimeConfig{Role: config.ClusterInitRole,
+				Network: &config.NetworkConfig{FlannelBackend: config.FlannelBackendHostGW}},
+			runtime: config.RuntimeRKE2,
+			err:     "flannelBackend is not supported by rke2",
+		},
+		{
+			name:    "dual-stack",
+			cfg:     config.RuntimeConfig{Role: config.ClusterInitRole, Network: dualStack},
+			runtime: config.RuntimeRKE2,
+			expected: map[string]interface{}{
+				"cluster-cidr":            "10.42.0.0/16,2001:cafe:42::/56",
+				"service-cidr":            "10.43.0.0/16,2001:cafe:43::/112",
+				"cluster-dns":             "10.43.0.10,2001:cafe:43::a",
+				"cluster-domain":          "llmos.local",
+				"service-node-port-range": "30000-32767",
+			},
+		},
+		{
+			name:     "agent",
+			cfg:      config.RuntimeConfig{Role: config.AgentRole, Network: dualStack},
+			runtime:  config.RuntimeK3S,
+			expected: map[string]interface{}{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ToNetworkConfig(&tt.cfg, tt.runtime)
+			if tt.err != "" {
+				assert.ErrorContains(t, err, tt.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package runtime
+
+import (
+	"github.com/llmos-ai/llmos/pkg/bootstrap/config"
+)
+
+// ToEtcdSnapshotConfig translates the etcd snapshot settings into the runtime etcd-snapshot and etcd-s3 keys,
+// which are shared by k3s and rke2
+func ToEtcdSnapshotConfig(cfg *config.RuntimeConfig) map[string]interface{} {
+	result := map[string]interface{}{}
+	snapshot := cfg.EtcdSnapshot
+	// snapshots only apply to the server nodes running the embedded etcd
+	if snapshot == nil || cfg.Role == config.AgentRole || cfg.Datastore != nil {
+		return result
+	}
+
+	if snapshot.Disable {
+		result["etcd-disable-snapshots"] = true
+	}
+	if snapshot.ScheduleCron != "" {
+		result["etcd-snapshot-schedule-cron"] = snapshot.ScheduleCron
+	}
+	if snapshot.Retention > 0 {
+		result["etcd-snapshot-retention"] = snapshot.Retention
+	}
+	if snapshot.Dir != "" {
+		result["etcd-snapshot-dir"] = snapshot.Dir
+	}
+	if snapshot.Compress {
+		result["etcd-snapshot-compress"] = true
+	}
+
+	s3 := snapshot.S3
+	if s3 == nil {
+		return result
+	}
+
+	result["etcd-s3"] = true
+	for key, value := range map[string]string{
+		"etcd-s3-endpoint":    s3.Endpoint,
+		"etcd-s3-endpoint-ca": s3.EndpointCA,
+		"etcd-s3-access-key":  s3.AccessKey,
+		"etcd-s3-secret-key":  s3.SecretKey,
+		"etcd-s3-bucket":      s3.Bucket,
+		"etcd-s3-region":      s3.Region,
+		"etcd-s3-folder":      s3.Folder,
+	} {
+		if value != "" {
+			result[key] = value
+		}
+	}
+	if s3.SkipSSLVerify {
+		result["etcd-s3-skip-ssl-verify"] = true
+	}
+	if s3.Insecure {
+		result["etcd-s3-insecure"] = true
+	}
+
+	return result
+}
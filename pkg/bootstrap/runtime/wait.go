@@ -46,3 +46,19 @@ func ToWaitSystemAgentActiveInstruction(k8sVersion string) (*applyinator.OneTime
 		SaveOutput: true,
 	}, nil
 }
+
+func ToWaitRegistrationAddressInstruction(registrationURL string) (*applyinator.OneTimeInstruction, error) {
+	cmd, err := cmd.Self()
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve location of %s: %w", os.Args[0], err)
+	}
+
+	return &applyinator.OneTimeInstruction{
+		CommonInstruction: applyinator.CommonInstruction{
+			Name:    "wait-registration-address",
+			Args:    []string{"retry", cmd, "ping", registrationURL},
+			Command: cmd,
+		},
+		SaveOutput: true,
+	}, nil
+}
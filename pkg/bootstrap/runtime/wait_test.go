@@ -0,0 +1,21 @@
+package runtime
+
+import (
+	"testing"
+
+	"github.com/llmos-ai/llmos/utils/cmd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToWaitRegistrationAddressInstruction(t *testing.T) {
+	self, err := cmd.Self()
+	require.NoError(t, err)
+
+	instruction, err := ToWaitRegistrationAddressInstruction("https://192.168.1.100:9345")
+	require.NoError(t, err)
+	assert.Equal(t, "wait-registration-address", instruction.Name)
+	assert.Equal(t, self, instruction.Command)
+	assert.Equal(t, []string{"retry", self, "ping", "https://192.168.1.100:9345"}, instruction.Args)
+	assert.True(t, instruction.SaveOutput)
+}
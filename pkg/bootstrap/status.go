@@ -0,0 +1,53 @@
+package bootstrap
+
+import (
+	"errors"
+	"io/fs"
+
+	cliversion "github.com/llmos-ai/llmos/pkg/version"
+)
+
+// The bootstrap phases of the node
+const (
+	PhasePending       = "pending"
+	PhaseBootstrapping = "bootstrapping"
+	PhaseBootstrapped  = "bootstrapped"
+)
+
+// Status is the bootstrap phase of the node and the versions recorded by its stamps
+type Status struct {
+	Phase                string `json:"phase"`
+	Role                 string `json:"role,omitempty"`
+	KubernetesVersion    string `json:"kubernetesVersion,omitempty"`
+	LLMOSOperatorVersion string `json:"llmosOperatorVersion,omitempty"`
+	CLIVersion           string `json:"cliVersion"`
+	OSVersion            string `json:"osVersion,omitempty"`
+}
+
+// Status returns the bootstrap status from the stamps, the node is bootstrapping while only the
+// working stamp of a bootstrap attempt exists
+func (l *LLMOS) Status() (Status, error) {
+	status := Status{
+		Phase:      PhaseBootstrapped,
+		CLIVersion: cliversion.GetFriendlyVersion(),
+		OSVersion:  getOSVersion(),
+	}
+
+	cfg, err := l.LoadDoneConfig()
+	if errors.Is(err, fs.ErrNotExist) {
+		status.Phase = PhaseBootstrapping
+		cfg, err = l.LoadWorkingConfig()
+		if errors.Is(err, fs.ErrNotExist) {
+			status.Phase = PhasePending
+			return status, nil
+		}
+	}
+	if err != nil {
+		return status, err
+	}
+
+	status.Role = string(cfg.Role)
+	status.KubernetesVersion = cfg.KubernetesVersion
+	status.LLMOSOperatorVersion = cfg.LLMOSOperatorVersion
+	return status, nil
+}
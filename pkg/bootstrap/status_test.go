@@ -0,0 +1,34 @@
+package bootstrap
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/llmos-ai/llmos/pkg/bootstrap/config"
+)
+
+func TestStatus(t *testing.T) {
+	l := New(Config{DataDir: t.TempDir()})
+
+	status, err := l.Status()
+	require.NoError(t, err)
+	assert.Equal(t, PhasePending, status.Phase)
+	assert.NotEmpty(t, status.CLIVersion)
+
+	cfg := config.Config{KubernetesVersion: "v1.31.3+k3s1", LLMOSOperatorVersion: "0.2.0"}
+	cfg.Role = config.ServerRole
+	require.NoError(t, l.setWorking(cfg))
+	status, err = l.Status()
+	require.NoError(t, err)
+	assert.Equal(t, PhaseBootstrapping, status.Phase)
+	assert.Equal(t, "server", status.Role)
+
+	require.NoError(t, l.setDone(cfg))
+	status, err = l.Status()
+	require.NoError(t, err)
+	assert.Equal(t, PhaseBootstrapped, status.Phase)
+	assert.Equal(t, "v1.31.3+k3s1", status.KubernetesVersion)
+	assert.Equal(t, "0.2.0", status.LLMOSOperatorVersion)
+}
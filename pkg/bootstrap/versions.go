@@ -49,10 +49,11 @@ func (l *LLMOS) getExistingVersions(ctx context.Context) (operatorVersion, k8sVe
 		return "", "", osVersion
 	}
 
-	return getOperatorVersion(ctx, k8s), getK8sVersion(ctx, k8s), osVersion
+	return GetOperatorVersion(ctx, k8s), getK8sVersion(ctx, k8s), osVersion
 }
 
-func getOperatorVersion(ctx context.Context, k8s kubernetes.Interface) string {
+// GetOperatorVersion returns the chart version of the deployed llmos-operator helm release
+func GetOperatorVersion(ctx context.Context, k8s kubernetes.Interface) string {
 	secrets, err := k8s.CoreV1().Secrets("llmos-system").List(ctx, metav1.ListOptions{
 		LabelSelector: "name=llmos-operator,status=deployed",
 	})
@@ -0,0 +1,200 @@
+package vip
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/llmos-ai/llmos/pkg/applyinator"
+	"github.com/llmos-ai/llmos/pkg/bootstrap/config"
+	"github.com/llmos-ai/llmos/pkg/bootstrap/manifest"
+)
+
+const (
+	kubeVIPName      = "kube-vip"
+	kubeVIPNamespace = "kube-system"
+	apiServerPort    = "6443"
+)
+
+// ToFile returns the kube-vip manifests placed into the runtime auto-deploying manifests directory,
+// the runtime applies them on startup so the VIP is announced before the plan waits for it.
+func ToFile(cfg *config.Config, runtime config.Runtime) (*applyinator.File, error) {
+	if cfg.VIP == nil || cfg.VIP.Address == "" {
+		return nil, nil
+	}
+	return manifest.ToFile(Resources(cfg.VIP), GetManifestLocation(runtime))
+}
+
+func GetManifestLocation(runtime config.Runtime) string {
+	return fmt.Sprintf("/var/lib/rancher/%s/server/manifests/llmos-kube-vip.yaml", runtime)
+}
+
+// Resources returns the kube-vip RBAC and the daemonset running on the control-plane nodes
+func Resources(vip *config.VIPConfig) []config.GenericMap {
+	vipCIDR := "32"
+	if ip := net.ParseIP(vip.Address); ip != nil && ip.To4() == nil {
+		vipCIDR = "128"
+	}
+
+	env := []interface{}{
+		envVar("vip_arp", "true"),
+		envVar("port", apiServerPort),
+		envVar("vip_cidr", vipCIDR),
+		envVar("cp_enable", "true"),
+		envVar("cp_namespace", kubeVIPNamespace),
+		envVar("svc_enable", "false"),
+		envVar("vip_leaderelection", "true"),
+		envVar("vip_leasename", "plndr-cp-lock"),
+		envVar("vip_leaseduration", "5"),
+		envVar("vip_renewdeadline", "3"),
+		envVar("vip_retryperiod", "1"),
+		envVar("address", vip.Address),
+	}
+	if vip.Interface != "" {
+		env = append(env, envVar("vip_interface", vip.Interface))
+	}
+
+	labels := map[string]interface{}{
+		"app.kubernetes.io/name": kubeVIPName,
+	}
+
+	return []config.GenericMap{
+		{
+			Data: map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "ServiceAccount",
+				"metadata": map[string]interface{}{
+					"name":      kubeVIPName,
+					"namespace": kubeVIPNamespace,
+				},
+			},
+		},
+		{
+			Data: map[string]interface{}{
+				"apiVersion": "rbac.authorization.k8s.io/v1",
+				"kind":       "ClusterRole",
+				"metadata": map[string]interface{}{
+					"name": "system:kube-vip-role",
+				},
+				"rules": []interface{}{
+					map[string]interface{}{
+						"apiGroups": []interface{}{""},
+						"resources": []interface{}{"services/status"},
+						"verbs":     []interface{}{"update"},
+					},
+					map[string]interface{}{
+						"apiGroups": []interface{}{""},
+						"resources": []interface{}{"services", "endpoints"},
+						"verbs":     []interface{}{"list", "get", "watch", "update"},
+					},
+					map[string]interface{}{
+						"apiGroups": []interface{}{""},
+						"resources": []interface{}{"nodes"},
+						"verbs":     []interface{}{"list", "get", "watch", "update", "patch"},
+					},
+					map[string]interface{}{
+						"apiGroups": []interface{}{"coordination.k8s.io"},
+						"resources": []interface{}{"leases"},
+						"verbs":     []interface{}{"list", "get", "watch", "update", "create"},
+					},
+					map[string]interface{}{
+						"apiGroups": []interface{}{"discovery.k8s.io"},
+						"resources": []interface{}{"endpointslices"},
+						"verbs":     []interface{}{"list", "get", "watch", "update"},
+					},
+				},
+			},
+		},
+		{
+			Data: map[string]interface{}{
+				"apiVersion": "rbac.authorization.k8s.io/v1",
+				"kind":       "ClusterRoleBinding",
+				"metadata": map[string]interface{}{
+					"name": "system:kube-vip-binding",
+				},
+				"roleRef": map[string]interface{}{
+					"apiGroup": "rbac.authorization.k8s.io",
+					"kind":     "ClusterRole",
+					"name":     "system:kube-vip-role",
+				},
+				"subjects": []interface{}{
+					map[string]interface{}{
+						"kind":      "ServiceAccount",
+						"name":      kubeVIPName,
+						"namespace": kubeVIPNamespace,
+					},
+				},
+			},
+		},
+		{
+			Data: map[string]interface{}{
+				"apiVersion": "apps/v1",
+				"kind":       "DaemonSet",
+				"metadata": map[string]interface{}{
+					"name":      kubeVIPName,
+					"namespace": kubeVIPNamespace,
+					"labels":    labels,
+				},
+				"spec": map[string]interface{}{
+					"selector": map[string]interface{}{
+						"matchLabels": labels,
+					},
+					"template": map[string]interface{}{
+						"metadata": map[string]interface{}{
+							"labels": labels,
+						},
+						"spec": map[string]interface{}{
+							"affinity": map[string]interface{}{
+								"nodeAffinity": map[string]interface{}{
+									"requiredDuringSchedulingIgnoredDuringExecution": map[string]interface{}{
+										"nodeSelectorTerms": []interface{}{
+											map[string]interface{}{
+												"matchExpressions": []interface{}{
+													map[string]interface{}{
+														"key":      "node-role.kubernetes.io/control-plane",
+														"operator": "Exists",
+													},
+												},
+											},
+										},
+									},
+								},
+							},
+							"containers": []interface{}{
+								map[string]interface{}{
+									"name":  kubeVIPName,
+									"image": vip.GetImage(),
+									"args":  []interface{}{"manager"},
+									"env":   env,
+									"securityContext": map[string]interface{}{
+										"capabilities": map[string]interface{}{
+											"add": []interface{}{"NET_ADMIN", "NET_RAW"},
+										},
+									},
+								},
+							},
+							"hostNetwork":        true,
+							"serviceAccountName": kubeVIPName,
+							"tolerations": []interface{}{
+								map[string]interface{}{
+									"effect":   "NoSchedule",
+									"operator": "Exists",
+								},
+								map[string]interface{}{
+									"effect":   "NoExecute",
+									"operator": "Exists",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func envVar(name, value string) map[string]interface{} {
+	return map[string]interface{}{
+		"name":  name,
+		"value": value,
+	}
+}
@@ -0,0 +1,82 @@
+package vip
+
+import (
+	"bytes"
+	"encoding/base64"
+	"testing"
+
+	"github.com/llmos-ai/llmos/utils/yaml"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/llmos-ai/llmos/pkg/bootstrap/config"
+)
+
+// daemonSetContainer returns the kube-vip container of the rendered manifest
+func daemonSetContainer(t *testing.T, cfg *config.Config, runtime config.Runtime) (map[string]interface{}, string) {
+	file, err := ToFile(cfg, runtime)
+	require.NoError(t, err)
+	require.NotNil(t, file)
+	data, err := base64.StdEncoding.DecodeString(file.Content)
+	require.NoError(t, err)
+
+	objs, err := yaml.ToObjects(bytes.NewReader(data))
+	require.NoError(t, err)
+	var kinds []string
+	var container map[string]interface{}
+	for _, obj := range objs {
+		u, ok := obj.(*unstructured.Unstructured)
+		require.True(t, ok)
+		kinds = append(kinds, u.GetKind())
+		if u.GetKind() != "DaemonSet" {
+			continue
+		}
+		containers, found, err := unstructured.NestedSlice(u.Object, "spec", "template", "spec", "containers")
+		require.NoError(t, err)
+		require.True(t, found)
+		require.Len(t, containers, 1)
+		container = containers[0].(map[string]interface{})
+	}
+	assert.Equal(t, []string{"ServiceAccount", "ClusterRole", "ClusterRoleBinding", "DaemonSet"}, kinds)
+	require.NotNil(t, container)
+	return container, file.Path
+}
+
+func containerEnv(container map[string]interface{}) map[string]string {
+	env := map[string]string{}
+	for _, e := range container["env"].([]interface{}) {
+		e := e.(map[string]interface{})
+		env[e["name"].(string)] = e["value"].(string)
+	}
+	return env
+}
+
+func TestToFile(t *testing.T) {
+	file, err := ToFile(&config.Config{}, config.RuntimeK3S)
+	require.NoError(t, err)
+	assert.Nil(t, file)
+
+	container, path := daemonSetContainer(t, &config.Config{
+		VIP: &config.VIPConfig{Address: "192.168.1.100"},
+	}, config.RuntimeK3S)
+	assert.Equal(t, "/var/lib/rancher/k3s/server/manifests/llmos-kube-vip.yaml", path)
+	assert.Equal(t, config.DefaultKubeVIPImage, container["image"])
+	env := containerEnv(container)
+	assert.Equal(t, "192.168.1.100", env["address"])
+	assert.Equal(t, "32", env["vip_cidr"])
+	assert.Equal(t, "6443", env["port"])
+	assert.NotContains(t, env, "vip_interface")
+}
+
+func TestToFileIPv6AndOverrides(t *testing.T) {
+	container, path := daemonSetContainer(t, &config.Config{
+		VIP: &config.VIPConfig{Address: "fd00::100", Interface: "eth1", Image: "registry.local/kube-vip:v0.8.7"},
+	}, config.RuntimeRKE2)
+	assert.Equal(t, "/var/lib/rancher/rke2/server/manifests/llmos-kube-vip.yaml", path)
+	assert.Equal(t, "registry.local/kube-vip:v0.8.7", container["image"])
+	env := containerEnv(container)
+	assert.Equal(t, "fd00::100", env["address"])
+	assert.Equal(t, "128", env["vip_cidr"])
+	assert.Equal(t, "eth1", env["vip_interface"])
+}
@@ -0,0 +1,279 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/llmos-ai/llmos/pkg/applyinator"
+	"github.com/llmos-ai/llmos/pkg/applyinator/image"
+	"github.com/llmos-ai/llmos/pkg/applyinator/prober"
+)
+
+const (
+	defaultInterval       = 15 * time.Second
+	defaultFailureBackoff = 5 * time.Second
+	maxFailureBackoff     = 5 * time.Minute
+)
+
+type Options struct {
+	DataDir  string
+	Source   Source
+	Interval time.Duration
+	// FailureBackoff is the delay before the failed one-time instructions are applied again, it doubles with
+	// every failure of the plan up to 5 minutes
+	FailureBackoff time.Duration
+	// Verifier refuses the plans which are not signed by a trusted key when set
+	Verifier     *applyinator.Verifier
+	ImageUtility *image.Utility
+}
+
+// Position records the reconciliation of a plan so the agent resumes it after a restart
+type Position struct {
+	AppliedChecksum string `json:"appliedChecksum,omitempty"`
+	// Failures counts the failed attempts to apply the one-time instructions of the plan
+	Failures int `json:"failures,omitempty"`
+	// FailedChecksum is the checksum of the plan the failures count for, a new plan starts over
+	FailedChecksum string `json:"failedChecksum,omitempty"`
+	// LastFailure is the time of the last failed attempt, the next attempt is backed off from it
+	LastFailure    time.Time                     `json:"lastFailure,omitempty"`
+	OneTimeOutput  []byte                        `json:"oneTimeOutput,omitempty"`
+	PeriodicOutput []byte                        `json:"periodicOutput,omitempty"`
+	ProbeStatuses  map[string]prober.ProbeStatus `json:"probeStatuses,omitempty"`
+}
+
+// PositionStore persists the plan positions, a source implementing it keeps the positions next to
+// its plans, the positions of the other sources are stored in the data dir
+type PositionStore interface {
+	LoadPosition(ctx context.Context, name string) (Position, error)
+	SavePosition(ctx context.Context, name string, position Position) error
+}
+
+// Notifier is implemented by the sources which notify the plan changes, the agent reconciles on
+// each notification besides the interval
+type Notifier interface {
+	Changes(ctx context.Context) <-chan struct{}
+}
+
+// Agent reconciles the plans of a source, it applies a plan again when its checksum changes and runs
+// its periodic instructions on their own cadence
+type Agent struct {
+	opts        Options
+	applyinator *applyinator.Applyinator
+	positions   PositionStore
+
+	mu sync.Mutex
+	// planNames are the plans of the last reconciliation
+	planNames []string
+}
+
+func New(opts Options) *Agent {
+	if opts.Interval <= 0 {
+		opts.Interval = defaultInterval
+	}
+	if opts.FailureBackoff <= 0 {
+		opts.FailureBackoff = defaultFailureBackoff
+	}
+
+	a := &Agent{opts: opts}
+	if store, ok := opts.Source.(PositionStore); ok {
+		a.positions = store
+	} else {
+		a.positions = &filePositions{dir: filepath.Join(a.dir(), "positions")}
+	}
+	a.applyinator = applyinator.NewApplyinator(filepath.Join(a.dir(), "work"), false,
+		filepath.Join(a.dir(), "applied"), a.InterlockDir(), image.NewUtility(opts.ImageUtility))
+	a.applyinator.SetLogsDir(a.LogsDir())
+	if opts.Verifier != nil {
+		a.applyinator.SetVerifier(opts.Verifier)
+	}
+	return a
+}
+
+// Run reconciles the plans every interval until the context is canceled
+func (a *Agent) Run(ctx context.Context) error {
+	if err := os.MkdirAll(a.InterlockDir(), 0700); err != nil {
+		return err
+	}
+	logrus.Infof("Starting llmos agent, reconciling plans every %s", a.opts.Interval)
+
+	var changes <-chan struct{}
+	if notifier, ok := a.opts.Source.(Notifier); ok {
+		changes = notifier.Changes(ctx)
+	}
+
+	ticker := time.NewTicker(a.opts.Interval)
+	defer ticker.Stop()
+	for {
+		if err := a.Reconcile(ctx); err != nil {
+			logrus.Errorf("failed to reconcile plans, will retry: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		case <-changes:
+		}
+	}
+}
+
+// Reconcile applies each plan of the source once, the errors of the plans are joined
+func (a *Agent) Reconcile(ctx context.Context) error {
+	plans, err := a.opts.Source.Plans(ctx)
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(plans))
+	for _, plan := range plans {
+		names = append(names, plan.Name)
+	}
+	a.mu.Lock()
+	a.planNames = names
+	a.mu.Unlock()
+
+	var errs []error
+	for _, plan := range plans {
+		if err = a.reconcilePlan(ctx, plan); err != nil {
+			errs = append(errs, fmt.Errorf("plan %s: %w", plan.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (a *Agent) reconcilePlan(ctx context.Context, input PlanInput) error {
+	cp, err := applyinator.CalculateSignedPlan(input.Raw, input.Signature)
+	if err != nil {
+		return fmt.Errorf("parsing plan: %w", err)
+	}
+
+	position, err := a.positions.LoadPosition(ctx, input.Name)
+	if err != nil {
+		return err
+	}
+
+	changed := cp.Checksum != position.AppliedChecksum
+	if changed && position.FailedChecksum != cp.Checksum {
+		// the failures of a previous plan do not back off the new one
+		position.Failures = 0
+	}
+	apply := changed
+	if changed && position.Failures > 0 {
+		if wait := time.Until(position.LastFailure.Add(a.failureBackoff(position.Failures))); wait > 0 {
+			logrus.Debugf("Backing off plan %s with checksum %s for %s after %d failures", input.Name,
+				cp.Checksum, wait.Round(time.Second), position.Failures)
+			apply = false
+		}
+	}
+	if apply {
+		logrus.Infof("Applying plan %s with checksum %s, attempt %d", input.Name, cp.Checksum, position.Failures+1)
+	}
+
+	output, err := a.applyinator.Apply(ctx, applyinator.ApplyInput{
+		CalculatedPlan:             cp,
+		RunOneTimeInstructions:     apply,
+		ReconcileFiles:             apply,
+		OneTimeInstructionAttempts: position.Failures + 1,
+		ExistingOneTimeOutput:      position.OneTimeOutput,
+		ExistingPeriodicOutput:     position.PeriodicOutput,
+	})
+	if err != nil {
+		// e.g. the restart-pending interlock, the plan is applied on the next reconciliation
+		return err
+	}
+
+	if apply {
+		position.OneTimeOutput = output.OneTimeOutput
+		if output.OneTimeApplySucceeded {
+			logrus.Infof("Successfully applied plan %s with checksum %s", input.Name, cp.Checksum)
+			position.AppliedChecksum = cp.Checksum
+			position.Failures = 0
+			position.FailedChecksum = ""
+			position.LastFailure = time.Time{}
+		} else {
+			position.Failures++
+			position.FailedChecksum = cp.Checksum
+			position.LastFailure = time.Now().UTC()
+		}
+	}
+	position.PeriodicOutput = output.PeriodicOutput
+
+	if len(cp.Plan.Probes) > 0 {
+		if position.ProbeStatuses == nil {
+			position.ProbeStatuses = map[string]prober.ProbeStatus{}
+		}
+		// the initial delay of the probes only applies right after the plan is applied
+		prober.DoProbes(ctx, cp.Plan.Probes, position.ProbeStatuses, apply)
+	}
+
+	if err = a.positions.SavePosition(ctx, input.Name, position); err != nil {
+		return err
+	}
+	if apply && !output.OneTimeApplySucceeded {
+		return fmt.Errorf("one-time instructions failed, will retry in %s", a.failureBackoff(position.Failures))
+	}
+	return nil
+}
+
+// failureBackoff returns the delay before the next attempt of a plan failed the number of times
+func (a *Agent) failureBackoff(failures int) time.Duration {
+	backoff := a.opts.FailureBackoff
+	for i := 1; i < failures && backoff < maxFailureBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, maxFailureBackoff)
+}
+
+// LoadPosition returns the persisted position of the plan, an empty position if the plan was never applied
+func (a *Agent) LoadPosition(ctx context.Context, name string) (Position, error) {
+	return a.positions.LoadPosition(ctx, name)
+}
+
+// ProbeStatuses returns the probe statuses of the plans of the last reconciliation keyed by
+// <plan>/<probe>
+func (a *Agent) ProbeStatuses(ctx context.Context) (map[string]prober.ProbeStatus, error) {
+	a.mu.Lock()
+	names := a.planNames
+	a.mu.Unlock()
+
+	statuses := map[string]prober.ProbeStatus{}
+	for _, name := range names {
+		position, err := a.positions.LoadPosition(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		for probeName, status := range position.ProbeStatuses {
+			statuses[name+"/"+probeName] = status
+		}
+	}
+	return statuses, nil
+}
+
+// InterlockDir holds the restart-pending file, the agent does not apply plans for up to 5 minutes while it exists
+func (a *Agent) InterlockDir() string {
+	return filepath.Join(a.dir(), "interlock")
+}
+
+// LogsDir holds the instruction logs of the applied plans
+func (a *Agent) LogsDir() string {
+	return LogsDir(a.opts.DataDir)
+}
+
+// LogsDir returns the directory of the instruction logs of the agent with the data dir
+func LogsDir(dataDir string) string {
+	return filepath.Join(dir(dataDir), "logs")
+}
+
+func (a *Agent) dir() string {
+	return dir(a.opts.DataDir)
+}
+
+func dir(dataDir string) string {
+	return filepath.Join(dataDir, "agent")
+}
@@ -0,0 +1,188 @@
+package agent
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/llmos-ai/llmos/pkg/applyinator"
+)
+
+func shell(name, script string) applyinator.CommonInstruction {
+	return applyinator.CommonInstruction{Name: name, Command: "/bin/sh", Args: []string{"-c", script}}
+}
+
+func writePlan(t *testing.T, path string, plan applyinator.Plan) []byte {
+	data, err := json.Marshal(plan)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, data, 0600))
+	return data
+}
+
+func periodicOutputs(t *testing.T, data []byte) map[string]applyinator.PeriodicInstructionOutput {
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	require.NoError(t, err)
+	raw, err := io.ReadAll(gz)
+	require.NoError(t, err)
+	outputs := map[string]applyinator.PeriodicInstructionOutput{}
+	require.NoError(t, json.Unmarshal(raw, &outputs))
+	return outputs
+}
+
+func countLines(t *testing.T, path string) int {
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	return bytes.Count(data, []byte("\n"))
+}
+
+func TestReconcile(t *testing.T) {
+	dataDir := t.TempDir()
+	plansDir := t.TempDir()
+	out := filepath.Join(t.TempDir(), "runs")
+	target := filepath.Join(t.TempDir(), "managed")
+
+	plan := applyinator.Plan{
+		Files: []applyinator.File{{
+			Path:    target,
+			Content: base64.StdEncoding.EncodeToString([]byte("v1")),
+		}},
+		OneTimeInstructions: []applyinator.OneTimeInstruction{{
+			CommonInstruction: shell("install", "echo once >> "+out),
+		}},
+		PeriodicInstructions: []applyinator.PeriodicInstruction{{
+			CommonInstruction: shell("check", "echo periodic >> "+out+"; echo healthy"),
+			PeriodSeconds:     3600,
+		}},
+	}
+	writePlan(t, filepath.Join(plansDir, "node.plan"), plan)
+
+	a := New(Options{DataDir: dataDir, Source: &FileSource{Path: plansDir}})
+	ctx := context.Background()
+	require.NoError(t, a.Reconcile(ctx))
+	assert.Equal(t, 2, countLines(t, out))
+	content, err := os.ReadFile(target)
+	require.NoError(t, err)
+	assert.Equal(t, "v1", string(content))
+
+	position, err := a.LoadPosition(context.Background(), "node")
+	require.NoError(t, err)
+	assert.NotEmpty(t, position.AppliedChecksum)
+	assert.Equal(t, "healthy\n", string(periodicOutputs(t, position.PeriodicOutput)["check"].Stdout))
+
+	// a restarted agent neither applies the unchanged plan nor runs the periodic instruction before its period
+	a = New(Options{DataDir: dataDir, Source: &FileSource{Path: plansDir}})
+	require.NoError(t, a.Reconcile(ctx))
+	assert.Equal(t, 2, countLines(t, out))
+
+	// the plan is applied again on checksum change
+	plan.Files[0].Content = base64.StdEncoding.EncodeToString([]byte("v2"))
+	writePlan(t, filepath.Join(plansDir, "node.plan"), plan)
+	require.NoError(t, a.Reconcile(ctx))
+	assert.Equal(t, 4, countLines(t, out))
+	content, err = os.ReadFile(target)
+	require.NoError(t, err)
+	assert.Equal(t, "v2", string(content))
+}
+
+func TestReconcileFailure(t *testing.T) {
+	dataDir := t.TempDir()
+	planFile := filepath.Join(t.TempDir(), "node.plan")
+	writePlan(t, planFile, applyinator.Plan{
+		OneTimeInstructions: []applyinator.OneTimeInstruction{{CommonInstruction: shell("fail", "exit 1")}},
+	})
+
+	a := New(Options{DataDir: dataDir, Source: &FileSource{Path: planFile}, FailureBackoff: time.Nanosecond})
+	for i := 1; i <= 2; i++ {
+		assert.Error(t, a.Reconcile(context.Background()))
+		position, err := a.LoadPosition(context.Background(), "node")
+		require.NoError(t, err)
+		assert.Empty(t, position.AppliedChecksum)
+		assert.Equal(t, i, position.Failures)
+	}
+}
+
+func TestReconcileFailureBackoff(t *testing.T) {
+	planFile := filepath.Join(t.TempDir(), "node.plan")
+	out := filepath.Join(t.TempDir(), "runs")
+	plan := applyinator.Plan{
+		OneTimeInstructions: []applyinator.OneTimeInstruction{{CommonInstruction: shell("fail", "echo once >> "+out+
+			"; exit 1")}},
+	}
+	writePlan(t, planFile, plan)
+
+	a := New(Options{DataDir: t.TempDir(), Source: &FileSource{Path: planFile}, FailureBackoff: time.Minute})
+	ctx := context.Background()
+	assert.ErrorContains(t, a.Reconcile(ctx), "will retry in 1m0s")
+	// the failed plan is not applied again before its backoff
+	require.NoError(t, a.Reconcile(ctx))
+	assert.Equal(t, 1, countLines(t, out))
+	position, err := a.LoadPosition(ctx, "node")
+	require.NoError(t, err)
+	assert.Equal(t, 1, position.Failures)
+	assert.NotEmpty(t, position.FailedChecksum)
+	assert.False(t, position.LastFailure.IsZero())
+
+	// a new plan is applied right away and starts over
+	plan.OneTimeInstructions[0].Args = []string{"-c", "echo once >> " + out}
+	writePlan(t, planFile, plan)
+	require.NoError(t, a.Reconcile(ctx))
+	assert.Equal(t, 2, countLines(t, out))
+	position, err = a.LoadPosition(ctx, "node")
+	require.NoError(t, err)
+	assert.Equal(t, 0, position.Failures)
+	assert.Empty(t, position.FailedChecksum)
+	assert.NotEmpty(t, position.AppliedChecksum)
+}
+
+func TestFailureBackoff(t *testing.T) {
+	a := New(Options{DataDir: t.TempDir(), Source: &FileSource{}})
+	assert.Equal(t, 5*time.Second, a.failureBackoff(1))
+	assert.Equal(t, 10*time.Second, a.failureBackoff(2))
+	assert.Equal(t, 40*time.Second, a.failureBackoff(4))
+	assert.Equal(t, maxFailureBackoff, a.failureBackoff(7))
+	assert.Equal(t, maxFailureBackoff, a.failureBackoff(1000))
+}
+
+func TestReconcileRestartPending(t *testing.T) {
+	dataDir := t.TempDir()
+	planFile := filepath.Join(t.TempDir(), "node.plan")
+	writePlan(t, planFile, applyinator.Plan{})
+
+	a := New(Options{DataDir: dataDir, Source: &FileSource{Path: planFile}})
+	require.NoError(t, os.MkdirAll(a.InterlockDir(), 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(a.InterlockDir(), "restart-pending"),
+		[]byte(time.Now().Format(time.UnixDate)), 0600))
+
+	assert.ErrorContains(t, a.Reconcile(context.Background()), "restart is pending")
+	position, err := a.LoadPosition(context.Background(), "node")
+	require.NoError(t, err)
+	assert.Empty(t, position.AppliedChecksum)
+}
+
+func TestReconcileSignedPlans(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	verifier, err := applyinator.NewVerifier(pub)
+	require.NoError(t, err)
+
+	planFile := filepath.Join(t.TempDir(), "node.plan")
+	raw := writePlan(t, planFile, applyinator.Plan{})
+	a := New(Options{DataDir: t.TempDir(), Source: &FileSource{Path: planFile}, Verifier: verifier})
+	assert.ErrorIs(t, a.Reconcile(context.Background()), applyinator.ErrUnsignedPlan)
+
+	sig, err := applyinator.SignPlan(raw, priv)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(planFile+applyinator.SignatureSuffix, sig, 0600))
+	require.NoError(t, a.Reconcile(context.Background()))
+}
@@ -0,0 +1,48 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// filePositions stores the positions as JSON files of a directory
+type filePositions struct {
+	dir string
+}
+
+func (f *filePositions) LoadPosition(_ context.Context, name string) (Position, error) {
+	position := Position{}
+	data, err := os.ReadFile(f.path(name))
+	if os.IsNotExist(err) {
+		return position, nil
+	} else if err != nil {
+		return position, err
+	}
+	if err = json.Unmarshal(data, &position); err != nil {
+		return position, fmt.Errorf("parsing position %s: %w", f.path(name), err)
+	}
+	return position, nil
+}
+
+func (f *filePositions) SavePosition(_ context.Context, name string, position Position) error {
+	data, err := json.Marshal(position)
+	if err != nil {
+		return err
+	}
+
+	if err = os.MkdirAll(f.dir, 0700); err != nil {
+		return err
+	}
+	tmp := f.path(name) + ".tmp"
+	if err = os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, f.path(name))
+}
+
+func (f *filePositions) path(name string) string {
+	return filepath.Join(f.dir, name+".json")
+}
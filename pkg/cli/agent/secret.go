@@ -0,0 +1,215 @@
+package agent
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
+
+	"github.com/llmos-ai/llmos/pkg/applyinator/prober"
+)
+
+// The keys of the plan Secret, the plan and its signature are written by the controller and
+// the agent writes back the others
+const (
+	SecretPlanKey            = "plan"
+	SecretSignatureKey       = "plan-signature"
+	SecretAppliedChecksumKey = "applied-checksum"
+	SecretFailureCountKey    = "failure-count"
+	SecretFailedChecksumKey  = "failed-checksum"
+	SecretLastFailureKey     = "last-failure-time"
+	SecretAppliedOutputKey   = "applied-output"
+	SecretPeriodicOutputKey  = "applied-periodic-output"
+	SecretProbeStatusesKey   = "probe-statuses"
+)
+
+const defaultReconnectBackoff = 5 * time.Second
+
+// SecretSource reads the plan of the node from a Secret and writes the position of the plan back to it,
+// like the machine plan Secret of the rancher system-agent
+type SecretSource struct {
+	Client    kubernetes.Interface
+	Namespace string
+	Name      string
+	// ReconnectBackoff is the delay before the watch is established again after it is closed or failed
+	ReconnectBackoff time.Duration
+
+	// lastPlan is the checksum of the plan and signature last notified by the watch
+	lastPlan string
+}
+
+// SecretName returns the name of the plan Secret of the node
+func SecretName(nodeName string) string {
+	return "llmos-plan-" + nodeName
+}
+
+func (s *SecretSource) Plans(ctx context.Context) ([]PlanInput, error) {
+	secret, err := s.Client.CoreV1().Secrets(s.Namespace).Get(ctx, s.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		logrus.Debugf("plan secret %s/%s is not found", s.Namespace, s.Name)
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("getting plan secret %s/%s: %w", s.Namespace, s.Name, err)
+	}
+
+	if len(secret.Data[SecretPlanKey]) == 0 {
+		return nil, nil
+	}
+	return []PlanInput{{
+		Name:      s.Name,
+		Raw:       secret.Data[SecretPlanKey],
+		Signature: secret.Data[SecretSignatureKey],
+	}}, nil
+}
+
+func (s *SecretSource) LoadPosition(ctx context.Context, _ string) (Position, error) {
+	position := Position{}
+	secret, err := s.Client.CoreV1().Secrets(s.Namespace).Get(ctx, s.Name, metav1.GetOptions{})
+	if err != nil {
+		return position, fmt.Errorf("getting plan secret %s/%s: %w", s.Namespace, s.Name, err)
+	}
+
+	position.AppliedChecksum = string(secret.Data[SecretAppliedChecksumKey])
+	position.OneTimeOutput = secret.Data[SecretAppliedOutputKey]
+	position.PeriodicOutput = secret.Data[SecretPeriodicOutputKey]
+	if count := string(secret.Data[SecretFailureCountKey]); count != "" {
+		if position.Failures, err = strconv.Atoi(count); err != nil {
+			return position, fmt.Errorf("parsing %s of plan secret %s/%s: %w", SecretFailureCountKey,
+				s.Namespace, s.Name, err)
+		}
+	}
+	position.FailedChecksum = string(secret.Data[SecretFailedChecksumKey])
+	if lastFailure := string(secret.Data[SecretLastFailureKey]); lastFailure != "" {
+		if position.LastFailure, err = time.Parse(time.RFC3339, lastFailure); err != nil {
+			return position, fmt.Errorf("parsing %s of plan secret %s/%s: %w", SecretLastFailureKey,
+				s.Namespace, s.Name, err)
+		}
+	}
+	if statuses := secret.Data[SecretProbeStatusesKey]; len(statuses) > 0 {
+		position.ProbeStatuses = map[string]prober.ProbeStatus{}
+		if err = json.Unmarshal(statuses, &position.ProbeStatuses); err != nil {
+			return position, fmt.Errorf("parsing %s of plan secret %s/%s: %w", SecretProbeStatusesKey,
+				s.Namespace, s.Name, err)
+		}
+	}
+	return position, nil
+}
+
+// SavePosition writes the position back to the latest Secret, it retries on the conflicts with the
+// controller updating the plan
+func (s *SecretSource) SavePosition(ctx context.Context, _ string, position Position) error {
+	statuses, err := json.Marshal(position.ProbeStatuses)
+	if err != nil {
+		return err
+	}
+
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		secret, err := s.Client.CoreV1().Secrets(s.Namespace).Get(ctx, s.Name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+
+		secret = secret.DeepCopy()
+		if secret.Data == nil {
+			secret.Data = map[string][]byte{}
+		}
+		secret.Data[SecretAppliedChecksumKey] = []byte(position.AppliedChecksum)
+		secret.Data[SecretFailureCountKey] = []byte(strconv.Itoa(position.Failures))
+		secret.Data[SecretFailedChecksumKey] = []byte(position.FailedChecksum)
+		if position.LastFailure.IsZero() {
+			delete(secret.Data, SecretLastFailureKey)
+		} else {
+			secret.Data[SecretLastFailureKey] = []byte(position.LastFailure.Format(time.RFC3339))
+		}
+		secret.Data[SecretAppliedOutputKey] = position.OneTimeOutput
+		secret.Data[SecretPeriodicOutputKey] = position.PeriodicOutput
+		secret.Data[SecretProbeStatusesKey] = statuses
+
+		_, err = s.Client.CoreV1().Secrets(s.Namespace).Update(ctx, secret, metav1.UpdateOptions{})
+		return err
+	})
+}
+
+// Changes watches the Secret and notifies its changes, the watch is established again whenever it
+// is closed or failed, e.g. while the apiserver restarts
+func (s *SecretSource) Changes(ctx context.Context) <-chan struct{} {
+	backoff := s.ReconnectBackoff
+	if backoff <= 0 {
+		backoff = defaultReconnectBackoff
+	}
+
+	changes := make(chan struct{}, 1)
+	go func() {
+		for {
+			if err := s.watch(ctx, changes); err != nil {
+				logrus.Warnf("watching plan secret %s/%s, will reconnect: %v", s.Namespace, s.Name, err)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(backoff):
+			}
+		}
+	}()
+	return changes
+}
+
+func (s *SecretSource) watch(ctx context.Context, changes chan<- struct{}) error {
+	w, err := s.Client.CoreV1().Secrets(s.Namespace).Watch(ctx, metav1.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector("metadata.name", s.Name).String(),
+	})
+	if err != nil {
+		return err
+	}
+	defer w.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-w.ResultChan():
+			if !ok {
+				return fmt.Errorf("watch closed")
+			}
+			switch event.Type {
+			case watch.Error:
+				return apierrors.FromObject(event.Object)
+			case watch.Added, watch.Modified:
+				// the agent writes back to the same Secret, only the plan changes are notified
+				if secret, ok := event.Object.(*corev1.Secret); ok && !s.planChanged(secret) {
+					continue
+				}
+			}
+			// the notification is dropped if a reconciliation is already pending
+			select {
+			case changes <- struct{}{}:
+			default:
+			}
+		}
+	}
+}
+
+// planChanged tracks the plan and signature of the watched Secret
+func (s *SecretSource) planChanged(secret *corev1.Secret) bool {
+	h := sha256.New()
+	h.Write(secret.Data[SecretPlanKey])
+	h.Write(secret.Data[SecretSignatureKey])
+	checksum := hex.EncodeToString(h.Sum(nil))
+	if checksum == s.lastPlan {
+		return false
+	}
+	s.lastPlan = checksum
+	return true
+}
@@ -0,0 +1,155 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/llmos-ai/llmos/pkg/applyinator"
+	"github.com/llmos-ai/llmos/pkg/applyinator/prober"
+	"github.com/llmos-ai/llmos/pkg/constants"
+)
+
+func planSecret(t *testing.T, plan applyinator.Plan) *corev1.Secret {
+	data, err := json.Marshal(plan)
+	require.NoError(t, err)
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: SecretName("node1"), Namespace: constants.SystemNamespace},
+		Data:       map[string][]byte{SecretPlanKey: data},
+	}
+}
+
+func newSecretSource(client *fake.Clientset) *SecretSource {
+	return &SecretSource{
+		Client:           client,
+		Namespace:        constants.SystemNamespace,
+		Name:             SecretName("node1"),
+		ReconnectBackoff: 10 * time.Millisecond,
+	}
+}
+
+func TestSecretSource(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	out := filepath.Join(t.TempDir(), "runs")
+	client := fake.NewSimpleClientset(planSecret(t, applyinator.Plan{
+		OneTimeInstructions: []applyinator.OneTimeInstruction{{
+			CommonInstruction: shell("install", "echo once >> "+out+"; echo installed"),
+			SaveOutput:        true,
+		}},
+		Probes: map[string]prober.Probe{
+			"server": {HTTPGetAction: prober.HTTPGetAction{URL: server.URL}, TimeoutSeconds: 5},
+		},
+	}))
+
+	// the controller updates the secret concurrently, the first write back conflicts
+	var conflicts atomic.Int32
+	client.PrependReactor("update", "secrets", func(k8stesting.Action) (bool, k8sruntime.Object, error) {
+		if conflicts.Add(1) == 1 {
+			return true, nil, apierrors.NewConflict(schema.GroupResource{Resource: "secrets"},
+				SecretName("node1"), nil)
+		}
+		return false, nil, nil
+	})
+
+	source := newSecretSource(client)
+	a := New(Options{DataDir: t.TempDir(), Source: source})
+	ctx := context.Background()
+	require.NoError(t, a.Reconcile(ctx))
+	require.NoError(t, a.Reconcile(ctx))
+	assert.Equal(t, 1, countLines(t, out))
+
+	secret, err := client.CoreV1().Secrets(constants.SystemNamespace).Get(ctx, SecretName("node1"), metav1.GetOptions{})
+	require.NoError(t, err)
+	cp, err := applyinator.CalculatePlan(secret.Data[SecretPlanKey])
+	require.NoError(t, err)
+	assert.Equal(t, cp.Checksum, string(secret.Data[SecretAppliedChecksumKey]))
+	assert.Equal(t, "0", string(secret.Data[SecretFailureCountKey]))
+	assert.Empty(t, secret.Data[SecretFailedChecksumKey])
+	assert.NotContains(t, secret.Data, SecretLastFailureKey)
+	assert.NotEmpty(t, secret.Data[SecretAppliedOutputKey])
+	assert.NotEmpty(t, secret.Data[SecretPeriodicOutputKey])
+
+	statuses := map[string]prober.ProbeStatus{}
+	require.NoError(t, json.Unmarshal(secret.Data[SecretProbeStatusesKey], &statuses))
+	assert.True(t, statuses["server"].Healthy)
+
+	position, err := a.LoadPosition(ctx, source.Name)
+	require.NoError(t, err)
+	assert.Equal(t, cp.Checksum, position.AppliedChecksum)
+	assert.Equal(t, statuses, position.ProbeStatuses)
+
+	probeStatuses, err := a.ProbeStatuses(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]prober.ProbeStatus{SecretName("node1") + "/server": statuses["server"]}, probeStatuses)
+}
+
+func TestSecretSourceNotFound(t *testing.T) {
+	plans, err := newSecretSource(fake.NewSimpleClientset()).Plans(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, plans)
+}
+
+func TestSecretSourceChanges(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	watchers := make(chan *watch.FakeWatcher, 2)
+	client.PrependWatchReactor("secrets", func(k8stesting.Action) (bool, watch.Interface, error) {
+		w := watch.NewFake()
+		watchers <- w
+		return true, w, nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	changes := newSecretSource(client).Changes(ctx)
+
+	secret := planSecret(t, applyinator.Plan{})
+	first := <-watchers
+	first.Add(secret)
+	assertNotified(t, changes)
+
+	// the write back of the agent does not change the plan
+	updated := secret.DeepCopy()
+	updated.Data[SecretAppliedChecksumKey] = []byte("applied")
+	first.Modify(updated)
+	select {
+	case <-changes:
+		t.Fatal("unexpected notification for the written back position")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// the watch is established again after the apiserver closed it
+	first.Stop()
+	second := <-watchers
+	updated = updated.DeepCopy()
+	updated.Data[SecretPlanKey] = []byte(`{"files":[]}`)
+	second.Modify(updated)
+	assertNotified(t, changes)
+}
+
+func assertNotified(t *testing.T, changes <-chan struct{}) {
+	select {
+	case <-changes:
+	case <-time.After(5 * time.Second):
+		t.Fatal("plan change is not notified")
+	}
+}
@@ -0,0 +1,83 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/llmos-ai/llmos/pkg/applyinator"
+)
+
+const planFileSuffix = ".plan"
+
+// PlanInput is a raw plan read from a plan source with its optional detached signature
+type PlanInput struct {
+	// Name identifies the plan and its persisted position, it must be unique in a source
+	Name      string
+	Raw       []byte
+	Signature []byte
+}
+
+// Source provides the plans reconciled by the agent
+type Source interface {
+	Plans(ctx context.Context) ([]PlanInput, error)
+}
+
+// FileSource reads the plan from a file, or the *.plan files of a directory, and their <file>.sig signatures
+type FileSource struct {
+	Path string
+}
+
+func (f *FileSource) Plans(_ context.Context) ([]PlanInput, error) {
+	info, err := os.Stat(f.Path)
+	if err != nil {
+		return nil, fmt.Errorf("reading plan source %s: %w", f.Path, err)
+	}
+	if !info.IsDir() {
+		plan, err := readPlanFile(f.Path)
+		if err != nil {
+			return nil, err
+		}
+		return []PlanInput{plan}, nil
+	}
+
+	entries, err := os.ReadDir(f.Path)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name() < entries[j].Name()
+	})
+
+	var plans []PlanInput
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), planFileSuffix) {
+			continue
+		}
+		plan, err := readPlanFile(filepath.Join(f.Path, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		plans = append(plans, plan)
+	}
+	return plans, nil
+}
+
+func readPlanFile(path string) (PlanInput, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return PlanInput{}, err
+	}
+	signature, err := os.ReadFile(path + applyinator.SignatureSuffix)
+	if err != nil && !os.IsNotExist(err) {
+		return PlanInput{}, err
+	}
+	return PlanInput{
+		Name:      strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)),
+		Raw:       raw,
+		Signature: signature,
+	}, nil
+}
@@ -0,0 +1,241 @@
+package health
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/llmos-ai/llmos/pkg/applyinator/prober"
+	"github.com/llmos-ai/llmos/pkg/bootstrap"
+	"github.com/llmos-ai/llmos/pkg/utils/metrics"
+)
+
+const (
+	readHeaderTimeout = 10 * time.Second
+	shutdownTimeout   = 5 * time.Second
+)
+
+// Options configures the health listener, it serves HTTPS when the cert and key are set and only
+// accepts the clients presenting a certificate signed by the client CA when it is set
+type Options struct {
+	Address      string
+	CertFile     string
+	KeyFile      string
+	ClientCAFile string
+}
+
+// ProbeSource provides the probe statuses checked by /readyz
+type ProbeSource interface {
+	ProbeStatuses(ctx context.Context) (map[string]prober.ProbeStatus, error)
+}
+
+// ProbeSourceFunc adapts a function to a ProbeSource
+type ProbeSourceFunc func(ctx context.Context) (map[string]prober.ProbeStatus, error)
+
+func (f ProbeSourceFunc) ProbeStatuses(ctx context.Context) (map[string]prober.ProbeStatus, error) {
+	return f(ctx)
+}
+
+// StatusFunc returns the bootstrap status of the node
+type StatusFunc func() (bootstrap.Status, error)
+
+// Readiness is the body of /readyz, the node is ready once it is bootstrapped and all its probes
+// are healthy, or only when all its probes are healthy without a bootstrap status
+type Readiness struct {
+	Ready     bool                          `json:"ready"`
+	Phase     string                        `json:"phase"`
+	Unhealthy []string                      `json:"unhealthy,omitempty"`
+	Probes    map[string]prober.ProbeStatus `json:"probes"`
+	Errors    []string                      `json:"errors,omitempty"`
+}
+
+// Server exposes the node health without SSH, /healthz reports the liveness of the process, /readyz
+// the readiness of the node, /status its bootstrap phase and versions and /metrics the prometheus metrics
+type Server struct {
+	opts   Options
+	status StatusFunc
+	probes []ProbeSource
+}
+
+func New(opts Options, status StatusFunc, probes ...ProbeSource) *Server {
+	return &Server{
+		opts:   opts,
+		status: status,
+		probes: probes,
+	}
+}
+
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	})
+	mux.HandleFunc("/readyz", s.readyz)
+	mux.HandleFunc("/status", s.statusz)
+	mux.Handle("/metrics", metrics.Handler())
+	return mux
+}
+
+// Readiness returns the bootstrap phase and the probe statuses of all probe sources
+func (s *Server) Readiness(ctx context.Context) Readiness {
+	readiness := Readiness{Probes: map[string]prober.ProbeStatus{}}
+
+	if s.status != nil {
+		status, err := s.status()
+		if err != nil {
+			readiness.Errors = append(readiness.Errors, fmt.Sprintf("getting bootstrap status: %v", err))
+		}
+		readiness.Phase = status.Phase
+	}
+
+	for _, source := range s.probes {
+		statuses, err := source.ProbeStatuses(ctx)
+		if err != nil {
+			readiness.Errors = append(readiness.Errors, fmt.Sprintf("getting probe statuses: %v", err))
+			continue
+		}
+		for name, probeStatus := range statuses {
+			readiness.Probes[name] = probeStatus
+			if !probeStatus.Healthy {
+				readiness.Unhealthy = append(readiness.Unhealthy, name)
+			}
+		}
+	}
+	sort.Strings(readiness.Unhealthy)
+
+	readiness.Ready = (s.status == nil || readiness.Phase == bootstrap.PhaseBootstrapped) &&
+		len(readiness.Unhealthy) == 0 && len(readiness.Errors) == 0
+	return readiness
+}
+
+func (s *Server) readyz(w http.ResponseWriter, r *http.Request) {
+	readiness := s.Readiness(r.Context())
+	code := http.StatusOK
+	if !readiness.Ready {
+		code = http.StatusServiceUnavailable
+	}
+	writeJSON(w, code, readiness)
+}
+
+func (s *Server) statusz(w http.ResponseWriter, _ *http.Request) {
+	if s.status == nil {
+		http.Error(w, "bootstrap status is not available", http.StatusNotFound)
+		return
+	}
+	status, err := s.status()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, status)
+}
+
+func writeJSON(w http.ResponseWriter, code int, obj interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(obj); err != nil {
+		logrus.Debugf("writing health response: %v", err)
+	}
+}
+
+// Run listens on the address and serves until the context is canceled
+func (s *Server) Run(ctx context.Context) error {
+	listener, err := net.Listen("tcp", s.opts.Address)
+	if err != nil {
+		return fmt.Errorf("listening on %s: %w", s.opts.Address, err)
+	}
+	return s.Serve(ctx, listener)
+}
+
+// Serve serves on the listener until the context is canceled, the listener is closed when it returns
+func (s *Server) Serve(ctx context.Context, listener net.Listener) error {
+	tlsConfig, err := s.tlsConfig()
+	if err != nil {
+		_ = listener.Close()
+		return err
+	}
+
+	server := &http.Server{
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: readHeaderTimeout,
+		TLSConfig:         tlsConfig,
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		if tlsConfig != nil {
+			logrus.Infof("Serving node health on https://%s", listener.Addr())
+			errCh <- server.ServeTLS(listener, s.opts.CertFile, s.opts.KeyFile)
+		} else {
+			logrus.Infof("Serving node health on http://%s", listener.Addr())
+			errCh <- server.Serve(listener)
+		}
+	}()
+
+	select {
+	case err = <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err = server.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	if err = <-errCh; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+func (s *Server) tlsConfig() (*tls.Config, error) {
+	if s.opts.CertFile == "" && s.opts.KeyFile == "" {
+		if s.opts.ClientCAFile != "" {
+			return nil, fmt.Errorf("the health cert and key are required to verify the client certificates")
+		}
+		return nil, nil
+	}
+	if s.opts.CertFile == "" || s.opts.KeyFile == "" {
+		return nil, fmt.Errorf("both the health cert and key are required to serve HTTPS")
+	}
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if s.opts.ClientCAFile != "" {
+		caCert, err := os.ReadFile(s.opts.ClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading client CA %s: %w", s.opts.ClientCAFile, err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caCert) {
+			return nil, fmt.Errorf("no certificates found in client CA %s", s.opts.ClientCAFile)
+		}
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return tlsConfig, nil
+}
+
+// RunnerSource reports the statuses of the probes run by the runner, the probes which have not run
+// yet, e.g. during their initial delay, are unhealthy
+func RunnerSource(runner *prober.Runner, probes map[string]prober.Probe) ProbeSource {
+	return ProbeSourceFunc(func(context.Context) (map[string]prober.ProbeStatus, error) {
+		statuses := runner.Statuses()
+		for name := range probes {
+			if _, ok := statuses[name]; !ok {
+				statuses[name] = prober.ProbeStatus{LastError: "probe has not run yet"}
+			}
+		}
+		return statuses, nil
+	})
+}
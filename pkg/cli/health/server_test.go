@@ -0,0 +1,190 @@
+package health
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/llmos-ai/llmos/pkg/applyinator/prober"
+	"github.com/llmos-ai/llmos/pkg/bootstrap"
+)
+
+func staticProbes(statuses map[string]prober.ProbeStatus) ProbeSource {
+	return ProbeSourceFunc(func(context.Context) (map[string]prober.ProbeStatus, error) {
+		return statuses, nil
+	})
+}
+
+func get(t *testing.T, client *http.Client, url string, obj interface{}) int {
+	resp, err := client.Get(url)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	if obj != nil {
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(obj))
+	}
+	return resp.StatusCode
+}
+
+func TestServerEndpoints(t *testing.T) {
+	status := bootstrap.Status{Phase: bootstrap.PhaseBootstrapped, Role: "server", KubernetesVersion: "v1.31.3+k3s1"}
+	statuses := map[string]prober.ProbeStatus{"kubelet": {Healthy: true}}
+	server := New(Options{}, func() (bootstrap.Status, error) {
+		return status, nil
+	}, staticProbes(statuses), staticProbes(map[string]prober.ProbeStatus{"node/gateway": {Healthy: true}}))
+
+	ts := httptest.NewServer(server.Handler())
+	defer ts.Close()
+
+	assert.Equal(t, http.StatusOK, get(t, ts.Client(), ts.URL+"/healthz", nil))
+
+	readiness := Readiness{}
+	assert.Equal(t, http.StatusOK, get(t, ts.Client(), ts.URL+"/readyz", &readiness))
+	assert.True(t, readiness.Ready)
+	assert.Len(t, readiness.Probes, 2)
+
+	got := bootstrap.Status{}
+	assert.Equal(t, http.StatusOK, get(t, ts.Client(), ts.URL+"/status", &got))
+	assert.Equal(t, status, got)
+
+	statuses["kube-apiserver"] = prober.ProbeStatus{FailureCount: 3, LastError: "connection refused"}
+	readiness = Readiness{}
+	assert.Equal(t, http.StatusServiceUnavailable, get(t, ts.Client(), ts.URL+"/readyz", &readiness))
+	assert.False(t, readiness.Ready)
+	assert.Equal(t, []string{"kube-apiserver"}, readiness.Unhealthy)
+	assert.Equal(t, "connection refused", readiness.Probes["kube-apiserver"].LastError)
+
+	resp, err := ts.Client().Get(ts.URL + "/metrics")
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, string(body), "go_goroutines")
+
+	delete(statuses, "kube-apiserver")
+	status.Phase = bootstrap.PhaseBootstrapping
+	readiness = Readiness{}
+	assert.Equal(t, http.StatusServiceUnavailable, get(t, ts.Client(), ts.URL+"/readyz", &readiness))
+	assert.Equal(t, bootstrap.PhaseBootstrapping, readiness.Phase)
+}
+
+func TestRunnerSource(t *testing.T) {
+	probes := map[string]prober.Probe{"delayed": {InitialDelaySeconds: 60}}
+	statuses, err := RunnerSource(prober.NewRunner(probes), probes).ProbeStatuses(context.Background())
+	require.NoError(t, err)
+	assert.False(t, statuses["delayed"].Healthy)
+}
+
+type testCert struct {
+	cert     *x509.Certificate
+	key      *ecdsa.PrivateKey
+	certFile string
+	keyFile  string
+}
+
+func newTestCert(t *testing.T, dir, name string, template *x509.Certificate, parent *testCert) *testCert {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template.Subject = pkix.Name{CommonName: name}
+	template.NotBefore = time.Now().Add(-time.Hour)
+	template.NotAfter = time.Now().Add(time.Hour)
+	signer, signerKey := template, key
+	if parent != nil {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	c := &testCert{
+		cert:     cert,
+		key:      key,
+		certFile: filepath.Join(dir, name+".crt"),
+		keyFile:  filepath.Join(dir, name+".key"),
+	}
+	require.NoError(t, os.WriteFile(c.certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	require.NoError(t, os.WriteFile(c.keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600))
+	return c
+}
+
+func TestServerClientCertAuth(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCert(t, dir, "ca", &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}, nil)
+	serverCert := newTestCert(t, dir, "server", &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}, ca)
+	clientCert := newTestCert(t, dir, "client", &x509.Certificate{
+		SerialNumber: big.NewInt(3),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, ca)
+
+	server := New(Options{
+		CertFile:     serverCert.certFile,
+		KeyFile:      serverCert.keyFile,
+		ClientCAFile: ca.certFile,
+	}, nil, staticProbes(map[string]prober.ProbeStatus{"kubelet": {Healthy: true}}))
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- server.Serve(ctx, listener)
+	}()
+	url := fmt.Sprintf("https://%s/readyz", listener.Addr())
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	_, err = (&http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots}}}).Get(url)
+	assert.Error(t, err, "the clients without a certificate are rejected")
+
+	keyPair, err := tls.LoadX509KeyPair(clientCert.certFile, clientCert.keyFile)
+	require.NoError(t, err)
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+		RootCAs:      roots,
+		Certificates: []tls.Certificate{keyPair},
+	}}}
+	readiness := Readiness{}
+	assert.Equal(t, http.StatusOK, get(t, client, url, &readiness))
+	assert.True(t, readiness.Ready)
+
+	cancel()
+	require.NoError(t, <-done)
+}
+
+func TestServerTLSConfigErrors(t *testing.T) {
+	_, err := New(Options{ClientCAFile: "ca.crt"}, nil).tlsConfig()
+	assert.Error(t, err)
+	_, err = New(Options{CertFile: "server.crt"}, nil).tlsConfig()
+	assert.Error(t, err)
+}
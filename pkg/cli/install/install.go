@@ -0,0 +1,326 @@
+package install
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/llmos-ai/llmos/pkg/utils"
+	"github.com/llmos-ai/llmos/pkg/utils/command"
+)
+
+const (
+	SystemName = "llmos"
+
+	InitSystemd = "systemd"
+	InitOpenRC  = "openrc"
+
+	defaultBinDir     = "/usr/local/bin"
+	fallbackBinDir    = "/opt/bin"
+	defaultSystemdDir = "/etc/systemd/system"
+)
+
+var preservedEnv = regexp.MustCompile(`^(LLMOS_.+|(?i:(no|http|https)_proxy))=`)
+
+type Options struct {
+	// Root is the target root of the installation, all the paths are relative to it
+	Root         string
+	BinDir       string
+	SystemdDir   string
+	Init         string
+	Args         []string
+	Env          []string
+	SkipEnable   bool
+	SkipStart    bool
+	ForceRestart bool
+}
+
+// Installer installs the running llmos binary as the llmos bootstrap service managed by systemd or openrc
+type Installer struct {
+	opts       Options
+	executable string
+	Run        command.Runner
+}
+
+func New(opts Options) (*Installer, error) {
+	executable, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("finding the running llmos binary: %w", err)
+	}
+	if executable, err = filepath.EvalSymlinks(executable); err != nil {
+		return nil, err
+	}
+
+	if opts.Root == "" {
+		opts.Root = "/"
+	}
+	if opts.Env == nil {
+		opts.Env = os.Environ()
+	}
+	if opts.Init == "" {
+		if opts.Init, err = detectInit(); err != nil {
+			return nil, err
+		}
+	}
+	if opts.Init != InitSystemd && opts.Init != InitOpenRC {
+		return nil, fmt.Errorf("unsupported init system %s, must be one of %s, %s", opts.Init, InitSystemd, InitOpenRC)
+	}
+	if opts.SystemdDir == "" {
+		opts.SystemdDir = defaultSystemdDir
+	}
+	if opts.BinDir == "" {
+		opts.BinDir = defaultBinDir
+		if !writable(filepath.Join(opts.Root, defaultBinDir)) && isDir(filepath.Join(opts.Root, fallbackBinDir)) {
+			opts.BinDir = fallbackBinDir
+		}
+	}
+
+	return &Installer{
+		opts:       opts,
+		executable: executable,
+		Run:        command.Run,
+	}, nil
+}
+
+// Install copies the binary, writes the service and environment files and enables the service,
+// the service is only restarted when any of the installed files changes
+func (i *Installer) Install(ctx context.Context) error {
+	preHashes, err := i.installedHashes()
+	if err != nil {
+		return err
+	}
+
+	if err = i.installBinary(); err != nil {
+		return err
+	}
+	if err = i.writeUninstallScript(); err != nil {
+		return err
+	}
+	if err = i.writeEnvFile(); err != nil {
+		return err
+	}
+	if err = i.writeServiceFile(); err != nil {
+		return err
+	}
+
+	if i.opts.SkipEnable {
+		return nil
+	}
+	if err = i.enable(ctx); err != nil {
+		return err
+	}
+
+	if i.opts.SkipStart {
+		return nil
+	}
+	postHashes, err := i.installedHashes()
+	if err != nil {
+		return err
+	}
+	if preHashes == postHashes && !i.opts.ForceRestart {
+		logrus.Info("No change detected so skipping service start")
+		return nil
+	}
+	return i.start(ctx)
+}
+
+func (i *Installer) installBinary() error {
+	target := i.path(i.BinPath())
+	if target == i.executable {
+		logrus.Infof("Skipping llmos binary install, %s is already running", target)
+		return nil
+	}
+	sourceHash, err := utils.HashFile(i.executable)
+	if err != nil {
+		return err
+	}
+	// the binary is not installed yet if the target does not exist
+	targetHash, err := utils.HashFile(target)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if targetHash == sourceHash {
+		logrus.Infof("Skipping llmos binary install, installed %s matches hash", target)
+		return nil
+	}
+
+	logrus.Infof("Installing llmos to %s", target)
+	src, err := os.Open(i.executable)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	if err = os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	// write to a temp file first, the target binary may be busy
+	tmp, err := os.CreateTemp(filepath.Dir(target), ".llmos-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err = io.Copy(tmp, src); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	if err = os.Chmod(tmp.Name(), 0755); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), target)
+}
+
+// writeEnvFile captures the LLMOS_ and proxy variables of the current environment for the service to use
+func (i *Installer) writeEnvFile() error {
+	var lines []string
+	for _, env := range i.opts.Env {
+		if !preservedEnv.MatchString(env) {
+			continue
+		}
+		key, value, _ := strings.Cut(env, "=")
+		lines = append(lines, fmt.Sprintf("%s=%s", key, quoteEnv(value)))
+	}
+	sort.Strings(lines)
+
+	content := strings.Join(lines, "\n")
+	if content != "" {
+		content += "\n"
+	}
+	logrus.Infof("Creating environment file %s", i.EnvFilePath())
+	return i.writeFile(i.EnvFilePath(), content, 0600)
+}
+
+func (i *Installer) writeServiceFile() error {
+	if i.opts.Init == InitOpenRC {
+		logrus.Infof("openrc: Creating service file %s", i.ServiceFilePath())
+		if err := i.writeFile(i.ServiceFilePath(), i.openRCService(), 0755); err != nil {
+			return err
+		}
+		return i.writeFile(filepath.Join("/etc/logrotate.d", SystemName), fmt.Sprintf(openRCLogrotate, logFile), 0644)
+	}
+
+	logrus.Infof("systemd: Creating service file %s", i.ServiceFilePath())
+	// the environment file used to live next to the unit file, remove the stale one
+	if err := os.Remove(i.path(i.ServiceFilePath() + ".env")); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return i.writeFile(i.ServiceFilePath(), i.systemdService(), 0644)
+}
+
+func (i *Installer) writeUninstallScript() error {
+	path := filepath.Join(i.opts.BinDir, SystemName+"-uninstall.sh")
+	logrus.Infof("Creating uninstall script %s", path)
+	return i.writeFile(path, i.uninstallScript(), 0755)
+}
+
+func (i *Installer) enable(ctx context.Context) error {
+	if i.opts.Init == InitOpenRC {
+		logrus.Infof("openrc: Enabling %s service for default runlevel", SystemName)
+		return i.Run(ctx, "rc-update", "add", SystemName, "default")
+	}
+
+	logrus.Infof("systemd: Enabling %s unit", SystemName)
+	if err := i.Run(ctx, "systemctl", "enable", i.ServiceFilePath()); err != nil {
+		return err
+	}
+	return i.Run(ctx, "systemctl", "daemon-reload")
+}
+
+func (i *Installer) start(ctx context.Context) error {
+	if i.opts.Init == InitOpenRC {
+		logrus.Infof("openrc: Starting %s", SystemName)
+		return i.Run(ctx, i.ServiceFilePath(), "restart")
+	}
+
+	logrus.Infof("systemd: Starting %s", SystemName)
+	if err := i.Run(ctx, "systemctl", "restart", "--no-block", SystemName); err != nil {
+		return err
+	}
+	logrus.Infof("Run \"journalctl -u %s -f\" to watch logs", SystemName)
+	return nil
+}
+
+func (i *Installer) BinPath() string {
+	return filepath.Join(i.opts.BinDir, SystemName)
+}
+
+func (i *Installer) ServiceFilePath() string {
+	if i.opts.Init == InitOpenRC {
+		return filepath.Join("/etc/init.d", SystemName)
+	}
+	return filepath.Join(i.opts.SystemdDir, SystemName+".service")
+}
+
+func (i *Installer) EnvFilePath() string {
+	return filepath.Join("/etc/default", SystemName)
+}
+
+// installedHashes returns the hashes of the installed files, the hash of a file not installed yet is empty
+func (i *Installer) installedHashes() (string, error) {
+	hashes := make([]string, 0, 3)
+	for _, path := range []string{i.BinPath(), i.ServiceFilePath(), i.EnvFilePath()} {
+		hash, err := utils.HashFile(i.path(path))
+		if err != nil && !os.IsNotExist(err) {
+			return "", err
+		}
+		hashes = append(hashes, hash)
+	}
+	return strings.Join(hashes, ","), nil
+}
+
+func (i *Installer) writeFile(path, content string, perm os.FileMode) error {
+	path = i.path(path)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, []byte(content), perm); err != nil {
+		return err
+	}
+	// WriteFile keeps the mode of the existing file
+	return os.Chmod(path, perm)
+}
+
+// path returns the path inside the target root
+func (i *Installer) path(path string) string {
+	return filepath.Join(i.opts.Root, path)
+}
+
+func detectInit() (string, error) {
+	if _, err := os.Stat("/bin/systemctl"); err == nil {
+		return InitSystemd, nil
+	}
+	if _, err := exec.LookPath("systemctl"); err == nil {
+		return InitSystemd, nil
+	}
+	if _, err := os.Stat("/sbin/openrc-run"); err == nil {
+		return InitOpenRC, nil
+	}
+	return "", fmt.Errorf("can not find systemd or openrc to use as a process supervisor for llmos")
+}
+
+func writable(dir string) bool {
+	f, err := os.CreateTemp(dir, ".llmos-ro-test-*")
+	if err != nil {
+		return false
+	}
+	f.Close()
+	_ = os.Remove(f.Name())
+	return true
+}
+
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
@@ -0,0 +1,131 @@
+package install
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/llmos-ai/llmos/pkg/utils/command/commandtest"
+)
+
+func newTestInstaller(t *testing.T, init string) (*Installer, *commandtest.Recorder) {
+	runner := &commandtest.Recorder{}
+	executable := filepath.Join(t.TempDir(), "llmos")
+	require.NoError(t, os.WriteFile(executable, []byte("binary"), 0755))
+
+	return &Installer{
+		opts: Options{
+			Root:       t.TempDir(),
+			BinDir:     defaultBinDir,
+			SystemdDir: defaultSystemdDir,
+			Init:       init,
+			Args:       []string{"--cluster-init", "--token=it's"},
+			Env: []string{
+				"LLMOS_TOKEN=secret",
+				"LLMOS_CONFIG_FILE=/etc/llmos/my config.yaml",
+				"HTTPS_PROXY=http://proxy:3128",
+				"no_proxy=localhost",
+				"HOME=/root",
+				"INSTALL_LLMOS_SKIP_START=false",
+			},
+		},
+		executable: executable,
+		Run:        runner.Run,
+	}, runner
+}
+
+func readFile(t *testing.T, i *Installer, path string) (string, os.FileMode) {
+	path = i.path(path)
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	return string(content), info.Mode().Perm()
+}
+
+func TestInstallSystemd(t *testing.T) {
+	i, runner := newTestInstaller(t, InitSystemd)
+	require.NoError(t, i.Install(context.Background()))
+
+	binary, mode := readFile(t, i, "/usr/local/bin/llmos")
+	assert.Equal(t, "binary", binary)
+	assert.Equal(t, os.FileMode(0755), mode)
+
+	service, _ := readFile(t, i, "/etc/systemd/system/llmos.service")
+	assert.Contains(t, service, "EnvironmentFile=-/etc/default/%N\n")
+	assert.Contains(t, service, "ExecStart=/usr/local/bin/llmos bootstrap \\\n"+
+		"\t'--cluster-init' \\\n\t'--token=it'\\''s'\n")
+
+	env, mode := readFile(t, i, "/etc/default/llmos")
+	assert.Equal(t, os.FileMode(0600), mode)
+	assert.Equal(t, "HTTPS_PROXY=http://proxy:3128\n"+
+		"LLMOS_CONFIG_FILE=\"/etc/llmos/my config.yaml\"\n"+
+		"LLMOS_TOKEN=secret\n"+
+		"no_proxy=localhost\n", env)
+
+	uninstall, mode := readFile(t, i, "/usr/local/bin/llmos-uninstall.sh")
+	assert.Equal(t, os.FileMode(0755), mode)
+	assert.Contains(t, uninstall, "/usr/local/bin/llmos uninstall --data-dir")
+
+	assert.Equal(t, []string{
+		"systemctl enable /etc/systemd/system/llmos.service",
+		"systemctl daemon-reload",
+		"systemctl restart --no-block llmos",
+	}, runner.Calls())
+
+	// nothing changed, the service is enabled but not restarted
+	require.NoError(t, i.Install(context.Background()))
+	assert.Equal(t, []string{
+		"systemctl enable /etc/systemd/system/llmos.service",
+		"systemctl daemon-reload",
+	}, runner.Calls())
+
+	i.opts.ForceRestart = true
+	require.NoError(t, i.Install(context.Background()))
+	assert.Contains(t, runner.Calls(), "systemctl restart --no-block llmos")
+}
+
+func TestInstallOpenRC(t *testing.T) {
+	i, runner := newTestInstaller(t, InitOpenRC)
+	i.opts.SkipStart = true
+	require.NoError(t, i.Install(context.Background()))
+
+	service, mode := readFile(t, i, "/etc/init.d/llmos")
+	assert.Equal(t, os.FileMode(0755), mode)
+	assert.Contains(t, service, `command="/usr/local/bin/llmos"`)
+	assert.Contains(t, service, "command_args=\"bootstrap \\\n\t'--cluster-init' \\\n")
+	assert.Contains(t, service, "if [ -f /etc/default/llmos ]; then . /etc/default/llmos; fi")
+
+	_, err := os.Stat(i.path("/etc/logrotate.d/llmos"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"rc-update add llmos default"}, runner.Calls())
+}
+
+func TestInstallSkipEnable(t *testing.T) {
+	i, runner := newTestInstaller(t, InitSystemd)
+	i.opts.SkipEnable = true
+
+	// the env file used to live next to the unit file
+	staleEnv := i.path("/etc/systemd/system/llmos.service.env")
+	require.NoError(t, os.MkdirAll(filepath.Dir(staleEnv), 0755))
+	require.NoError(t, os.WriteFile(staleEnv, []byte("LLMOS_TOKEN=old"), 0600))
+
+	require.NoError(t, i.Install(context.Background()))
+	assert.Empty(t, runner.Calls())
+	assert.NoFileExists(t, staleEnv)
+}
+
+func TestInstallUnreadableBinary(t *testing.T) {
+	i, runner := newTestInstaller(t, InitSystemd)
+	// the unreadable files must not be taken as identical
+	require.NoError(t, os.Remove(i.executable))
+	require.NoError(t, os.Mkdir(i.executable, 0755))
+	require.NoError(t, os.MkdirAll(i.path("/usr/local/bin/llmos"), 0755))
+
+	assert.ErrorContains(t, i.Install(context.Background()), "is a directory")
+	assert.Empty(t, runner.Calls())
+}
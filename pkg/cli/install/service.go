@@ -0,0 +1,142 @@
+package install
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	logFile = "/var/log/" + SystemName + ".log"
+
+	systemdService = `[Unit]
+Description=LLMOS Bootstrap
+Documentation=https://github.com/llmos-ai/llmos
+Wants=network-online.target
+After=network-online.target
+
+[Install]
+WantedBy=multi-user.target
+
+[Service]
+Type=oneshot
+EnvironmentFile=-/etc/default/%%N
+EnvironmentFile=-/etc/sysconfig/%%N
+KillMode=process
+# Having non-zero Limit*s causes performance problems due to accounting overhead
+# in the kernel. We recommend using cgroups to do container-local accounting.
+LimitNOFILE=1048576
+LimitNPROC=infinity
+LimitCORE=infinity
+TasksMax=infinity
+TimeoutStartSec=0
+ExecStart=%s bootstrap%s
+`
+
+	openRCService = `#!/sbin/openrc-run
+
+depend() {
+    after network-online
+    want cgroups
+}
+
+start_pre() {
+    rm -f /tmp/llmos.*
+}
+
+supervisor=supervise-daemon
+name=%s
+command="%s"
+command_args="bootstrap%s
+    >>%s 2>&1"
+
+output_log=%s
+error_log=%s
+
+pidfile="/var/run/%s.pid"
+respawn_delay=5
+respawn_max=0
+
+set -o allexport
+if [ -f /etc/environment ]; then . /etc/environment; fi
+if [ -f %s ]; then . %s; fi
+set +o allexport
+`
+
+	openRCLogrotate = `%s {
+	missingok
+	notifempty
+	copytruncate
+}
+`
+
+	uninstallScript = `#!/bin/sh
+set -x
+[ $(id -u) -eq 0 ] || exec sudo $0 $@
+
+LLMOS_DATA_DIR=${LLMOS_DATA_DIR:-/var/lib/llmos}
+
+if command -v systemctl; then
+    systemctl disable %[1]s
+    systemctl reset-failed %[1]s
+    systemctl daemon-reload
+fi
+if command -v rc-update; then
+    rc-update delete %[1]s default
+fi
+
+rm -f %[2]s
+rm -f %[3]s
+
+remove_uninstall() {
+    rm -f %[4]s
+}
+trap remove_uninstall EXIT
+
+%[5]s uninstall --data-dir "${LLMOS_DATA_DIR}"
+rm -rf /var/lib/rook/*llmos*
+rm -f %[5]s
+`
+)
+
+func (i *Installer) systemdService() string {
+	return fmt.Sprintf(systemdService, i.BinPath(), quoteIndent(i.opts.Args))
+}
+
+func (i *Installer) openRCService() string {
+	envFile := i.EnvFilePath()
+	args := strings.ReplaceAll(quoteIndent(i.opts.Args), `"`, `\"`)
+	return fmt.Sprintf(openRCService, SystemName, i.BinPath(), args, logFile, logFile, logFile,
+		SystemName, envFile, envFile)
+}
+
+func (i *Installer) uninstallScript() string {
+	return fmt.Sprintf(uninstallScript, SystemName, i.ServiceFilePath(), i.EnvFilePath(),
+		i.BinPath()+"-uninstall.sh", i.BinPath())
+}
+
+// quote wraps the argument in single quotes, it is understood by both systemd and the shell
+func quote(arg string) string {
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
+
+// quoteIndent renders the quoted arguments one per line with the trailing line continuation
+func quoteIndent(args []string) string {
+	if len(args) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString(" \\\n")
+	for _, arg := range args {
+		fmt.Fprintf(&b, "\t%s \\\n", quote(arg))
+	}
+	return strings.TrimSuffix(b.String(), " \\\n")
+}
+
+// quoteEnv quotes the environment value so the file can be read by both systemd and the shell
+func quoteEnv(value string) string {
+	if value != "" && !strings.ContainsAny(value, " \t\"'\\$`#;&|<>(){}*?!~") {
+		return value
+	}
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, `$`, `\$`, "`", "\\`")
+	return `"` + r.Replace(value) + `"`
+}
@@ -0,0 +1,344 @@
+package logs
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/llmos-ai/llmos/pkg/applyinator"
+)
+
+const (
+	defaultPollInterval = 500 * time.Millisecond
+	periodicPrefix      = "periodic-"
+)
+
+type Options struct {
+	// Dir holds the instruction logs of the plans in a directory per plan checksum
+	Dir string
+	// Checksum selects the plan, defaults to the last applied plan
+	Checksum string
+	// Instruction is the index, name or log file of the instruction, the instructions are listed if empty
+	Instruction string
+	// Attempt selects the attempt of the instruction, defaults to the last attempt
+	Attempt int
+	// Follow streams the lines appended to the log until the attempt finished or the context is canceled
+	Follow       bool
+	PollInterval time.Duration
+}
+
+// Instruction is the log of an instruction of the plan
+type Instruction struct {
+	Name     string
+	Path     string
+	Attempts []applyinator.LogAttempt
+	ModTime  time.Time
+}
+
+// Run prints the instructions of the plan or the log of the selected instruction attempt
+func Run(ctx context.Context, w io.Writer, opts Options) error {
+	planDir, err := PlanDir(opts.Dir, opts.Checksum)
+	if err != nil {
+		return err
+	}
+	logrus.Debugf("Reading instruction logs of plan %s", filepath.Base(planDir))
+
+	if opts.Instruction == "" {
+		if opts.Follow || opts.Attempt != 0 {
+			return fmt.Errorf("--follow and --attempt require an instruction")
+		}
+		instructions, err := ListInstructions(planDir)
+		if err != nil {
+			return err
+		}
+		return PrintInstructions(w, instructions)
+	}
+
+	path, err := FindInstruction(planDir, opts.Instruction)
+	if err != nil {
+		return err
+	}
+	return printLog(ctx, w, path, opts)
+}
+
+// PlanDir returns the log directory of the plan with the checksum, the last applied plan if it is empty
+func PlanDir(dir, checksum string) (string, error) {
+	if checksum != "" {
+		planDir := filepath.Join(dir, checksum)
+		if _, err := os.Stat(planDir); err != nil {
+			return "", fmt.Errorf("no instruction logs of plan %s: %w", checksum, err)
+		}
+		return planDir, nil
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+	var latest string
+	var latestModTime time.Time
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return "", err
+		}
+		// the applyinator touches the log directory whenever it applies the plan
+		if latest == "" || info.ModTime().After(latestModTime) {
+			latest, latestModTime = entry.Name(), info.ModTime()
+		}
+	}
+	if latest == "" {
+		return "", fmt.Errorf("no instruction logs found in %s", dir)
+	}
+	return filepath.Join(dir, latest), nil
+}
+
+// ListInstructions reads the instruction logs of the plan, the one-time instructions come first in order
+func ListInstructions(planDir string) ([]Instruction, error) {
+	files, err := filepath.Glob(filepath.Join(planDir, "*"+applyinator.InstructionLogSuffix))
+	if err != nil {
+		return nil, err
+	}
+
+	instructions := make([]Instruction, 0, len(files))
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			return nil, err
+		}
+		attempts, err := applyinator.ReadInstructionLog(file)
+		if err != nil {
+			return nil, err
+		}
+		instructions = append(instructions, Instruction{
+			Name:     strings.TrimSuffix(filepath.Base(file), applyinator.InstructionLogSuffix),
+			Path:     file,
+			Attempts: attempts,
+			ModTime:  info.ModTime(),
+		})
+	}
+	sort.SliceStable(instructions, func(i, j int) bool {
+		return lessInstruction(instructions[i].Name, instructions[j].Name)
+	})
+	return instructions, nil
+}
+
+func lessInstruction(a, b string) bool {
+	aPeriodic, bPeriodic := strings.HasPrefix(a, periodicPrefix), strings.HasPrefix(b, periodicPrefix)
+	if aPeriodic != bPeriodic {
+		return bPeriodic
+	}
+	aIndex, bIndex := instructionIndex(a), instructionIndex(b)
+	if len(aIndex) != len(bIndex) {
+		return len(aIndex) < len(bIndex)
+	}
+	if aIndex != bIndex {
+		return aIndex < bIndex
+	}
+	return a < b
+}
+
+func instructionIndex(name string) string {
+	index, _, _ := strings.Cut(strings.TrimPrefix(name, periodicPrefix), "-")
+	return index
+}
+
+func PrintInstructions(w io.Writer, instructions []Instruction) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "INSTRUCTION\tATTEMPTS\tEXIT CODE\tUPDATED")
+	for _, instruction := range instructions {
+		exitCode := "-"
+		if n := len(instruction.Attempts); n > 0 && instruction.Attempts[n-1].ExitCode != nil {
+			exitCode = fmt.Sprintf("%d", *instruction.Attempts[n-1].ExitCode)
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\n", instruction.Name, len(instruction.Attempts), exitCode,
+			instruction.ModTime.UTC().Format(time.RFC3339))
+	}
+	return tw.Flush()
+}
+
+// FindInstruction returns the log file of the instruction, it matches the log file name, the index and
+// the name of the instruction
+func FindInstruction(planDir, instruction string) (string, error) {
+	instructions, err := ListInstructions(planDir)
+	if err != nil {
+		return "", err
+	}
+
+	var matches []string
+	for _, i := range instructions {
+		if i.Name == instruction || i.Name+applyinator.InstructionLogSuffix == instruction {
+			return i.Path, nil
+		}
+		// the periodic instructions are only matched by their name, their index is prefixed with periodic-
+		periodic := strings.HasPrefix(i.Name, periodicPrefix)
+		index, name, _ := strings.Cut(strings.TrimPrefix(i.Name, periodicPrefix), "-")
+		if (!periodic && index == instruction) || name == instruction {
+			matches = append(matches, i.Name)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("no log of instruction %s found in %s", instruction, planDir)
+	case 1:
+		return filepath.Join(planDir, matches[0]+applyinator.InstructionLogSuffix), nil
+	default:
+		return "", fmt.Errorf("instruction %s is ambiguous, use one of %s", instruction, strings.Join(matches, ", "))
+	}
+}
+
+// printLog prints the selected attempt, the follow continues with the lines appended to the current log
+// file and reopens it once it is rotated
+func printLog(ctx context.Context, w io.Writer, path string, opts Options) error {
+	files := applyinator.InstructionLogFiles(path)
+	var attempts []applyinator.LogAttempt
+	for _, file := range files[:len(files)-1] {
+		if _, err := readLines(file, func(line string) {
+			attempts = applyinator.ParseLogLine(attempts, line)
+		}); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	offset, err := readLines(path, func(line string) {
+		attempts = applyinator.ParseLogLine(attempts, line)
+	})
+	if err != nil {
+		return err
+	}
+	if len(attempts) == 0 {
+		return fmt.Errorf("no attempts found in %s", path)
+	}
+
+	selected := attempts[len(attempts)-1]
+	if opts.Attempt != 0 {
+		found := false
+		for _, attempt := range attempts {
+			if attempt.Attempt == opts.Attempt {
+				selected, found = attempt, true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("attempt %d of %s not found, the log holds the attempts %d to %d", opts.Attempt,
+				filepath.Base(path), attempts[0].Attempt, attempts[len(attempts)-1].Attempt)
+		}
+	}
+	for _, line := range selected.Lines {
+		fmt.Fprintln(w, line)
+	}
+
+	if !opts.Follow || (opts.Attempt != 0 && selected.ExitCode != nil) {
+		return nil
+	}
+	return follow(ctx, w, path, offset, opts, attempts)
+}
+
+// follow prints the lines appended after the offset, it returns once the selected attempt finished
+func follow(ctx context.Context, w io.Writer, path string, offset int64, opts Options,
+	attempts []applyinator.LogAttempt) error {
+	interval := opts.PollInterval
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	if _, err = f.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+
+	reader := bufio.NewReader(f)
+	var partial string
+	for {
+		line, err := reader.ReadString('\n')
+		partial += line
+		if err == nil {
+			line, partial = strings.TrimSuffix(partial, "\n"), ""
+			attempts = applyinator.ParseLogLine(attempts, line)
+			last := attempts[len(attempts)-1]
+			if opts.Attempt == 0 || last.Attempt == opts.Attempt {
+				fmt.Fprintln(w, line)
+			}
+			if opts.Attempt != 0 && last.Attempt == opts.Attempt && last.ExitCode != nil {
+				return nil
+			}
+			continue
+		} else if err != io.EOF {
+			return err
+		}
+
+		// the log is only rotated before an execution, the rotated file is read to its end first
+		if rotated, err := isRotated(f, path); err != nil {
+			return err
+		} else if rotated {
+			_ = f.Close()
+			if f, err = os.Open(path); err != nil {
+				return err
+			}
+			reader.Reset(f)
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(interval):
+		}
+	}
+}
+
+func isRotated(f *os.File, path string) (bool, error) {
+	current, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	opened, err := f.Stat()
+	if err != nil {
+		return false, err
+	}
+	return !os.SameFile(opened, current), nil
+}
+
+// readLines calls the fn for every complete line of the file, it returns the offset following the last
+// complete line
+func readLines(file string, fn func(line string)) (int64, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	var offset int64
+	reader := bufio.NewReader(f)
+	for {
+		line, err := reader.ReadString('\n')
+		if err == io.EOF {
+			return offset, nil
+		} else if err != nil {
+			return offset, err
+		}
+		offset += int64(len(line))
+		fn(strings.TrimSuffix(line, "\n"))
+	}
+}
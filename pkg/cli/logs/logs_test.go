@@ -0,0 +1,141 @@
+package logs
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const installLog = `2024-01-01T00:00:00.000000000Z attempt 1 started: /bin/sh -c install
+2024-01-01T00:00:01.000000000Z stderr failed
+2024-01-01T00:00:02.000000000Z attempt 1 finished: exit code 1
+2024-01-01T00:00:03.000000000Z attempt 2 started: /bin/sh -c install
+2024-01-01T00:00:04.000000000Z stdout installed
+2024-01-01T00:00:05.000000000Z attempt 2 finished: exit code 0
+`
+
+func writeLogs(t *testing.T) string {
+	dir := t.TempDir()
+	old, latest := filepath.Join(dir, "old"), filepath.Join(dir, "latest")
+	for file, content := range map[string]string{
+		filepath.Join(old, "0-install.log"):           "",
+		filepath.Join(latest, "0-install.log"):        installLog,
+		filepath.Join(latest, "10-verify.log"):        "2024-01-01T00:00:06.000000000Z attempt 1 started: /bin/true \n",
+		filepath.Join(latest, "periodic-0-check.log"): "",
+	} {
+		require.NoError(t, os.MkdirAll(filepath.Dir(file), 0700))
+		require.NoError(t, os.WriteFile(file, []byte(content), 0600))
+	}
+	past := time.Now().Add(-time.Hour)
+	require.NoError(t, os.Chtimes(old, past, past))
+	return dir
+}
+
+func TestListInstructions(t *testing.T) {
+	dir := writeLogs(t)
+	out := &bytes.Buffer{}
+	require.NoError(t, Run(context.Background(), out, Options{Dir: dir}))
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 4)
+	assert.Equal(t, []string{"INSTRUCTION", "ATTEMPTS", "EXIT", "CODE", "UPDATED"}, strings.Fields(lines[0]))
+	assert.Equal(t, []string{"0-install", "2", "0"}, strings.Fields(lines[1])[:3])
+	assert.Equal(t, []string{"10-verify", "1", "-"}, strings.Fields(lines[2])[:3])
+	assert.Equal(t, []string{"periodic-0-check", "0", "-"}, strings.Fields(lines[3])[:3])
+}
+
+func TestFindInstruction(t *testing.T) {
+	planDir := filepath.Join(writeLogs(t), "latest")
+	for _, instruction := range []string{"0", "install", "0-install", "0-install.log"} {
+		path, err := FindInstruction(planDir, instruction)
+		require.NoError(t, err, instruction)
+		assert.Equal(t, filepath.Join(planDir, "0-install.log"), path)
+	}
+	path, err := FindInstruction(planDir, "check")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(planDir, "periodic-0-check.log"), path)
+
+	_, err = FindInstruction(planDir, "missing")
+	assert.Error(t, err)
+}
+
+func TestPrintAttempt(t *testing.T) {
+	dir := writeLogs(t)
+	out := &bytes.Buffer{}
+	require.NoError(t, Run(context.Background(), out, Options{Dir: dir, Instruction: "install"}))
+	assert.Equal(t, strings.Join(strings.Split(installLog, "\n")[3:], "\n"), out.String())
+
+	out.Reset()
+	require.NoError(t, Run(context.Background(), out, Options{Dir: dir, Instruction: "install", Attempt: 1}))
+	assert.Equal(t, strings.Join(strings.Split(installLog, "\n")[:3], "\n")+"\n", out.String())
+
+	err := Run(context.Background(), out, Options{Dir: dir, Instruction: "install", Attempt: 3})
+	assert.ErrorContains(t, err, "attempt 3 of 0-install.log not found")
+
+	out.Reset()
+	require.NoError(t, Run(context.Background(), out, Options{Dir: dir, Checksum: "old"}))
+	assert.Contains(t, out.String(), "0-install")
+}
+
+type syncBuffer struct {
+	mu sync.Mutex
+	b  bytes.Buffer
+}
+
+func (s *syncBuffer) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.b.Write(p)
+}
+
+func (s *syncBuffer) String() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.b.String()
+}
+
+func TestFollow(t *testing.T) {
+	dir := writeLogs(t)
+	path := filepath.Join(dir, "latest", "10-verify.log")
+	out := &syncBuffer{}
+	done := make(chan error)
+	go func() {
+		done <- Run(context.Background(), out, Options{
+			Dir:          dir,
+			Instruction:  "verify",
+			Attempt:      1,
+			Follow:       true,
+			PollInterval: 10 * time.Millisecond,
+		})
+	}()
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0600)
+	require.NoError(t, err)
+	_, err = f.WriteString("2024-01-01T00:00:07.000000000Z stdout verifying\n")
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	require.Eventually(t, func() bool {
+		return strings.Contains(out.String(), "stdout verifying")
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// the log is rotated before the next execution
+	require.NoError(t, os.Rename(path, path+".1"))
+	require.NoError(t, os.WriteFile(path, []byte("2024-01-01T00:00:08.000000000Z stdout rotated\n"+
+		"2024-01-01T00:00:09.000000000Z attempt 1 finished: exit code 0\n"), 0600))
+
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("follow did not return once the attempt finished")
+	}
+	assert.True(t, strings.HasSuffix(out.String(), "stdout rotated\n2024-01-01T00:00:09.000000000Z attempt 1 finished: exit code 0\n"))
+}
@@ -0,0 +1,53 @@
+package ping
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-retryablehttp"
+	"github.com/sirupsen/logrus"
+)
+
+// Ping checks if the k8s runtime supervisor of the server URL is ready by requesting its /ping endpoint
+func Ping(ctx context.Context, serverURL string) error {
+	retryClient := retryablehttp.NewClient()
+	retryClient.RetryMax = 3
+	retryClient.HTTPClient = &http.Client{
+		Timeout: 5 * time.Second,
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+		},
+	}
+
+	url := fmt.Sprintf("%s/ping", strings.TrimSuffix(serverURL, "/"))
+	req, err := retryablehttp.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := retryClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to check server URL: %v", err)
+	}
+	defer func() {
+		err = resp.Body.Close()
+		if err != nil {
+			logrus.Fatal(err)
+		}
+	}()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to validate server url: %v", err)
+	}
+
+	if string(body) != "pong" || resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("server url is not ready: %s", string(body))
+	}
+
+	return nil
+}
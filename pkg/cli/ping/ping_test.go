@@ -0,0 +1,33 @@
+package ping
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPing(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/ping" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte("pong"))
+	}))
+	defer server.Close()
+
+	assert.NoError(t, Ping(context.Background(), server.URL))
+	assert.NoError(t, Ping(context.Background(), server.URL+"/"))
+}
+
+func TestPingNotReady(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("starting"))
+	}))
+	defer server.Close()
+
+	assert.ErrorContains(t, Ping(context.Background(), server.URL), "server url is not ready: starting")
+}
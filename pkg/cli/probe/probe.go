@@ -77,6 +77,27 @@ func AllProbes(runtime config.Runtime) map[string]prober.Probe {
 	return replaceRuntimeForProbes(probes, runtime)
 }
 
+// WithConfigProbes returns the probes with the user-defined probes of the config applying to the node
+// role, a user-defined probe replaces the probe of the same name and a disabled probe is removed
+func WithConfigProbes(probes map[string]prober.Probe, cfg *config.Config) map[string]prober.Probe {
+	result := make(map[string]prober.Probe, len(probes)+len(cfg.Probes))
+	for name, p := range probes {
+		result[name] = p
+	}
+
+	for name, p := range cfg.Probes {
+		if !p.AppliesTo(&cfg.RuntimeConfig) {
+			continue
+		}
+		if p.Disable {
+			delete(result, name)
+			continue
+		}
+		result[name] = p.Probe
+	}
+	return result
+}
+
 func replaceRuntimeForProbes(probes map[string]prober.Probe, runtime config.Runtime) map[string]prober.Probe {
 	result := map[string]prober.Probe{}
 	for k, v := range probes {
@@ -0,0 +1,45 @@
+package probe
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/llmos-ai/llmos/pkg/applyinator/prober"
+	"github.com/llmos-ai/llmos/pkg/bootstrap/config"
+)
+
+func TestWithConfigProbes(t *testing.T) {
+	gateway := prober.Probe{HTTPGetAction: prober.HTTPGetAction{URL: "http://127.0.0.1:8080/healthz"}}
+	kubelet := prober.Probe{TimeoutSeconds: 10, HTTPGetAction: prober.HTTPGetAction{URL: "http://127.0.0.1:10248/healthz"}}
+	cfg := &config.Config{
+		RuntimeConfig: config.RuntimeConfig{Role: config.AgentRole},
+		Probes: map[string]config.ProbeConfig{
+			"inference-gateway": {Probe: gateway, Roles: []string{config.ProbeRoleWorker}},
+			"kubelet":           {Probe: kubelet},
+			"kube-scheduler":    {Disable: true},
+			"kube-apiserver":    {Disable: true, Roles: []string{config.ProbeRoleControlPlane}},
+			"server-only":       {Probe: gateway, Roles: []string{string(config.ServerRole)}},
+		},
+	}
+
+	probes := WithConfigProbes(AllProbes(config.RuntimeK3S), cfg)
+	assert.ElementsMatch(t, []string{"inference-gateway", "kubelet", "kube-apiserver", "kube-controller-manager"},
+		keys(probes))
+	assert.Equal(t, gateway, probes["inference-gateway"])
+	assert.Equal(t, kubelet, probes["kubelet"], "a user-defined probe replaces the built-in probe")
+	assert.Equal(t, "https://127.0.0.1:6443/readyz", probes["kube-apiserver"].HTTPGetAction.URL,
+		"the probe is only disabled on the control plane nodes")
+
+	// the built-in probes are not changed
+	assert.Equal(t, "http://127.0.0.1:10248/healthz", AllProbes(config.RuntimeK3S)["kubelet"].HTTPGetAction.URL)
+	assert.Equal(t, 5, AllProbes(config.RuntimeK3S)["kubelet"].TimeoutSeconds)
+}
+
+func keys(probes map[string]prober.Probe) []string {
+	result := make([]string, 0, len(probes))
+	for name := range probes {
+		result = append(result, name)
+	}
+	return result
+}
@@ -4,7 +4,11 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -13,28 +17,69 @@ import (
 	"github.com/llmos-ai/llmos/pkg/applyinator/prober"
 )
 
-func RunProbes(_ context.Context, planFile string, interval time.Duration) error {
-	f, err := os.Open(planFile)
+const (
+	// TimeoutExitCode is the exit code of the probe command when the probes are not healthy before
+	// the timeout, the same as the timeout command
+	TimeoutExitCode = 124
+
+	maxTableColumnLength = 60
+)
+
+// TimeoutError is returned when the probes are not healthy before the timeout
+type TimeoutError struct {
+	Timeout time.Duration
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("probes are not healthy after %s", e.Timeout)
+}
+
+func (e *TimeoutError) ExitCode() int {
+	return TimeoutExitCode
+}
+
+// Options configures a probe run, the probes run until they are healthy or the timeout is hit,
+// a zero timeout waits forever. The plan is only loaded if it is signed by a trusted key when the
+// verifier is set
+type Options struct {
+	PlanFile string
+	Interval time.Duration
+	Timeout  time.Duration
+	Verifier *applyinator.Verifier
+}
+
+// Report is the final status of the probes of a run
+type Report struct {
+	Healthy  bool          `json:"healthy"`
+	TimedOut bool          `json:"timedOut,omitempty"`
+	Probes   []ProbeReport `json:"probes"`
+}
+
+type ProbeReport struct {
+	Name string `json:"name"`
+	prober.ProbeStatus
+}
+
+// RunProbes runs the probes of the plan file until all of them are healthy, the report holds the last
+// status of every probe and is also returned with the TimeoutError
+func RunProbes(ctx context.Context, opts Options) (Report, error) {
+	plan, err := readPlan(opts.PlanFile, opts.Verifier)
 	if err != nil {
-		return fmt.Errorf("opening plan %s: %w", planFile, err)
+		return Report{}, err
 	}
-	defer func() {
-		err = f.Close()
-		if err != nil {
-			logrus.Fatalln(err)
-		}
-	}()
 
-	plan := &applyinator.Plan{}
-	if err = json.NewDecoder(f).Decode(plan); err != nil {
-		return err
+	if len(plan.Probes) == 0 {
+		logrus.Infof("No probes defined in %s", opts.PlanFile)
+		return Report{Healthy: true}, nil
 	}
+	logrus.Infof("Running probes defined in %s", opts.PlanFile)
 
-	if len(plan.Probes) == 0 {
-		logrus.Infof("No probes defined in %s", planFile)
-		return nil
+	runCtx := ctx
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
 	}
-	logrus.Infof("Running probes defined in %s", planFile)
 
 	probeStatuses := make(map[string]prober.ProbeStatus)
 	initial := true
@@ -46,32 +91,115 @@ func RunProbes(_ context.Context, planFile string, interval time.Duration) error
 		}
 
 		allGood := true
-		prober.DoProbes(plan.Probes, newProbeStatuses, initial)
+		prober.DoProbes(runCtx, plan.Probes, newProbeStatuses, initial)
 
-		for probeName, probeStatus := range probeStatuses {
+		for probeName := range plan.Probes {
+			probeStatus := newProbeStatuses[probeName]
 			if !probeStatus.Healthy {
 				allGood = false
 			}
 
 			oldProbeStatus, ok := probeStatuses[probeName]
-			if !ok || oldProbeStatus.Healthy != probeStatus.Healthy {
+			if _, ran := newProbeStatuses[probeName]; ran && (!ok || oldProbeStatus.Healthy != probeStatus.Healthy) {
 				if probeStatus.Healthy {
 					logrus.Infof("Probe [%s] is healthy", probeName)
 				} else {
-					logrus.Infof("Probe [%s] is unhealthy", probeName)
+					logrus.Infof("Probe [%s] is unhealthy: %s", probeName, probeStatus.LastError)
 				}
 			}
 		}
+		probeStatuses = newProbeStatuses
 
 		if allGood {
 			logrus.Info("All probes are healthy")
-			break
+			return newReport(plan.Probes, probeStatuses), nil
 		}
 
-		probeStatuses = newProbeStatuses
 		initial = false
-		time.Sleep(interval)
+		select {
+		case <-runCtx.Done():
+			report := newReport(plan.Probes, probeStatuses)
+			if ctx.Err() != nil {
+				return report, ctx.Err()
+			}
+			report.TimedOut = true
+			return report, &TimeoutError{Timeout: opts.Timeout}
+		case <-time.After(opts.Interval):
+		}
 	}
+}
 
-	return nil
+func newReport(probes map[string]prober.Probe, statuses map[string]prober.ProbeStatus) Report {
+	report := Report{Healthy: true}
+	for name := range probes {
+		status := statuses[name]
+		if !status.Healthy {
+			report.Healthy = false
+		}
+		report.Probes = append(report.Probes, ProbeReport{Name: name, ProbeStatus: status})
+	}
+	sort.Slice(report.Probes, func(i, j int) bool {
+		return report.Probes[i].Name < report.Probes[j].Name
+	})
+	return report
+}
+
+// Print writes the report as a table, or as JSON if the output format is json
+func (r Report) Print(w io.Writer, output string) error {
+	switch output {
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(r)
+	case "", "table":
+	default:
+		return fmt.Errorf("unsupported output format %s, must be table or json", output)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tHEALTHY\tSUCCESS\tFAILURE\tLAST ERROR\tLAST OUTPUT")
+	for _, p := range r.Probes {
+		fmt.Fprintf(tw, "%s\t%t\t%d\t%d\t%s\t%s\n", p.Name, p.Healthy, p.SuccessCount, p.FailureCount,
+			tableColumn(p.LastError), tableColumn(p.LastOutput))
+	}
+	return tw.Flush()
+}
+
+// tableColumn keeps the column on a single line of a bounded length
+func tableColumn(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if len(s) > maxTableColumnLength {
+		s = s[:maxTableColumnLength] + "..."
+	}
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+func readPlan(planFile string, verifier *applyinator.Verifier) (*applyinator.Plan, error) {
+	if verifier != nil {
+		plan, err := verifier.ReadSignedPlan(planFile)
+		if err != nil {
+			return nil, err
+		}
+		return &plan, nil
+	}
+
+	f, err := os.Open(planFile)
+	if err != nil {
+		return nil, fmt.Errorf("opening plan %s: %w", planFile, err)
+	}
+	defer func() {
+		err = f.Close()
+		if err != nil {
+			logrus.Fatalln(err)
+		}
+	}()
+
+	plan := &applyinator.Plan{}
+	if err = json.NewDecoder(f).Decode(plan); err != nil {
+		return nil, err
+	}
+	return plan, nil
 }
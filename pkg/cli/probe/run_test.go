@@ -0,0 +1,151 @@
+package probe
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/llmos-ai/llmos/pkg/applyinator"
+	"github.com/llmos-ai/llmos/pkg/applyinator/prober"
+)
+
+func writePlan(t *testing.T, probes map[string]prober.Probe) string {
+	data, err := json.Marshal(applyinator.Plan{Probes: probes})
+	require.NoError(t, err)
+	planFile := filepath.Join(t.TempDir(), "plan.json")
+	require.NoError(t, os.WriteFile(planFile, data, 0600))
+	return planFile
+}
+
+func TestRunProbes(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/unhealthy" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	planFile := writePlan(t, map[string]prober.Probe{
+		"healthy": {TimeoutSeconds: 5, HTTPGetAction: prober.HTTPGetAction{URL: server.URL + "/healthy"}},
+	})
+	report, err := RunProbes(context.Background(), Options{PlanFile: planFile, Interval: 10 * time.Millisecond})
+	require.NoError(t, err)
+	assert.True(t, report.Healthy)
+	require.Len(t, report.Probes, 1)
+	assert.Equal(t, "HTTP 200: ok", report.Probes[0].LastOutput)
+
+	planFile = writePlan(t, map[string]prober.Probe{
+		"healthy":   {TimeoutSeconds: 5, HTTPGetAction: prober.HTTPGetAction{URL: server.URL + "/healthy"}},
+		"unhealthy": {TimeoutSeconds: 5, HTTPGetAction: prober.HTTPGetAction{URL: server.URL + "/unhealthy"}},
+	})
+	report, err = RunProbes(context.Background(), Options{
+		PlanFile: planFile,
+		Interval: 10 * time.Millisecond,
+		Timeout:  200 * time.Millisecond,
+	})
+	timeoutErr := &TimeoutError{}
+	require.True(t, errors.As(err, &timeoutErr), err)
+	assert.Equal(t, TimeoutExitCode, timeoutErr.ExitCode())
+	assert.False(t, report.Healthy)
+	assert.True(t, report.TimedOut)
+	require.Len(t, report.Probes, 2)
+	assert.Equal(t, "healthy", report.Probes[0].Name)
+	assert.True(t, report.Probes[0].Healthy)
+	assert.Equal(t, "unhealthy", report.Probes[1].Name)
+	assert.Greater(t, report.Probes[1].FailureCount, 0)
+	assert.Contains(t, report.Probes[1].LastError, "HTTP 503")
+}
+
+func TestRunProbesCanceled(t *testing.T) {
+	planFile := writePlan(t, map[string]prober.Probe{
+		"delayed": {InitialDelaySeconds: 60, HTTPGetAction: prober.HTTPGetAction{URL: "http://127.0.0.1:1"}},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	report, err := RunProbes(ctx, Options{PlanFile: planFile, Interval: time.Second, Timeout: time.Minute})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.False(t, report.TimedOut)
+	require.Len(t, report.Probes, 1)
+	assert.False(t, report.Probes[0].Healthy)
+}
+
+func TestReportPrint(t *testing.T) {
+	report := Report{Probes: []ProbeReport{
+		{Name: "kubelet", ProbeStatus: prober.ProbeStatus{Healthy: true, SuccessCount: 1, LastOutput: "HTTP 200: ok"}},
+		{Name: "kube-apiserver", ProbeStatus: prober.ProbeStatus{FailureCount: 2, LastError: "connection\nrefused"}},
+	}}
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, report.Print(buf, "table"))
+	assert.Equal(t, `NAME            HEALTHY  SUCCESS  FAILURE  LAST ERROR          LAST OUTPUT
+kubelet         true     1        0        -                   HTTP 200: ok
+kube-apiserver  false    0        2        connection refused  -
+`, buf.String())
+
+	buf.Reset()
+	require.NoError(t, report.Print(buf, "json"))
+	decoded := Report{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	assert.Equal(t, report, decoded)
+
+	assert.Error(t, report.Print(buf, "yaml"))
+}
+
+func TestRunProbesFromRedactedPlan(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(tokenFile, []byte("Bearer abc"), 0600))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer abc" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	probes := map[string]prober.Probe{
+		"api": {TimeoutSeconds: 5, HTTPGetAction: prober.HTTPGetAction{
+			URL:     server.URL + "/readyz",
+			Headers: []prober.HTTPHeader{{Name: "Authorization", ValueFile: tokenFile}},
+		}},
+		"exec": {TimeoutSeconds: 5, ExecAction: &prober.ExecAction{
+			Command: []string{"sh", "-c", `test "$(cat "$1")" = "Bearer abc"`, "sh", tokenFile},
+		}},
+	}
+	for _, probe := range probes {
+		require.NoError(t, probe.Validate())
+	}
+	// the plan is persisted redacted like the bootstrap and the applyinator do
+	redacted := applyinator.RedactPlan(applyinator.Plan{
+		OneTimeInstructions: []applyinator.OneTimeInstruction{{CommonInstruction: applyinator.CommonInstruction{
+			Args: []string{"--token", "abc"},
+		}}},
+		Probes: probes,
+	})
+	data, err := json.Marshal(redacted)
+	require.NoError(t, err)
+	planFile := filepath.Join(t.TempDir(), "plan.json")
+	require.NoError(t, os.WriteFile(planFile, data, 0600))
+
+	report, err := RunProbes(context.Background(), Options{
+		PlanFile: planFile,
+		Interval: 10 * time.Millisecond,
+		Timeout:  5 * time.Second,
+	})
+	require.NoError(t, err)
+	assert.True(t, report.Healthy)
+	require.Len(t, report.Probes, 2)
+}
@@ -7,6 +7,8 @@ import (
 	"time"
 
 	"github.com/sirupsen/logrus"
+
+	"github.com/llmos-ai/llmos/pkg/utils/redact"
 )
 
 func Retry(ctx context.Context, interval time.Duration, args []string) error {
@@ -17,7 +19,7 @@ func Retry(ctx context.Context, interval time.Duration, args []string) error {
 		cmd.Stderr = os.Stderr
 		err := cmd.Run()
 		if err != nil {
-			logrus.Errorf("will retry failed command %v: %v", args, err)
+			logrus.Errorf("will retry failed command %v: %v", redact.LogArgs(args), err)
 			select {
 			case <-time.After(interval):
 				continue
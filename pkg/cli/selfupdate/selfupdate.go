@@ -0,0 +1,216 @@
+package selfupdate
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/llmos-ai/llmos/pkg/cli/install"
+	"github.com/llmos-ai/llmos/pkg/utils"
+	"github.com/llmos-ai/llmos/pkg/utils/command"
+	cliversion "github.com/llmos-ai/llmos/pkg/version"
+)
+
+const (
+	DefaultBaseURL = "https://github.com/llmos-ai/llmos/releases"
+
+	checksumsFile = "checksums.txt"
+)
+
+type Options struct {
+	// BaseURL serves <base>/latest redirecting to the latest release and the assets at <base>/download/<version>/
+	BaseURL   string
+	Version   string
+	Binary    string
+	NoRestart bool
+}
+
+// Updater replaces the running llmos binary with the release binary after verifying its sha256 checksum
+type Updater struct {
+	opts   Options
+	client *http.Client
+	Run    command.Runner
+}
+
+func New(opts Options) (*Updater, error) {
+	if opts.BaseURL == "" {
+		opts.BaseURL = DefaultBaseURL
+	}
+	opts.BaseURL = strings.TrimSuffix(opts.BaseURL, "/")
+
+	if opts.Binary == "" {
+		executable, err := os.Executable()
+		if err != nil {
+			return nil, fmt.Errorf("finding the running llmos binary: %w", err)
+		}
+		if opts.Binary, err = filepath.EvalSymlinks(executable); err != nil {
+			return nil, err
+		}
+	}
+
+	return &Updater{
+		opts:   opts,
+		client: &http.Client{Timeout: 5 * time.Minute},
+		Run:    command.Run,
+	}, nil
+}
+
+// Update installs the requested release, it returns the installed version and whether the binary was replaced
+func (u *Updater) Update(ctx context.Context) (string, bool, error) {
+	version, err := u.releaseVersion(ctx)
+	if err != nil {
+		return "", false, err
+	}
+
+	asset := AssetName(runtime.GOOS, runtime.GOARCH)
+	expected, err := u.expectedHash(ctx, version, asset)
+	if err != nil {
+		return version, false, err
+	}
+
+	// a missing binary is installed
+	installed, err := utils.HashFile(u.opts.Binary)
+	if err != nil && !os.IsNotExist(err) {
+		return version, false, err
+	}
+	if installed == expected {
+		logrus.Infof("llmos %s is already installed at %s", version, u.opts.Binary)
+		return version, false, nil
+	}
+
+	logrus.Infof("Updating llmos from %s to %s", cliversion.Version, version)
+	if err = u.replaceBinary(ctx, version, asset, expected); err != nil {
+		return version, false, err
+	}
+
+	if !u.opts.NoRestart {
+		if err = u.restartService(ctx); err != nil {
+			return version, true, err
+		}
+	}
+	return version, true, nil
+}
+
+// releaseVersion returns the requested version or resolves the latest release by its redirect like install.sh
+func (u *Updater) releaseVersion(ctx context.Context) (string, error) {
+	if u.opts.Version != "" {
+		return u.opts.Version, nil
+	}
+
+	url := u.opts.BaseURL + "/latest"
+	resp, err := u.get(ctx, url)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+
+	version := path.Base(resp.Request.URL.Path)
+	if !strings.HasPrefix(version, "v") {
+		return "", fmt.Errorf("invalid llmos version %s resolved from %s", version, url)
+	}
+	logrus.Infof("Resolving latest llmos release to %s from %s", version, url)
+	return version, nil
+}
+
+func (u *Updater) expectedHash(ctx context.Context, version, asset string) (string, error) {
+	url := fmt.Sprintf("%s/download/%s/%s", u.opts.BaseURL, version, checksumsFile)
+	resp, err := u.get(ctx, url)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == asset {
+			return strings.ToLower(fields[0]), nil
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return "", fmt.Errorf("reading %s: %w", url, err)
+	}
+	return "", fmt.Errorf("checksum of %s is not found in %s", asset, url)
+}
+
+// replaceBinary downloads the binary next to the target and renames it over the target once verified
+func (u *Updater) replaceBinary(ctx context.Context, version, asset, expected string) error {
+	url := fmt.Sprintf("%s/download/%s/%s", u.opts.BaseURL, version, asset)
+	resp, err := u.get(ctx, url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	tmp, err := os.CreateTemp(filepath.Dir(u.opts.Binary), ".llmos-update-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	h := sha256.New()
+	if _, err = io.Copy(io.MultiWriter(tmp, h), resp.Body); err != nil {
+		tmp.Close()
+		return fmt.Errorf("downloading %s: %w", url, err)
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+
+	if actual := hex.EncodeToString(h.Sum(nil)); actual != expected {
+		return fmt.Errorf("download sha256 does not match %s, got %s", expected, actual)
+	}
+
+	if err = os.Chmod(tmp.Name(), 0755); err != nil {
+		return err
+	}
+	if err = os.Rename(tmp.Name(), u.opts.Binary); err != nil {
+		return fmt.Errorf("replacing %s: %w", u.opts.Binary, err)
+	}
+	logrus.Infof("Installed llmos %s to %s", version, u.opts.Binary)
+	return nil
+}
+
+// restartService restarts the llmos service only if it is running, a stopped service
+// or a node without systemd picks up the new binary on the next start
+func (u *Updater) restartService(ctx context.Context) error {
+	if err := u.Run(ctx, "systemctl", "is-active", "--quiet", install.SystemName); err != nil {
+		logrus.Debugf("%s service is not active, skipping restart", install.SystemName)
+		return nil
+	}
+	logrus.Infof("Restarting %s service", install.SystemName)
+	return u.Run(ctx, "systemctl", "restart", "--no-block", install.SystemName)
+}
+
+func (u *Updater) get(ctx context.Context, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := u.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("getting %s: %w", url, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("getting %s: unexpected status %s", url, resp.Status)
+	}
+	return resp, nil
+}
+
+// AssetName returns the release asset name of the binary, matching the suffix used by install.sh
+func AssetName(goos, goarch string) string {
+	return fmt.Sprintf("llmos_%s_%s", goos, goarch)
+}
@@ -0,0 +1,120 @@
+package selfupdate
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/llmos-ai/llmos/pkg/utils/command/commandtest"
+)
+
+// newReleaseServer serves the release layout of the GitHub releases from a local directory
+func newReleaseServer(t *testing.T, latest string, assets map[string]string) *httptest.Server {
+	dir := t.TempDir()
+	for name, content := range assets {
+		path := filepath.Join(dir, "download", latest, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/download/", http.FileServer(http.Dir(dir)))
+	mux.HandleFunc("/latest", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/tag/"+latest, http.StatusFound)
+	})
+	mux.HandleFunc("/tag/", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	return httptest.NewServer(mux)
+}
+
+func checksum(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+func newTestUpdater(t *testing.T, server *httptest.Server) (*Updater, *commandtest.Recorder) {
+	binary := filepath.Join(t.TempDir(), "llmos")
+	require.NoError(t, os.WriteFile(binary, []byte("old"), 0755))
+
+	u, err := New(Options{BaseURL: server.URL + "/", Binary: binary})
+	require.NoError(t, err)
+	runner := &commandtest.Recorder{}
+	u.Run = runner.Run
+	return u, runner
+}
+
+func TestUpdate(t *testing.T) {
+	asset := AssetName(runtime.GOOS, runtime.GOARCH)
+	server := newReleaseServer(t, "v0.2.0", map[string]string{
+		"checksums.txt": fmt.Sprintf("%s  llmos_other_arch\n%s  %s\n", checksum("other"), checksum("new"), asset),
+		asset:           "new",
+	})
+	defer server.Close()
+
+	u, runner := newTestUpdater(t, server)
+
+	version, updated, err := u.Update(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "v0.2.0", version)
+	assert.True(t, updated)
+
+	content, err := os.ReadFile(u.opts.Binary)
+	require.NoError(t, err)
+	assert.Equal(t, "new", string(content))
+	info, err := os.Stat(u.opts.Binary)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0755), info.Mode().Perm())
+	assert.Equal(t, []string{
+		"systemctl is-active --quiet llmos",
+		"systemctl restart --no-block llmos",
+	}, runner.Calls())
+
+	// the installed binary matches the release
+	_, updated, err = u.Update(context.Background())
+	require.NoError(t, err)
+	assert.False(t, updated)
+	assert.Empty(t, runner.Calls())
+}
+
+func TestUpdateChecksumMismatch(t *testing.T) {
+	asset := AssetName(runtime.GOOS, runtime.GOARCH)
+	server := newReleaseServer(t, "v0.2.0", map[string]string{
+		"checksums.txt": fmt.Sprintf("%s  %s\n", checksum("expected"), asset),
+		asset:           "tampered",
+	})
+	defer server.Close()
+
+	u, _ := newTestUpdater(t, server)
+	_, updated, err := u.Update(context.Background())
+	assert.ErrorContains(t, err, "sha256 does not match")
+	assert.False(t, updated)
+
+	// the binary is untouched and no temp file is left behind
+	content, err := os.ReadFile(u.opts.Binary)
+	require.NoError(t, err)
+	assert.Equal(t, "old", string(content))
+	entries, err := os.ReadDir(filepath.Dir(u.opts.Binary))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func TestUpdateVersionNotFound(t *testing.T) {
+	server := newReleaseServer(t, "v0.2.0", map[string]string{})
+	defer server.Close()
+
+	u, _ := newTestUpdater(t, server)
+	u.opts.Version = "v0.3.0"
+	_, _, err := u.Update(context.Background())
+	assert.ErrorContains(t, err, "404")
+}
@@ -0,0 +1,107 @@
+package snapshot
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+
+	"github.com/llmos-ai/llmos/pkg/bootstrap/config"
+)
+
+const defaultS3Endpoint = "s3.amazonaws.com"
+
+func newS3Client(cfg *config.S3Config) (*minio.Client, error) {
+	endpoint := cfg.Endpoint
+	if endpoint == "" {
+		endpoint = defaultS3Endpoint
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: cfg.SkipSSLVerify, //nolint:gosec
+	}
+	if cfg.EndpointCA != "" {
+		ca, err := os.ReadFile(cfg.EndpointCA)
+		if err != nil {
+			return nil, fmt.Errorf("reading s3 endpoint CA %s: %w", cfg.EndpointCA, err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("invalid s3 endpoint CA %s", cfg.EndpointCA)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	transport.TLSClientConfig = tlsConfig
+
+	var creds *credentials.Credentials
+	if cfg.AccessKey != "" || cfg.SecretKey != "" {
+		creds = credentials.NewStaticV4(cfg.AccessKey, cfg.SecretKey, "")
+	} else {
+		creds = credentials.NewIAM("")
+	}
+
+	return minio.New(endpoint, &minio.Options{
+		Creds:        creds,
+		Secure:       !cfg.Insecure,
+		Region:       cfg.Region,
+		BucketLookup: minio.BucketLookupAuto,
+		Transport:    transport,
+	})
+}
+
+func listS3(ctx context.Context, cfg *config.S3Config) ([]Snapshot, error) {
+	client, err := newS3Client(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := ""
+	if cfg.Folder != "" {
+		prefix = strings.TrimSuffix(cfg.Folder, "/") + "/"
+	}
+
+	var snapshots []Snapshot
+	for obj := range client.ListObjects(ctx, cfg.Bucket, minio.ListObjectsOptions{Prefix: prefix}) {
+		if obj.Err != nil {
+			return nil, fmt.Errorf("listing s3 bucket %s: %w", cfg.Bucket, obj.Err)
+		}
+		name := strings.TrimPrefix(obj.Key, prefix)
+		// skip nested folders and the runtime snapshot metadata
+		if name == "" || strings.Contains(name, "/") {
+			continue
+		}
+		snapshots = append(snapshots, Snapshot{
+			Name:     name,
+			Location: LocationS3,
+			Size:     obj.Size,
+			Created:  obj.LastModified,
+		})
+	}
+	return snapshots, nil
+}
+
+func downloadS3(ctx context.Context, cfg *config.S3Config, name, dest string) error {
+	client, err := newS3Client(cfg)
+	if err != nil {
+		return err
+	}
+
+	key := name
+	if cfg.Folder != "" {
+		key = path.Join(cfg.Folder, name)
+	}
+
+	if err = os.MkdirAll(filepath.Dir(dest), 0700); err != nil {
+		return err
+	}
+	return client.FGetObject(ctx, cfg.Bucket, key, dest, minio.GetObjectOptions{})
+}
@@ -0,0 +1,189 @@
+package snapshot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/llmos-ai/llmos/pkg/bootstrap/config"
+	"github.com/llmos-ai/llmos/pkg/bootstrap/runtime"
+	"github.com/llmos-ai/llmos/pkg/utils/command"
+	"github.com/llmos-ai/llmos/pkg/utils/redact"
+)
+
+const (
+	LocationLocal = "local"
+	LocationS3    = "s3"
+
+	// serviceStartTimeout bounds the start of the runtime service once the restore finished or was canceled
+	serviceStartTimeout = 5 * time.Minute
+)
+
+// Snapshot is an etcd snapshot stored locally or in the S3 compatible storage
+type Snapshot struct {
+	Name     string    `json:"name"`
+	Location string    `json:"location"`
+	Size     int64     `json:"size"`
+	Created  time.Time `json:"created"`
+}
+
+// Snapshotter wraps the etcd snapshot functionality and the cluster-reset flow of the k8s runtime
+type Snapshotter struct {
+	Runtime config.Runtime
+	Config  *config.EtcdSnapshotConfig
+	Binary  string
+	Service string
+	Run     command.Runner
+}
+
+func New(cfg *config.Config) (*Snapshotter, error) {
+	if cfg.Role == config.AgentRole {
+		return nil, fmt.Errorf("etcd snapshots are only available on the server nodes")
+	}
+	if cfg.Datastore != nil {
+		return nil, fmt.Errorf("etcd snapshots are not available with the external datastore")
+	}
+
+	// the secret key is redacted from the stamps and only restored from the config file, a key only
+	// passed by a flag would be sent as the redacted placeholder
+	if snap := cfg.EtcdSnapshot; snap != nil && snap.S3 != nil && redact.IsRedacted(snap.S3.SecretKey) {
+		return nil, fmt.Errorf("the etcd snapshot s3 secret key is redacted from the bootstrapped stamp and is " +
+			"not set in the config file, add it to the config file to use the s3 snapshots")
+	}
+
+	rt := runtime.Detect(cfg.KubernetesVersion)
+	if rt == config.RuntimeUnknown {
+		return nil, fmt.Errorf("unable to detect the k8s runtime of version %s", cfg.KubernetesVersion)
+	}
+
+	return &Snapshotter{
+		Runtime: rt,
+		Config:  cfg.EtcdSnapshot,
+		Binary:  runtime.GetBinary(rt),
+		Service: runtime.GetServiceName(rt, cfg.Role),
+		Run:     command.Run,
+	}, nil
+}
+
+// Save takes an on-demand snapshot, the runtime uploads it to S3 if configured
+func (s *Snapshotter) Save(ctx context.Context, name string) error {
+	args := []string{"etcd-snapshot", "save"}
+	if name != "" {
+		args = append(args, "--name", name)
+	}
+	return s.Run(ctx, s.Binary, args...)
+}
+
+// List returns the local and S3 snapshots ordered by creation time
+func (s *Snapshotter) List(ctx context.Context) ([]Snapshot, error) {
+	snapshots, err := s.listLocal()
+	if err != nil {
+		return nil, err
+	}
+
+	if s.Config != nil && s.Config.S3 != nil {
+		s3Snapshots, err := listS3(ctx, s.Config.S3)
+		if err != nil {
+			return nil, err
+		}
+		snapshots = append(snapshots, s3Snapshots...)
+	}
+
+	sort.SliceStable(snapshots, func(i, j int) bool {
+		return snapshots[i].Created.Before(snapshots[j].Created)
+	})
+	return snapshots, nil
+}
+
+// Restore stops the runtime service, resets the cluster to the snapshot and starts the service again.
+// Snapshots only found in S3 are downloaded to the local snapshot directory first.
+func (s *Snapshotter) Restore(ctx context.Context, name string) (err error) {
+	path, err := s.resolve(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	logrus.Infof("Stopping %s service", s.Service)
+	if err = s.Run(ctx, "systemctl", "stop", s.Service); err != nil {
+		return fmt.Errorf("stopping %s service: %w", s.Service, err)
+	}
+
+	// always try to start the service again, a failed reset leaves the previous datastore in place, the
+	// service is also started when the restore is canceled
+	defer func() {
+		startCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), serviceStartTimeout)
+		defer cancel()
+		logrus.Infof("Starting %s service", s.Service)
+		if startErr := s.Run(startCtx, "systemctl", "start", s.Service); startErr != nil {
+			err = errors.Join(err, fmt.Errorf("starting %s service: %w", s.Service, startErr))
+		}
+	}()
+
+	logrus.Infof("Restoring etcd snapshot %s", path)
+	if err = s.Run(ctx, s.Binary, "server", "--cluster-reset",
+		"--cluster-reset-restore-path="+path); err != nil {
+		return fmt.Errorf("restoring etcd snapshot %s: %w", path, err)
+	}
+	return nil
+}
+
+func (s *Snapshotter) resolve(ctx context.Context, name string) (string, error) {
+	if filepath.IsAbs(name) {
+		if _, err := os.Stat(name); err != nil {
+			return "", err
+		}
+		return name, nil
+	}
+
+	path := filepath.Join(s.Config.GetSnapshotDir(s.Runtime), name)
+	if _, err := os.Stat(path); err == nil {
+		return path, nil
+	} else if !os.IsNotExist(err) {
+		return "", err
+	}
+
+	if s.Config == nil || s.Config.S3 == nil {
+		return "", fmt.Errorf("snapshot %s is not found in %s", name, filepath.Dir(path))
+	}
+
+	logrus.Infof("Downloading etcd snapshot %s from s3 bucket %s", name, s.Config.S3.Bucket)
+	if err := downloadS3(ctx, s.Config.S3, name, path); err != nil {
+		return "", fmt.Errorf("downloading snapshot %s: %w", name, err)
+	}
+	return path, nil
+}
+
+func (s *Snapshotter) listLocal() ([]Snapshot, error) {
+	dir := s.Config.GetSnapshotDir(s.Runtime)
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var snapshots []Snapshot
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+		snapshots = append(snapshots, Snapshot{
+			Name:     entry.Name(),
+			Location: LocationLocal,
+			Size:     info.Size(),
+			Created:  info.ModTime(),
+		})
+	}
+	return snapshots, nil
+}
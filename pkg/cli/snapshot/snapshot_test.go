@@ -0,0 +1,206 @@
+package snapshot
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/llmos-ai/llmos/pkg/bootstrap/config"
+	"github.com/llmos-ai/llmos/pkg/utils/command/commandtest"
+)
+
+type s3Object struct {
+	Key          string
+	LastModified string
+	Size         int
+	ETag         string
+}
+
+type listBucketResult struct {
+	XMLName  xml.Name   `xml:"ListBucketResult"`
+	Name     string     `xml:"Name"`
+	Prefix   string     `xml:"Prefix"`
+	KeyCount int        `xml:"KeyCount"`
+	Contents []s3Object `xml:"Contents"`
+}
+
+// newS3StandIn serves the minimal ListObjectsV2 and GetObject API of a MinIO-like storage
+func newS3StandIn(t *testing.T, bucket string, objects map[string]string) *httptest.Server {
+	modified := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := strings.TrimPrefix(r.URL.Path, "/"+bucket)
+		if path == "" || path == "/" {
+			prefix := r.URL.Query().Get("prefix")
+			result := listBucketResult{Name: bucket, Prefix: prefix}
+			for key, content := range objects {
+				if !strings.HasPrefix(key, prefix) {
+					continue
+				}
+				result.Contents = append(result.Contents, s3Object{
+					Key:          key,
+					LastModified: modified.Format(time.RFC3339),
+					Size:         len(content),
+					ETag:         `"etag"`,
+				})
+			}
+			result.KeyCount = len(result.Contents)
+			w.Header().Set("Content-Type", "application/xml")
+			require.NoError(t, xml.NewEncoder(w).Encode(result))
+			return
+		}
+
+		content, ok := objects[strings.TrimPrefix(path, "/")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = fmt.Fprint(w, `<Error><Code>NoSuchKey</Code></Error>`)
+			return
+		}
+		w.Header().Set("Content-Length", fmt.Sprint(len(content)))
+		w.Header().Set("Last-Modified", modified.Format(http.TimeFormat))
+		w.Header().Set("ETag", `"etag"`)
+		if r.Method != http.MethodHead {
+			_, _ = fmt.Fprint(w, content)
+		}
+	}))
+}
+
+func newTestSnapshotter(t *testing.T, server *httptest.Server) (*Snapshotter, *commandtest.Recorder) {
+	runner := &commandtest.Recorder{}
+	return &Snapshotter{
+		Runtime: config.RuntimeK3S,
+		Config: &config.EtcdSnapshotConfig{
+			Dir: t.TempDir(),
+			S3: &config.S3Config{
+				Endpoint:  strings.TrimPrefix(server.URL, "http://"),
+				Insecure:  true,
+				AccessKey: "access",
+				SecretKey: "secret",
+				Bucket:    "snapshots",
+				Region:    "us-east-1",
+				Folder:    "llmos",
+			},
+		},
+		Binary:  "/usr/local/bin/k3s",
+		Service: "k3s",
+		Run:     runner.Run,
+	}, runner
+}
+
+func TestList(t *testing.T) {
+	server := newS3StandIn(t, "snapshots", map[string]string{
+		"llmos/on-demand-node1-1700000000":    "s3-snapshot",
+		"llmos/.metadata/on-demand-node1-170": "metadata",
+	})
+	defer server.Close()
+
+	s, _ := newTestSnapshotter(t, server)
+	require.NoError(t, os.WriteFile(filepath.Join(s.Config.Dir, "etcd-snapshot-node1-1700000001"),
+		[]byte("local"), 0600))
+
+	snapshots, err := s.List(context.Background())
+	require.NoError(t, err)
+	require.Len(t, snapshots, 2)
+	assert.Equal(t, "on-demand-node1-1700000000", snapshots[0].Name)
+	assert.Equal(t, LocationS3, snapshots[0].Location)
+	assert.Equal(t, int64(len("s3-snapshot")), snapshots[0].Size)
+	assert.Equal(t, "etcd-snapshot-node1-1700000001", snapshots[1].Name)
+	assert.Equal(t, LocationLocal, snapshots[1].Location)
+}
+
+func TestRestoreFromS3(t *testing.T) {
+	server := newS3StandIn(t, "snapshots", map[string]string{
+		"llmos/on-demand-node1-1700000000": "s3-snapshot",
+	})
+	defer server.Close()
+
+	s, runner := newTestSnapshotter(t, server)
+	require.NoError(t, s.Restore(context.Background(), "on-demand-node1-1700000000"))
+
+	path := filepath.Join(s.Config.Dir, "on-demand-node1-1700000000")
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "s3-snapshot", string(content))
+	assert.Equal(t, []string{
+		"systemctl stop k3s",
+		"/usr/local/bin/k3s server --cluster-reset --cluster-reset-restore-path=" + path,
+		"systemctl start k3s",
+	}, runner.Calls())
+}
+
+func TestRestoreStartsServiceOnFailure(t *testing.T) {
+	server := newS3StandIn(t, "snapshots", map[string]string{})
+	defer server.Close()
+
+	s, runner := newTestSnapshotter(t, server)
+	path := filepath.Join(s.Config.Dir, "local-snapshot")
+	require.NoError(t, os.WriteFile(path, []byte("local"), 0600))
+	runner.Fail = func(name string, _ ...string) error {
+		if name == s.Binary {
+			return fmt.Errorf("reset failed")
+		}
+		return nil
+	}
+
+	assert.Error(t, s.Restore(context.Background(), "local-snapshot"))
+	assert.Equal(t, []string{
+		"systemctl stop k3s",
+		"/usr/local/bin/k3s server --cluster-reset --cluster-reset-restore-path=" + path,
+		"systemctl start k3s",
+	}, runner.Calls())
+
+	assert.Error(t, s.Restore(context.Background(), "missing-snapshot"))
+	assert.Empty(t, runner.Calls())
+}
+
+func TestRestoreStartsServiceOnCancel(t *testing.T) {
+	server := newS3StandIn(t, "snapshots", map[string]string{})
+	defer server.Close()
+
+	s, runner := newTestSnapshotter(t, server)
+	path := filepath.Join(s.Config.Dir, "local-snapshot")
+	require.NoError(t, os.WriteFile(path, []byte("local"), 0600))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var startErr error
+	var startDeadline bool
+	s.Run = func(ctx context.Context, name string, args ...string) error {
+		switch {
+		case name == s.Binary:
+			// the restore is canceled while the cluster is reset
+			cancel()
+			return ctx.Err()
+		case args[0] == "start":
+			startErr = ctx.Err()
+			_, startDeadline = ctx.Deadline()
+		}
+		return runner.Run(ctx, name, args...)
+	}
+
+	assert.ErrorIs(t, s.Restore(ctx, "local-snapshot"), context.Canceled)
+	assert.Equal(t, []string{"systemctl stop k3s", "systemctl start k3s"}, runner.Calls())
+	assert.NoError(t, startErr, "the service is started with a context which is not canceled")
+	assert.True(t, startDeadline, "the start of the service is bounded")
+}
+
+func TestNewRejectsRedactedSecretKey(t *testing.T) {
+	cfg := &config.Config{KubernetesVersion: "v1.31.3+k3s1"}
+	cfg.Role = config.ServerRole
+	cfg.EtcdSnapshot = &config.EtcdSnapshotConfig{S3: &config.S3Config{Bucket: "snapshots", SecretKey: "<redacted>"}}
+	_, err := New(cfg)
+	assert.ErrorContains(t, err, "s3 secret key is redacted from the bootstrapped stamp")
+
+	cfg.EtcdSnapshot.S3.SecretKey = "secret"
+	_, err = New(cfg)
+	assert.NoError(t, err)
+}
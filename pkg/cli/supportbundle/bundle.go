@@ -0,0 +1,382 @@
+package supportbundle
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/yaml"
+
+	"github.com/llmos-ai/llmos/pkg/applyinator"
+	"github.com/llmos-ai/llmos/pkg/applyinator/prober"
+	"github.com/llmos-ai/llmos/pkg/bootstrap"
+	"github.com/llmos-ai/llmos/pkg/bootstrap/config"
+	"github.com/llmos-ai/llmos/pkg/bootstrap/kubectl"
+	"github.com/llmos-ai/llmos/pkg/bootstrap/plan"
+	"github.com/llmos-ai/llmos/pkg/bootstrap/runtime"
+	"github.com/llmos-ai/llmos/pkg/constants"
+	"github.com/llmos-ai/llmos/pkg/utils/redact"
+)
+
+const (
+	journalLines   = "5000"
+	k8sListTimeout = 30 * time.Second
+)
+
+// Exec runs a command and returns its combined output, it is replaced in tests
+type Exec func(ctx context.Context, name string, args ...string) ([]byte, error)
+
+type Options struct {
+	DataDir    string
+	ConfigPath string
+	Output     string
+}
+
+// Collector writes the node state useful to troubleshoot the bootstrap into a gzipped tarball,
+// the failures of the individual items are recorded in errors.txt instead of failing the bundle
+type Collector struct {
+	opts   Options
+	boot   *bootstrap.LLMOS
+	tw     *tar.Writer
+	prefix string
+	errors []string
+
+	RancherDir string
+	OSRelease  string
+	Exec       Exec
+	// K8s lists the llmos-system resources, it is created from the runtime kubeconfig if not set
+	K8s kubernetes.Interface
+}
+
+func New(opts Options) *Collector {
+	return &Collector{
+		opts:       opts,
+		boot:       bootstrap.New(bootstrap.Config{DataDir: opts.DataDir, ConfigPath: opts.ConfigPath}),
+		RancherDir: "/etc/rancher",
+		OSRelease:  "/etc/os-release",
+		Exec:       execCommand,
+	}
+}
+
+// DefaultOutput returns the bundle file name in the working directory
+func DefaultOutput(now time.Time) string {
+	hostname, _ := os.Hostname()
+	return fmt.Sprintf("llmos-support-bundle-%s-%s.tar.gz", hostname, now.Format("20060102-150405"))
+}
+
+// Collect writes the bundle to the output file and returns its path
+func (c *Collector) Collect(ctx context.Context) (string, error) {
+	output := c.opts.Output
+	if output == "" {
+		output = DefaultOutput(time.Now())
+	}
+	c.prefix = strings.TrimSuffix(filepath.Base(output), ".tar.gz")
+
+	f, err := os.OpenFile(output, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	gz := gzip.NewWriter(f)
+	c.tw = tar.NewWriter(gz)
+
+	cfg := c.collectConfig()
+	c.collectPlans()
+	c.collectProbes(ctx)
+	c.collectInfo(ctx)
+	c.collectRuntimeConfig()
+	c.collectLogs(ctx, cfg)
+	c.collectKubernetes(ctx)
+
+	if len(c.errors) > 0 {
+		c.add("errors.txt", []byte(strings.Join(c.errors, "\n")+"\n"))
+	}
+
+	if err = c.tw.Close(); err != nil {
+		return "", err
+	}
+	if err = gz.Close(); err != nil {
+		return "", err
+	}
+	return output, f.Close()
+}
+
+// collectConfig adds the redacted config file and stamps, it returns the effective config of the node
+func (c *Collector) collectConfig() *config.Config {
+	if data, err := os.ReadFile(c.opts.ConfigPath); err == nil {
+		c.add("config/config.yaml", redact.YAML(data))
+	} else if !os.IsNotExist(err) {
+		c.recordError("reading config %s: %v", c.opts.ConfigPath, err)
+	}
+
+	// the bootstrapped stamp takes precedence over the working stamp of the last attempt
+	var effective *config.Config
+	for _, stamp := range []struct {
+		name string
+		load func() (config.Config, error)
+	}{
+		{name: "bootstrapped", load: c.boot.LoadDoneConfig},
+		{name: "working", load: c.boot.LoadWorkingConfig},
+	} {
+		cfg, err := stamp.load()
+		if err != nil {
+			if !errors.Is(err, fs.ErrNotExist) {
+				c.recordError("loading %s stamp: %v", stamp.name, err)
+			}
+			continue
+		}
+		c.addYAML("stamps/"+stamp.name, cfg)
+		if effective == nil {
+			effective = &cfg
+		}
+	}
+
+	if effective != nil {
+		c.addYAML("config/effective.yaml", *effective)
+	}
+	return effective
+}
+
+func (c *Collector) collectPlans() {
+	if p, ok := c.readPlan(plan.GetPlanFile(c.opts.DataDir)); ok {
+		c.addJSON("plan/plan.json", applyinator.RedactPlan(p))
+	}
+
+	if data, err := os.ReadFile(plan.GetPlanOutput(c.opts.DataDir)); err == nil {
+		if redactedOutput, err := redactPlanOutput(data); err == nil {
+			c.add("plan/plan-output.json", redactedOutput)
+		} else {
+			c.recordError("parsing plan output: %v", err)
+		}
+	} else if !os.IsNotExist(err) {
+		c.recordError("reading plan output: %v", err)
+	}
+
+	appliedDir := filepath.Join(c.opts.DataDir, "plan", "applied")
+	entries, err := os.ReadDir(appliedDir)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		data, err := os.ReadFile(filepath.Join(appliedDir, entry.Name()))
+		if err != nil {
+			c.recordError("reading applied plan %s: %v", entry.Name(), err)
+			continue
+		}
+		applied := applyinator.CalculatedPlan{}
+		if err = json.Unmarshal(data, &applied); err != nil {
+			c.recordError("parsing applied plan %s: %v", entry.Name(), err)
+			continue
+		}
+		applied.Plan = applyinator.RedactPlan(applied.Plan)
+		c.addJSON("plan/applied/"+entry.Name(), applied)
+	}
+}
+
+func (c *Collector) readPlan(path string) (applyinator.Plan, bool) {
+	p := applyinator.Plan{}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return p, false
+	} else if err != nil {
+		c.recordError("reading plan %s: %v", path, err)
+		return p, false
+	}
+	if err = json.Unmarshal(data, &p); err != nil {
+		c.recordError("parsing plan %s: %v", path, err)
+		return p, false
+	}
+	return p, true
+}
+
+// collectProbes runs the plan probes once and records their status
+func (c *Collector) collectProbes(ctx context.Context) {
+	p, ok := c.readPlan(plan.GetPlanFile(c.opts.DataDir))
+	if !ok || len(p.Probes) == 0 {
+		return
+	}
+
+	statuses := map[string]prober.ProbeStatus{}
+	prober.DoProbes(ctx, p.Probes, statuses, false)
+	c.addJSON("probes.json", statuses)
+}
+
+func (c *Collector) collectInfo(ctx context.Context) {
+	buf := &strings.Builder{}
+	if err := c.boot.WriteInfo(ctx, buf); err != nil {
+		c.recordError("collecting info: %v", err)
+		return
+	}
+	c.add("info.txt", []byte(buf.String()))
+
+	if data, err := os.ReadFile(c.OSRelease); err == nil {
+		c.add("os-release", data)
+	} else {
+		c.recordError("reading %s: %v", c.OSRelease, err)
+	}
+}
+
+// collectRuntimeConfig adds the k3s/rke2 config, the config.yaml.d drop-ins and the registries
+func (c *Collector) collectRuntimeConfig() {
+	for _, rt := range []config.Runtime{config.RuntimeK3S, config.RuntimeRKE2} {
+		dir := filepath.Join(c.RancherDir, string(rt))
+		files := []string{"config.yaml", "registries.yaml"}
+		if entries, err := os.ReadDir(filepath.Join(dir, "config.yaml.d")); err == nil {
+			for _, entry := range entries {
+				if !entry.IsDir() {
+					files = append(files, filepath.Join("config.yaml.d", entry.Name()))
+				}
+			}
+		}
+
+		for _, file := range files {
+			data, err := os.ReadFile(filepath.Join(dir, file))
+			if err != nil {
+				continue
+			}
+			c.add(filepath.Join("runtime", string(rt), file), redact.YAML(data))
+		}
+	}
+}
+
+// collectLogs adds the journal of the llmos service, it contains the output of the plan instructions
+func (c *Collector) collectLogs(ctx context.Context, cfg *config.Config) {
+	services := []string{"llmos"}
+	if cfg != nil {
+		if rt := runtime.Detect(cfg.KubernetesVersion); rt != config.RuntimeUnknown {
+			services = append(services, runtime.GetServiceName(rt, cfg.Role))
+		}
+	}
+
+	for _, service := range services {
+		out, err := c.Exec(ctx, "journalctl", "-u", service, "--no-pager", "-n", journalLines)
+		if err != nil {
+			c.recordError("collecting %s journal: %v", service, err)
+			continue
+		}
+		c.add(fmt.Sprintf("logs/%s.log", service), []byte(redact.Text(string(out))))
+	}
+
+	// openrc writes the service log to file
+	if data, err := os.ReadFile("/var/log/llmos.log"); err == nil {
+		c.add("logs/llmos-openrc.log", []byte(redact.Text(string(data))))
+	}
+}
+
+// collectKubernetes lists the nodes and the llmos-system pods and events when the cluster is reachable
+func (c *Collector) collectKubernetes(ctx context.Context) {
+	if c.K8s == nil {
+		k8s, err := newKubernetesClient()
+		if err != nil {
+			c.recordError("skipping kubernetes resources: %v", err)
+			return
+		}
+		c.K8s = k8s
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, k8sListTimeout)
+	defer cancel()
+
+	if nodes, err := c.K8s.CoreV1().Nodes().List(ctx, metav1.ListOptions{}); err == nil {
+		c.addYAML("kubernetes/nodes.yaml", nodes)
+	} else {
+		c.recordError("listing nodes: %v", err)
+	}
+
+	if pods, err := c.K8s.CoreV1().Pods(constants.SystemNamespace).List(ctx, metav1.ListOptions{}); err == nil {
+		c.addYAML("kubernetes/pods.yaml", pods)
+	} else {
+		c.recordError("listing pods: %v", err)
+	}
+
+	if events, err := c.K8s.CoreV1().Events(constants.SystemNamespace).List(ctx, metav1.ListOptions{}); err == nil {
+		sort.SliceStable(events.Items, func(i, j int) bool {
+			return events.Items[i].LastTimestamp.Before(&events.Items[j].LastTimestamp)
+		})
+		c.addYAML("kubernetes/events.yaml", events)
+	} else {
+		c.recordError("listing events: %v", err)
+	}
+}
+
+func newKubernetesClient() (kubernetes.Interface, error) {
+	kubeconfig, err := kubectl.GetKubeconfig("")
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+	restConfig, err := clientcmd.RESTConfigFromKubeConfig(data)
+	if err != nil {
+		return nil, err
+	}
+	restConfig.Timeout = k8sListTimeout
+	return kubernetes.NewForConfig(restConfig)
+}
+
+func (c *Collector) addYAML(name string, obj interface{}) {
+	c.add(name, c.marshalRedactedYAML(obj))
+}
+
+func (c *Collector) marshalRedactedYAML(obj interface{}) []byte {
+	data, err := yaml.Marshal(obj)
+	if err != nil {
+		c.recordError("marshalling %T: %v", obj, err)
+		return nil
+	}
+	return redact.YAML(data)
+}
+
+func (c *Collector) addJSON(name string, obj interface{}) {
+	data, err := marshalJSON(obj)
+	if err != nil {
+		c.recordError("marshalling %s: %v", name, err)
+		return
+	}
+	c.add(name, data)
+}
+
+func (c *Collector) add(name string, data []byte) {
+	if data == nil {
+		return
+	}
+	err := c.tw.WriteHeader(&tar.Header{
+		Name:    filepath.Join(c.prefix, name),
+		Mode:    0600,
+		Size:    int64(len(data)),
+		ModTime: time.Now(),
+	})
+	if err == nil {
+		_, err = c.tw.Write(data)
+	}
+	if err != nil {
+		logrus.Errorf("failed to add %s to support bundle: %v", name, err)
+	}
+}
+
+func (c *Collector) recordError(format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	logrus.Debugf("support bundle: %s", msg)
+	c.errors = append(c.errors, msg)
+}
+
+func execCommand(ctx context.Context, name string, args ...string) ([]byte, error) {
+	return exec.CommandContext(ctx, name, args...).CombinedOutput()
+}
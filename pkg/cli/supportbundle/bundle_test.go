@@ -0,0 +1,119 @@
+package supportbundle
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"sigs.k8s.io/yaml"
+
+	"github.com/llmos-ai/llmos/pkg/applyinator"
+	"github.com/llmos-ai/llmos/pkg/bootstrap"
+	"github.com/llmos-ai/llmos/pkg/bootstrap/config"
+	"github.com/llmos-ai/llmos/pkg/constants"
+)
+
+func readBundle(t *testing.T, path string) map[string]string {
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	require.NoError(t, err)
+	tr := tar.NewReader(gz)
+
+	files := map[string]string{}
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		data, err := io.ReadAll(tr)
+		require.NoError(t, err)
+		files[strings.SplitN(header.Name, "/", 2)[1]] = string(data)
+	}
+	return files
+}
+
+func TestCollect(t *testing.T) {
+	dataDir := t.TempDir()
+	cfg := config.Config{KubernetesVersion: "v1.31.3+k3s1"}
+	cfg.Role = config.ClusterInitRole
+	cfg.Token = "cluster-secret"
+	require.NoError(t, bootstrap.New(bootstrap.Config{DataDir: dataDir}).SaveDoneConfig(cfg))
+
+	plan := applyinator.Plan{
+		Files: []applyinator.File{{
+			Path:    "/etc/rancher/k3s/config.yaml.d/40-llmos.yaml",
+			Content: base64.StdEncoding.EncodeToString([]byte("token: cluster-secret\n")),
+		}},
+		OneTimeInstructions: []applyinator.OneTimeInstruction{{
+			CommonInstruction: applyinator.CommonInstruction{
+				Name: "install",
+				Env:  []string{"INSTALL_K3S_TOKEN=cluster-secret"},
+			},
+		}},
+	}
+	planData, err := json.Marshal(plan)
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(filepath.Join(dataDir, "plan"), 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(dataDir, "plan", "plan.json"), planData, 0600))
+	outputData, err := json.Marshal(map[string][]byte{"install": []byte("using token=cluster-secret")})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dataDir, "plan", "plan-output.json"), outputData, 0600))
+
+	rancherDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(rancherDir, "k3s", "config.yaml.d"), 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(rancherDir, "k3s", "config.yaml.d", "40-llmos.yaml"),
+		[]byte("token: cluster-secret\nnode-name: node1\n"), 0600))
+
+	c := New(Options{
+		DataDir:    dataDir,
+		ConfigPath: filepath.Join(dataDir, "missing-config.yaml"),
+		Output:     filepath.Join(t.TempDir(), "bundle.tar.gz"),
+	})
+	c.RancherDir = rancherDir
+	c.Exec = func(_ context.Context, name string, args ...string) ([]byte, error) {
+		if args[1] == "k3s" {
+			return nil, fmt.Errorf("no journal")
+		}
+		return []byte("bootstrap with --token=cluster-secret"), nil
+	}
+	c.K8s = fake.NewSimpleClientset(&corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "llmos-operator", Namespace: constants.SystemNamespace},
+	})
+
+	output, err := c.Collect(context.Background())
+	require.NoError(t, err)
+
+	files := readBundle(t, output)
+	for name, content := range files {
+		assert.NotContains(t, content, "cluster-secret", name)
+	}
+
+	effective := config.Config{}
+	require.NoError(t, yaml.Unmarshal([]byte(files["config/effective.yaml"]), &effective))
+	assert.Equal(t, "<redacted>", effective.Token)
+	assert.Equal(t, "v1.31.3+k3s1", effective.KubernetesVersion)
+	assert.Contains(t, files, "stamps/bootstrapped")
+	assert.Contains(t, files["plan/plan.json"], "INSTALL_K3S_TOKEN=<redacted>")
+	assert.Contains(t, files["plan/plan-output.json"], "token=<redacted>")
+	assert.Contains(t, files["runtime/k3s/config.yaml.d/40-llmos.yaml"], "node-name: node1")
+	assert.Contains(t, files["logs/llmos.log"], "--token=<redacted>")
+	assert.Contains(t, files["kubernetes/pods.yaml"], "name: llmos-operator")
+	assert.Contains(t, files["errors.txt"], "collecting k3s journal: no journal")
+}
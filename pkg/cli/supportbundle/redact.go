@@ -0,0 +1,34 @@
+package supportbundle
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/llmos-ai/llmos/pkg/utils/redact"
+)
+
+// redactPlanOutput decodes the output of the one-time instructions and redacts it as text
+func redactPlanOutput(data []byte) ([]byte, error) {
+	outputs := map[string][]byte{}
+	if err := json.Unmarshal(data, &outputs); err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]string, len(outputs))
+	for name, output := range outputs {
+		result[name] = redact.Text(string(output))
+	}
+	return marshalJSON(result)
+}
+
+// marshalJSON indents the JSON and keeps the redacted placeholder readable
+func marshalJSON(obj interface{}) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(obj); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -3,14 +3,17 @@ package token
 import (
 	"context"
 	"fmt"
-	"os"
+	"path/filepath"
 	"strings"
+
+	"github.com/llmos-ai/llmos/pkg/utils/crypt"
 )
 
 const defaultTokenPath = "/var/lib/llmos/token"
 
 func GetLocalToken(_ context.Context) (string, error) {
-	token, err := os.ReadFile(defaultTokenPath)
+	// the token is encrypted by the node key next to it, tokens written by older releases are plain text
+	token, err := crypt.ForDataDir(filepath.Dir(defaultTokenPath)).ReadFile(defaultTokenPath)
 	if err != nil {
 		return "", err
 	}
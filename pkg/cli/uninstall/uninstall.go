@@ -0,0 +1,309 @@
+package uninstall
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/llmos-ai/llmos/pkg/applyinator"
+	"github.com/llmos-ai/llmos/pkg/bootstrap"
+	"github.com/llmos-ai/llmos/pkg/bootstrap/config"
+	"github.com/llmos-ai/llmos/pkg/bootstrap/plan"
+	"github.com/llmos-ai/llmos/pkg/bootstrap/runtime"
+	"github.com/llmos-ai/llmos/pkg/utils/command"
+)
+
+const (
+	imagesDir             = "images"
+	appliedPlanFileSuffix = "-applied.plan"
+)
+
+type Options struct {
+	// Root is the root of the installation, all the paths are relative to it
+	Root       string
+	DataDir    string
+	DryRun     bool
+	KeepData   bool
+	KeepImages bool
+}
+
+// Uninstaller removes what the bootstrap installed on the node, every step skips
+// the items that no longer exist so it is safe to run repeatedly
+type Uninstaller struct {
+	opts    Options
+	cfg     config.Config
+	runtime config.Runtime
+	files   []applyinator.File
+	actions []string
+	removed map[string]bool
+	Run     command.Runner
+}
+
+func New(opts Options) *Uninstaller {
+	if opts.Root == "" {
+		opts.Root = "/"
+	}
+	dataDir := filepath.Join(opts.Root, opts.DataDir)
+	boot := bootstrap.New(bootstrap.Config{DataDir: dataDir})
+	cfg, err := boot.LoadDoneConfig()
+	if err != nil {
+		logrus.Debugf("failed to load bootstrapped stamp, falling back to working stamp: %v", err)
+		if cfg, err = boot.LoadWorkingConfig(); err != nil {
+			logrus.Debugf("failed to load working stamp: %v", err)
+		}
+	}
+
+	return &Uninstaller{
+		opts:    opts,
+		cfg:     cfg,
+		runtime: runtime.Detect(cfg.KubernetesVersion),
+		files:   loadPlanFiles(dataDir),
+		removed: map[string]bool{},
+		Run:     command.Run,
+	}
+}
+
+// Uninstall removes the k8s runtime, the generated files and the data dir, it returns the performed actions
+func (u *Uninstaller) Uninstall(ctx context.Context) ([]string, error) {
+	if err := u.uninstallRuntime(ctx); err != nil {
+		return u.actions, err
+	}
+
+	for _, file := range u.files {
+		if u.opts.KeepData && isSubPath(u.opts.DataDir, file.Path) {
+			continue
+		}
+		if err := u.remove(u.path(file.Path)); err != nil {
+			return u.actions, err
+		}
+	}
+
+	if err := u.removeKubeconfigSymlink(); err != nil {
+		return u.actions, err
+	}
+
+	if !u.opts.KeepData {
+		if err := u.removeDataDir(); err != nil {
+			return u.actions, err
+		}
+	}
+
+	return u.actions, nil
+}
+
+func (u *Uninstaller) uninstallRuntime(ctx context.Context) (err error) {
+	if u.runtime == config.RuntimeUnknown {
+		logrus.Infof("Kubernetes runtime not found, skipping uninstall k8s runtime")
+		return nil
+	}
+
+	uninstallScript := u.findScript(u.uninstallScriptName())
+	if uninstallScript == "" {
+		logrus.Infof("%s uninstall script not found, skipping uninstall k8s runtime", u.runtime)
+		return nil
+	}
+
+	if killallScript := u.findScript(fmt.Sprintf("%s-killall.sh", u.runtime)); killallScript != "" {
+		if err := u.exec(ctx, killallScript); err != nil {
+			return err
+		}
+	}
+
+	if !u.opts.KeepImages {
+		return u.exec(ctx, uninstallScript)
+	}
+
+	// the uninstall script wipes the runtime data dir, move the airgap images aside and restore them afterwards,
+	// also when the script fails so that they are not left behind in the backup
+	images := u.path(fmt.Sprintf("/var/lib/rancher/%s/agent/images", u.runtime))
+	backup := filepath.Join(filepath.Dir(u.path(u.opts.DataDir)), fmt.Sprintf(".%s-images", u.runtime))
+	if _, err = os.Stat(images); err != nil {
+		return u.exec(ctx, uninstallScript)
+	}
+
+	if err = u.rename(images, backup); err != nil {
+		return err
+	}
+	defer func() {
+		if restoreErr := u.restore(backup, images); restoreErr != nil {
+			err = errors.Join(err, restoreErr)
+		}
+	}()
+	return u.exec(ctx, uninstallScript)
+}
+
+func (u *Uninstaller) restore(backup, images string) error {
+	if err := u.mkdir(filepath.Dir(images)); err != nil {
+		return err
+	}
+	return u.rename(backup, images)
+}
+
+func (u *Uninstaller) uninstallScriptName() string {
+	if u.runtime == config.RuntimeK3S && u.cfg.Role == config.AgentRole {
+		return "k3s-agent-uninstall.sh"
+	}
+	return fmt.Sprintf("%s-uninstall.sh", u.runtime)
+}
+
+func (u *Uninstaller) removeKubeconfigSymlink() error {
+	path := u.path(runtime.GetLLMOSKubeconfigPath())
+	info, err := os.Lstat(path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if info.Mode()&os.ModeSymlink == 0 {
+		logrus.Infof("%s is not a symlink created by llmos, skipping", path)
+		return nil
+	}
+	return u.remove(path)
+}
+
+func (u *Uninstaller) removeDataDir() error {
+	dataDir := u.path(u.opts.DataDir)
+	if !u.opts.KeepImages {
+		return u.remove(dataDir)
+	}
+
+	entries, err := os.ReadDir(dataDir)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if entry.Name() == imagesDir {
+			continue
+		}
+		if err = u.remove(filepath.Join(dataDir, entry.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (u *Uninstaller) remove(path string) error {
+	if _, err := os.Lstat(path); os.IsNotExist(err) || u.removed[path] {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	u.removed[path] = true
+	if u.opts.DryRun {
+		u.actions = append(u.actions, fmt.Sprintf("would remove %s", path))
+		return nil
+	}
+	if err := os.RemoveAll(path); err != nil {
+		return fmt.Errorf("removing %s: %w", path, err)
+	}
+	u.actions = append(u.actions, fmt.Sprintf("removed %s", path))
+	return nil
+}
+
+func (u *Uninstaller) rename(from, to string) error {
+	if u.opts.DryRun {
+		u.actions = append(u.actions, fmt.Sprintf("would move %s to %s", from, to))
+		return nil
+	}
+	if err := os.Rename(from, to); err != nil {
+		return fmt.Errorf("moving %s to %s: %w", from, to, err)
+	}
+	u.actions = append(u.actions, fmt.Sprintf("moved %s to %s", from, to))
+	return nil
+}
+
+func (u *Uninstaller) mkdir(path string) error {
+	if u.opts.DryRun {
+		return nil
+	}
+	return os.MkdirAll(path, 0755)
+}
+
+func (u *Uninstaller) exec(ctx context.Context, script string) error {
+	if u.opts.DryRun {
+		u.actions = append(u.actions, fmt.Sprintf("would run %s", script))
+		return nil
+	}
+
+	logrus.Infof("Running %s", script)
+	if err := u.Run(ctx, script); err != nil {
+		return err
+	}
+	u.actions = append(u.actions, fmt.Sprintf("ran %s", script))
+	return nil
+}
+
+// loadPlanFiles returns the files of the recorded plan, falling back to the latest applied plan
+func loadPlanFiles(dataDir string) []applyinator.File {
+	p := applyinator.Plan{}
+	if data, err := os.ReadFile(plan.GetPlanFile(dataDir)); err == nil {
+		if err = json.Unmarshal(data, &p); err == nil {
+			return p.Files
+		}
+		logrus.Debugf("failed to parse plan file: %v", err)
+	}
+
+	appliedDir := filepath.Join(dataDir, "plan", "applied")
+	entries, err := os.ReadDir(appliedDir)
+	if err != nil {
+		return nil
+	}
+
+	var applied []string
+	for _, entry := range entries {
+		if strings.HasSuffix(entry.Name(), appliedPlanFileSuffix) {
+			applied = append(applied, entry.Name())
+		}
+	}
+	if len(applied) == 0 {
+		return nil
+	}
+	sort.Strings(applied)
+
+	data, err := os.ReadFile(filepath.Join(appliedDir, applied[len(applied)-1]))
+	if err != nil {
+		return nil
+	}
+	cp := applyinator.CalculatedPlan{}
+	if err = json.Unmarshal(data, &cp); err != nil {
+		logrus.Debugf("failed to parse applied plan: %v", err)
+		return nil
+	}
+	return cp.Plan.Files
+}
+
+// findScript looks up the script of the k8s runtime, the PATH is only searched when the root is the host root
+func (u *Uninstaller) findScript(name string) string {
+	if u.opts.Root == "/" {
+		if path, err := exec.LookPath(name); err == nil {
+			return path
+		}
+	}
+	for _, dir := range []string{"/usr/local/bin", "/opt/rke2/bin", "/opt/bin"} {
+		path := u.path(filepath.Join(dir, name))
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+func (u *Uninstaller) path(path string) string {
+	return filepath.Join(u.opts.Root, path)
+}
+
+func isSubPath(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && !strings.HasPrefix(rel, "..")
+}
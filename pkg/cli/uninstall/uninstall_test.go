@@ -0,0 +1,135 @@
+package uninstall
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/llmos-ai/llmos/pkg/applyinator"
+	"github.com/llmos-ai/llmos/pkg/bootstrap/plan"
+	"github.com/llmos-ai/llmos/pkg/utils/command/commandtest"
+)
+
+const (
+	testDataDir    = "/var/lib/llmos"
+	testImagesDir  = "/var/lib/rancher/k3s/agent/images"
+	testConfigFile = "/etc/rancher/k3s/config.yaml.d/40-llmos.yaml"
+)
+
+// newTestUninstaller returns the uninstaller of a k3s server bootstrapped to a temporary root
+func newTestUninstaller(t *testing.T, opts Options) (*Uninstaller, *commandtest.Recorder) {
+	opts.Root = t.TempDir()
+	opts.DataDir = testDataDir
+
+	writeFile(t, opts.Root, filepath.Join(testDataDir, "bootstrapped"),
+		"kubernetesVersion: v1.31.3+k3s1\nrole: server\n")
+	writeFile(t, opts.Root, filepath.Join(testDataDir, imagesDir, "llmos.tar"), "llmos")
+	writeFile(t, opts.Root, filepath.Join(testImagesDir, "k3s.tar"), "k3s")
+	writeFile(t, opts.Root, testConfigFile, "token: secret\n")
+	writeFile(t, opts.Root, "/usr/local/bin/k3s-uninstall.sh", "#!/bin/sh\n")
+	writeFile(t, opts.Root, "/usr/local/bin/k3s-killall.sh", "#!/bin/sh\n")
+
+	data, err := json.Marshal(applyinator.Plan{Files: []applyinator.File{{Path: testConfigFile}}})
+	require.NoError(t, err)
+	writeFile(t, opts.Root, plan.GetPlanFile(testDataDir), string(data))
+
+	runner := &commandtest.Recorder{}
+	u := New(opts)
+	u.Run = runner.Run
+	return u, runner
+}
+
+func writeFile(t *testing.T, root, path, content string) {
+	path = filepath.Join(root, path)
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0755))
+}
+
+// wipeRuntime fakes the k3s uninstall script removing the runtime data dir
+func wipeRuntime(u *Uninstaller, err error) func(name string, args ...string) error {
+	return func(name string, _ ...string) error {
+		if filepath.Base(name) != "k3s-uninstall.sh" {
+			return nil
+		}
+		if removeErr := os.RemoveAll(u.path("/var/lib/rancher")); removeErr != nil {
+			return removeErr
+		}
+		return err
+	}
+}
+
+func TestUninstallDryRun(t *testing.T) {
+	u, runner := newTestUninstaller(t, Options{DryRun: true, KeepImages: true})
+
+	actions, err := u.Uninstall(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, runner.Calls())
+	assert.Equal(t, []string{
+		"would run " + u.path("/usr/local/bin/k3s-killall.sh"),
+		"would move " + u.path(testImagesDir) + " to " + u.path("/var/lib/.k3s-images"),
+		"would run " + u.path("/usr/local/bin/k3s-uninstall.sh"),
+		"would move " + u.path("/var/lib/.k3s-images") + " to " + u.path(testImagesDir),
+		"would remove " + u.path(testConfigFile),
+		"would remove " + u.path(filepath.Join(testDataDir, "bootstrapped")),
+		"would remove " + u.path(filepath.Join(testDataDir, "plan")),
+	}, actions)
+
+	for _, path := range []string{testConfigFile, filepath.Join(testImagesDir, "k3s.tar"),
+		filepath.Join(testDataDir, "bootstrapped")} {
+		assert.FileExists(t, u.path(path))
+	}
+}
+
+func TestUninstallKeepImages(t *testing.T) {
+	u, runner := newTestUninstaller(t, Options{KeepImages: true})
+	runner.Fail = wipeRuntime(u, nil)
+
+	_, err := u.Uninstall(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		u.path("/usr/local/bin/k3s-killall.sh"),
+		u.path("/usr/local/bin/k3s-uninstall.sh"),
+	}, runner.Calls())
+
+	assert.FileExists(t, u.path(filepath.Join(testImagesDir, "k3s.tar")))
+	assert.FileExists(t, u.path(filepath.Join(testDataDir, imagesDir, "llmos.tar")))
+	assert.NoDirExists(t, u.path("/var/lib/.k3s-images"))
+	assert.NoFileExists(t, u.path(testConfigFile))
+	assert.NoFileExists(t, u.path(filepath.Join(testDataDir, "bootstrapped")))
+}
+
+func TestUninstallRestoresImagesOnFailure(t *testing.T) {
+	u, runner := newTestUninstaller(t, Options{KeepImages: true})
+	runner.Fail = wipeRuntime(u, errors.New("exit status 1"))
+
+	actions, err := u.Uninstall(context.Background())
+	assert.ErrorContains(t, err, "exit status 1")
+	assert.Equal(t, []string{
+		"ran " + u.path("/usr/local/bin/k3s-killall.sh"),
+		"moved " + u.path(testImagesDir) + " to " + u.path("/var/lib/.k3s-images"),
+		"moved " + u.path("/var/lib/.k3s-images") + " to " + u.path(testImagesDir),
+	}, actions)
+
+	assert.FileExists(t, u.path(filepath.Join(testImagesDir, "k3s.tar")))
+	assert.NoDirExists(t, u.path("/var/lib/.k3s-images"))
+	// the files and the data dir are kept for the next attempt
+	assert.FileExists(t, u.path(testConfigFile))
+	assert.FileExists(t, u.path(filepath.Join(testDataDir, "bootstrapped")))
+}
+
+func TestUninstallRemovesImages(t *testing.T) {
+	u, runner := newTestUninstaller(t, Options{})
+	runner.Fail = wipeRuntime(u, nil)
+
+	_, err := u.Uninstall(context.Background())
+	require.NoError(t, err)
+	assert.NoDirExists(t, u.path(testImagesDir))
+	assert.NoDirExists(t, u.path(testDataDir))
+	assert.NoFileExists(t, u.path(testConfigFile))
+}
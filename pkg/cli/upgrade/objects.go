@@ -0,0 +1,118 @@
+package upgrade
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/llmos-ai/llmos/pkg/bootstrap/config"
+	"github.com/llmos-ai/llmos/pkg/bootstrap/images"
+	"github.com/llmos-ai/llmos/pkg/constants"
+)
+
+const (
+	sucNamespace      = "system-upgrade"
+	sucServiceAccount = "system-upgrade"
+	controlPlaneLabel = "node-role.kubernetes.io/control-plane"
+)
+
+var (
+	planGVR      = schema.GroupVersionResource{Group: "upgrade.cattle.io", Version: "v1", Resource: "plans"}
+	helmChartGVR = schema.GroupVersionResource{Group: "helm.cattle.io", Version: "v1", Resource: "helmcharts"}
+)
+
+func serverPlanName(runtime config.Runtime) string {
+	return fmt.Sprintf("llmos-%s-server", runtime)
+}
+
+func agentPlanName(runtime config.Runtime) string {
+	return fmt.Sprintf("llmos-%s-agent", runtime)
+}
+
+// runtimePlans returns the system-upgrade-controller plans upgrading the control-plane nodes one by one first,
+// the agent plan waits for the server plan to complete by its prepare step
+func runtimePlans(cfg *config.Config, runtime config.Runtime, k8sVersion string) []*unstructured.Unstructured {
+	image := images.GetRuntimeUpgradeImage(cfg.GlobalSystemImageRegistry, cfg.Mirror, runtime)
+
+	server := plan(serverPlanName(runtime), k8sVersion, map[string]interface{}{
+		"concurrency":        int64(1),
+		"cordon":             true,
+		"serviceAccountName": sucServiceAccount,
+		"nodeSelector":       nodeSelector("Exists"),
+		"tolerations": []interface{}{
+			map[string]interface{}{
+				"operator": "Exists",
+			},
+		},
+		"upgrade": map[string]interface{}{
+			"image": image,
+		},
+	})
+
+	agent := plan(agentPlanName(runtime), k8sVersion, map[string]interface{}{
+		"concurrency":        int64(1),
+		"serviceAccountName": sucServiceAccount,
+		"nodeSelector":       nodeSelector("DoesNotExist"),
+		"prepare": map[string]interface{}{
+			"image": image,
+			"args":  []interface{}{"prepare", serverPlanName(runtime)},
+		},
+		"drain": map[string]interface{}{
+			"force":                    true,
+			"skipWaitForDeleteTimeout": int64(60),
+		},
+		"upgrade": map[string]interface{}{
+			"image": image,
+		},
+	})
+
+	return []*unstructured.Unstructured{server, agent}
+}
+
+func plan(name, version string, spec map[string]interface{}) *unstructured.Unstructured {
+	spec["version"] = version
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": planGVR.GroupVersion().String(),
+			"kind":       "Plan",
+			"metadata": map[string]interface{}{
+				"name":      name,
+				"namespace": sucNamespace,
+				"labels": map[string]interface{}{
+					"llmos.ai/managed": "true",
+				},
+			},
+			"spec": spec,
+		},
+	}
+}
+
+func nodeSelector(operator string) map[string]interface{} {
+	return map[string]interface{}{
+		"matchExpressions": []interface{}{
+			map[string]interface{}{
+				"key":      controlPlaneLabel,
+				"operator": operator,
+			},
+		},
+	}
+}
+
+// operatorChart returns the fields of the llmos-operator HelmChart to update, helm-controller upgrades the release
+func operatorChart(operatorVersion string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": helmChartGVR.GroupVersion().String(),
+			"kind":       "HelmChart",
+			"metadata": map[string]interface{}{
+				"name":      constants.LLMOSOperatorName,
+				"namespace": constants.SystemNamespace,
+			},
+			"spec": map[string]interface{}{
+				"version": strings.TrimPrefix(operatorVersion, "v"),
+			},
+		},
+	}
+}
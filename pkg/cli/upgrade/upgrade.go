@@ -0,0 +1,256 @@
+package upgrade
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/llmos-ai/llmos/utils/yaml"
+	"github.com/sirupsen/logrus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/llmos-ai/llmos/pkg/bootstrap"
+	"github.com/llmos-ai/llmos/pkg/bootstrap/config"
+	"github.com/llmos-ai/llmos/pkg/bootstrap/kubectl"
+	"github.com/llmos-ai/llmos/pkg/bootstrap/runtime"
+	"github.com/llmos-ai/llmos/pkg/bootstrap/version"
+	"github.com/llmos-ai/llmos/pkg/constants"
+)
+
+type Options struct {
+	DataDir           string
+	KubernetesVersion string
+	OperatorVersion   string
+	DryRun            bool
+	Timeout           time.Duration
+	Interval          time.Duration
+}
+
+// Upgrader upgrades the k8s runtime by the system-upgrade-controller plans and the llmos-operator by its HelmChart,
+// the bootstrapped stamp is updated once the cluster reports the new versions
+type Upgrader struct {
+	opts    Options
+	boot    *bootstrap.LLMOS
+	cfg     config.Config
+	runtime config.Runtime
+
+	K8s     kubernetes.Interface
+	Dynamic dynamic.Interface
+	Out     io.Writer
+}
+
+func New(opts Options) (*Upgrader, error) {
+	if opts.KubernetesVersion == "" && opts.OperatorVersion == "" {
+		return nil, fmt.Errorf("at least one of the kubernetes version and the operator version must be specified")
+	}
+	if opts.Interval == 0 {
+		opts.Interval = 5 * time.Second
+	}
+
+	boot := bootstrap.New(bootstrap.Config{DataDir: opts.DataDir})
+	cfg, err := boot.LoadDoneConfig()
+	if err != nil {
+		return nil, fmt.Errorf("the node is not bootstrapped: %w", err)
+	}
+	if cfg.Role == config.AgentRole {
+		return nil, fmt.Errorf("upgrade must be run on the server nodes")
+	}
+
+	return &Upgrader{
+		opts:    opts,
+		boot:    boot,
+		cfg:     cfg,
+		runtime: runtime.Detect(cfg.KubernetesVersion),
+		Out:     os.Stdout,
+	}, nil
+}
+
+func (u *Upgrader) Upgrade(ctx context.Context) error {
+	k8sVersion, operatorVersion, err := u.resolveVersions()
+	if err != nil {
+		return err
+	}
+
+	var objs []*unstructured.Unstructured
+	if k8sVersion != "" {
+		objs = append(objs, runtimePlans(&u.cfg, u.runtime, k8sVersion)...)
+	}
+	if operatorVersion != "" {
+		objs = append(objs, operatorChart(operatorVersion))
+	}
+
+	if u.opts.DryRun {
+		return u.print(objs)
+	}
+
+	if err = u.initClients(); err != nil {
+		return err
+	}
+
+	for _, obj := range objs {
+		if err = u.apply(ctx, obj); err != nil {
+			return err
+		}
+	}
+
+	if err = u.wait(ctx, k8sVersion, operatorVersion); err != nil {
+		return fmt.Errorf("waiting for the upgrade to complete: %w", err)
+	}
+
+	if k8sVersion != "" {
+		u.cfg.KubernetesVersion = k8sVersion
+	}
+	if operatorVersion != "" {
+		u.cfg.LLMOSOperatorVersion = operatorVersion
+	}
+	if err = u.boot.SaveDoneConfig(u.cfg); err != nil {
+		return fmt.Errorf("updating bootstrapped stamp: %w", err)
+	}
+
+	logrus.Infof("Successfully upgraded LLMOS %s(%s)", u.cfg.LLMOSOperatorVersion, u.cfg.KubernetesVersion)
+	return nil
+}
+
+func (u *Upgrader) resolveVersions() (k8sVersion, operatorVersion string, err error) {
+	if u.opts.KubernetesVersion != "" {
+		if k8sVersion, err = version.K8sVersion(u.opts.KubernetesVersion); err != nil {
+			return "", "", err
+		}
+		if rt := config.GetRuntime(k8sVersion); rt != u.runtime {
+			return "", "", fmt.Errorf("can not upgrade the k8s runtime from %s to %s", u.runtime, rt)
+		}
+	}
+
+	if u.opts.OperatorVersion != "" {
+		if operatorVersion, err = version.OperatorVersion(u.cfg.ChartRepo, u.opts.OperatorVersion); err != nil {
+			return "", "", err
+		}
+	}
+	return k8sVersion, operatorVersion, nil
+}
+
+func (u *Upgrader) print(objs []*unstructured.Unstructured) error {
+	runtimeObjs := make([]k8sruntime.Object, 0, len(objs))
+	for _, obj := range objs {
+		runtimeObjs = append(runtimeObjs, obj)
+	}
+	data, err := yaml.ToBytes(runtimeObjs)
+	if err != nil {
+		return err
+	}
+	_, err = u.Out.Write(data)
+	return err
+}
+
+func (u *Upgrader) initClients() error {
+	if u.K8s != nil && u.Dynamic != nil {
+		return nil
+	}
+
+	kubeconfig, err := kubectl.GetKubeconfig("")
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(kubeconfig)
+	if err != nil {
+		return err
+	}
+	restConfig, err := clientcmd.RESTConfigFromKubeConfig(data)
+	if err != nil {
+		return err
+	}
+
+	if u.K8s, err = kubernetes.NewForConfig(restConfig); err != nil {
+		return err
+	}
+	u.Dynamic, err = dynamic.NewForConfig(restConfig)
+	return err
+}
+
+// apply creates or updates the upgrade plans, the HelmChart only gets its version patched
+func (u *Upgrader) apply(ctx context.Context, obj *unstructured.Unstructured) error {
+	if obj.GetKind() == "HelmChart" {
+		patch, err := json.Marshal(map[string]interface{}{"spec": obj.Object["spec"]})
+		if err != nil {
+			return err
+		}
+		logrus.Infof("Updating HelmChart %s/%s", obj.GetNamespace(), obj.GetName())
+		_, err = u.Dynamic.Resource(helmChartGVR).Namespace(obj.GetNamespace()).Patch(ctx, obj.GetName(),
+			types.MergePatchType, patch, metav1.PatchOptions{})
+		if err != nil {
+			return fmt.Errorf("updating HelmChart %s: %w", obj.GetName(), err)
+		}
+		return nil
+	}
+
+	client := u.Dynamic.Resource(planGVR).Namespace(obj.GetNamespace())
+	existing, err := client.Get(ctx, obj.GetName(), metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		logrus.Infof("Creating upgrade plan %s/%s", obj.GetNamespace(), obj.GetName())
+		_, err = client.Create(ctx, obj, metav1.CreateOptions{})
+	} else if err == nil {
+		logrus.Infof("Updating upgrade plan %s/%s", obj.GetNamespace(), obj.GetName())
+		obj.SetResourceVersion(existing.GetResourceVersion())
+		_, err = client.Update(ctx, obj, metav1.UpdateOptions{})
+	}
+	if err != nil {
+		return fmt.Errorf("applying upgrade plan %s: %w", obj.GetName(), err)
+	}
+	return nil
+}
+
+// wait tracks the upgrade until every node runs the new kubelet and the operator release is upgraded
+func (u *Upgrader) wait(ctx context.Context, k8sVersion, operatorVersion string) error {
+	if u.opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, u.opts.Timeout)
+		defer cancel()
+	}
+
+	var lastProgress string
+	return wait.PollUntilContextCancel(ctx, u.opts.Interval, true, func(ctx context.Context) (bool, error) {
+		var progress []string
+		done := true
+
+		if k8sVersion != "" {
+			nodes, err := u.K8s.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+			if err != nil {
+				logrus.Debugf("failed to list nodes: %v", err)
+				return false, nil
+			}
+			upgraded := 0
+			for _, node := range nodes.Items {
+				if node.Status.NodeInfo.KubeletVersion == k8sVersion {
+					upgraded++
+				}
+			}
+			done = done && upgraded == len(nodes.Items)
+			progress = append(progress, fmt.Sprintf("%d/%d nodes upgraded to %s", upgraded, len(nodes.Items), k8sVersion))
+		}
+
+		if operatorVersion != "" {
+			current := bootstrap.GetOperatorVersion(ctx, u.K8s)
+			upgraded := current == "v"+strings.TrimPrefix(operatorVersion, "v")
+			done = done && upgraded
+			progress = append(progress, fmt.Sprintf("%s %s", constants.LLMOSOperatorName, current))
+		}
+
+		if p := strings.Join(progress, ", "); p != lastProgress {
+			logrus.Infof("Upgrade progress: %s", p)
+			lastProgress = p
+		}
+		return done, nil
+	})
+}
@@ -0,0 +1,181 @@
+package upgrade
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/llmos-ai/llmos/pkg/bootstrap"
+	"github.com/llmos-ai/llmos/pkg/bootstrap/config"
+	"github.com/llmos-ai/llmos/pkg/constants"
+)
+
+const (
+	oldK8sVersion = "v1.31.3+k3s1"
+	newK8sVersion = "v1.31.4+k3s1"
+)
+
+func newTestUpgrader(t *testing.T, opts Options) *Upgrader {
+	opts.DataDir = t.TempDir()
+	opts.Interval = 10 * time.Millisecond
+	opts.Timeout = 5 * time.Second
+
+	cfg := config.Config{
+		KubernetesVersion:    oldK8sVersion,
+		LLMOSOperatorVersion: "v0.1.0",
+	}
+	cfg.Role = config.ClusterInitRole
+	require.NoError(t, bootstrap.New(bootstrap.Config{DataDir: opts.DataDir}).SaveDoneConfig(cfg))
+
+	u, err := New(opts)
+	require.NoError(t, err)
+	return u
+}
+
+func node(name, kubeletVersion string) *corev1.Node {
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status: corev1.NodeStatus{
+			NodeInfo: corev1.NodeSystemInfo{KubeletVersion: kubeletVersion},
+		},
+	}
+}
+
+func helmRelease(t *testing.T, chartVersion string) *corev1.Secret {
+	release, err := json.Marshal(map[string]interface{}{
+		"chart": map[string]interface{}{
+			"metadata": map[string]interface{}{"version": chartVersion},
+		},
+	})
+	require.NoError(t, err)
+
+	buf := &bytes.Buffer{}
+	gz := gzip.NewWriter(buf)
+	_, err = gz.Write(release)
+	require.NoError(t, err)
+	require.NoError(t, gz.Close())
+
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "sh.helm.release.v1.llmos-operator.v2",
+			Namespace: constants.SystemNamespace,
+			Labels:    map[string]string{"name": constants.LLMOSOperatorName, "status": "deployed"},
+		},
+		Data: map[string][]byte{
+			"release": []byte(base64.StdEncoding.EncodeToString(buf.Bytes())),
+		},
+	}
+}
+
+func newDynamicClient(objs ...k8sruntime.Object) *dynamicfake.FakeDynamicClient {
+	listKinds := map[schema.GroupVersionResource]string{
+		planGVR:      "PlanList",
+		helmChartGVR: "HelmChartList",
+	}
+	return dynamicfake.NewSimpleDynamicClientWithCustomListKinds(k8sruntime.NewScheme(), listKinds, objs...)
+}
+
+func TestDryRun(t *testing.T) {
+	u := newTestUpgrader(t, Options{
+		KubernetesVersion: newK8sVersion,
+		OperatorVersion:   "v0.2.0",
+		DryRun:            true,
+	})
+	out := &bytes.Buffer{}
+	u.Out = out
+
+	require.NoError(t, u.Upgrade(context.Background()))
+	assert.Contains(t, out.String(), "name: llmos-k3s-server")
+	assert.Contains(t, out.String(), "name: llmos-k3s-agent")
+	assert.Contains(t, out.String(), "image: docker.io/rancher/k3s-upgrade")
+	assert.Contains(t, out.String(), "version: "+newK8sVersion)
+	assert.Contains(t, out.String(), "kind: HelmChart")
+	assert.Contains(t, out.String(), "version: 0.2.0")
+
+	// the stamp is untouched
+	cfg, err := u.boot.LoadDoneConfig()
+	require.NoError(t, err)
+	assert.Equal(t, oldK8sVersion, cfg.KubernetesVersion)
+}
+
+func TestUpgrade(t *testing.T) {
+	u := newTestUpgrader(t, Options{
+		KubernetesVersion: newK8sVersion,
+		OperatorVersion:   "v0.2.0",
+	})
+	u.K8s = fake.NewSimpleClientset(
+		node("server", newK8sVersion),
+		node("agent", newK8sVersion),
+		helmRelease(t, "0.2.0"),
+	)
+	existingPlan := runtimePlans(&u.cfg, u.runtime, oldK8sVersion)[0]
+	u.Dynamic = newDynamicClient(existingPlan, &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "helm.cattle.io/v1",
+			"kind":       "HelmChart",
+			"metadata": map[string]interface{}{
+				"name":      constants.LLMOSOperatorName,
+				"namespace": constants.SystemNamespace,
+			},
+			"spec": map[string]interface{}{
+				"chart":   constants.LLMOSOperatorName,
+				"version": "0.1.0",
+			},
+		},
+	})
+
+	require.NoError(t, u.Upgrade(context.Background()))
+
+	ctx := context.Background()
+	for _, name := range []string{"llmos-k3s-server", "llmos-k3s-agent"} {
+		plan, err := u.Dynamic.Resource(planGVR).Namespace(sucNamespace).Get(ctx, name, metav1.GetOptions{})
+		require.NoError(t, err)
+		version, _, _ := unstructured.NestedString(plan.Object, "spec", "version")
+		assert.Equal(t, newK8sVersion, version)
+	}
+
+	chart, err := u.Dynamic.Resource(helmChartGVR).Namespace(constants.SystemNamespace).Get(ctx,
+		constants.LLMOSOperatorName, metav1.GetOptions{})
+	require.NoError(t, err)
+	version, _, _ := unstructured.NestedString(chart.Object, "spec", "version")
+	assert.Equal(t, "0.2.0", version)
+	name, _, _ := unstructured.NestedString(chart.Object, "spec", "chart")
+	assert.Equal(t, constants.LLMOSOperatorName, name)
+
+	cfg, err := u.boot.LoadDoneConfig()
+	require.NoError(t, err)
+	assert.Equal(t, newK8sVersion, cfg.KubernetesVersion)
+	assert.Equal(t, "v0.2.0", cfg.LLMOSOperatorVersion)
+}
+
+func TestUpgradeTimeout(t *testing.T) {
+	u := newTestUpgrader(t, Options{KubernetesVersion: newK8sVersion})
+	u.opts.Timeout = 50 * time.Millisecond
+	u.K8s = fake.NewSimpleClientset(node("server", newK8sVersion), node("agent", oldK8sVersion))
+	u.Dynamic = newDynamicClient()
+
+	assert.Error(t, u.Upgrade(context.Background()))
+
+	cfg, err := u.boot.LoadDoneConfig()
+	require.NoError(t, err)
+	assert.Equal(t, oldK8sVersion, cfg.KubernetesVersion)
+}
+
+func TestUpgradeRuntimeMismatch(t *testing.T) {
+	u := newTestUpgrader(t, Options{KubernetesVersion: "v1.31.4+rke2r1", DryRun: true})
+	assert.Error(t, u.Upgrade(context.Background()))
+}
@@ -0,0 +1,32 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/llmos-ai/llmos/pkg/utils/redact"
+)
+
+// Runner runs a command on the node, the commands take it as a field so that the tests can record
+// them with a commandtest.Recorder instead
+type Runner func(ctx context.Context, name string, args ...string) error
+
+// Run is the Runner executing the command with the output of llmos, the error holds the command line
+// with the secrets redacted
+func Run(ctx context.Context, name string, args ...string) error {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("running %s: %w", Line(name, redact.LogArgs(args)...), err)
+	}
+	return nil
+}
+
+// Line returns the command line of the command
+func Line(name string, args ...string) string {
+	return strings.Join(append([]string{name}, args...), " ")
+}
@@ -0,0 +1,36 @@
+// Package commandtest provides the command runner of the tests
+package commandtest
+
+import (
+	"context"
+	"sync"
+
+	"github.com/llmos-ai/llmos/pkg/utils/command"
+)
+
+// Recorder is the command.Runner of the tests, it records the command lines without running them
+type Recorder struct {
+	mu    sync.Mutex
+	calls []string
+	// Fail returns the error of the command, the commands succeed if it is nil
+	Fail func(name string, args ...string) error
+}
+
+func (r *Recorder) Run(_ context.Context, name string, args ...string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, command.Line(name, args...))
+	if r.Fail != nil {
+		return r.Fail(name, args...)
+	}
+	return nil
+}
+
+// Calls returns the recorded command lines and resets them
+func (r *Recorder) Calls() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	calls := r.calls
+	r.calls = nil
+	return calls
+}
@@ -0,0 +1,203 @@
+package crypt
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+const (
+	// KeyFile is the node key file in the llmos data dir
+	KeyFile = "node.key"
+
+	keySize = 32
+	// prefix marks the encrypted data, the data without it is read as plain text written by older releases
+	prefix = "llmos:enc:v1:"
+	// syntheticNonceLabel derives the key of the deterministic nonces from the node key
+	syntheticNonceLabel = "llmos synthetic nonce"
+)
+
+// KeyProvider provides the node key encrypting the secrets stored in the llmos data dir,
+// a TPM-like backend can implement it to seal the key instead of storing it in a file
+type KeyProvider interface {
+	Key() ([]byte, error)
+}
+
+// FileKeyProvider stores a random node key in a file only readable by its owner
+type FileKeyProvider struct {
+	Path string
+}
+
+// Key reads the node key and generates it on the first use
+func (f *FileKeyProvider) Key() ([]byte, error) {
+	key, err := f.read()
+	if errors.Is(err, fs.ErrNotExist) {
+		if err = f.generate(); err != nil && !errors.Is(err, fs.ErrExist) {
+			return nil, err
+		}
+		key, err = f.read()
+	}
+	return key, err
+}
+
+func (f *FileKeyProvider) read() ([]byte, error) {
+	info, err := os.Stat(f.Path)
+	if err != nil {
+		return nil, err
+	}
+	if info.Mode().Perm()&0077 != 0 {
+		return nil, fmt.Errorf("node key %s must only be accessible by its owner, got permissions %s",
+			f.Path, info.Mode().Perm())
+	}
+
+	data, err := os.ReadFile(f.Path)
+	if err != nil {
+		return nil, err
+	}
+	key, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace(data)))
+	if err != nil || len(key) != keySize {
+		return nil, fmt.Errorf("invalid node key %s", f.Path)
+	}
+	return key, nil
+}
+
+// generate creates the key exclusively so concurrent callers end up with the same key
+func (f *FileKeyProvider) generate() error {
+	key := make([]byte, keySize)
+	if _, err := rand.Read(key); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(f.Path), 0700); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(f.Path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err = file.WriteString(base64.StdEncoding.EncodeToString(key) + "\n"); err != nil {
+		_ = file.Close()
+		_ = os.Remove(f.Path)
+		return err
+	}
+	return file.Close()
+}
+
+// Box encrypts and decrypts the secrets with AES-256-GCM using the node key
+type Box struct {
+	provider KeyProvider
+}
+
+func New(provider KeyProvider) *Box {
+	return &Box{provider: provider}
+}
+
+// ForDataDir returns the box using the node key file of the llmos data dir
+func ForDataDir(dataDir string) *Box {
+	return New(&FileKeyProvider{Path: filepath.Join(dataDir, KeyFile)})
+}
+
+// IsEncrypted returns whether the data is encrypted by a box
+func IsEncrypted(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(prefix))
+}
+
+// Encrypt returns the encrypted data as a single line of text
+func (b *Box) Encrypt(plaintext []byte) ([]byte, error) {
+	aead, err := b.aead()
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	sealed := aead.Seal(nonce, nonce, plaintext, nil)
+	return []byte(prefix + base64.StdEncoding.EncodeToString(sealed)), nil
+}
+
+// EncryptDeterministic returns the same encrypted data for the same plaintext and node key, e.g. for
+// the files of a plan whose checksum must not change between generations. The nonce is derived from the
+// plaintext with HMAC-SHA256 like a synthetic IV, so the equal plaintexts are the only ones sharing a
+// nonce and the data is decrypted by Decrypt
+func (b *Box) EncryptDeterministic(plaintext []byte) ([]byte, error) {
+	key, err := b.provider.Key()
+	if err != nil {
+		return nil, fmt.Errorf("loading node key: %w", err)
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	// the nonce key is derived from the node key so that the encryption key is not reused for the HMAC
+	nonceKey := hmac.New(sha256.New, key)
+	nonceKey.Write([]byte(syntheticNonceLabel))
+	mac := hmac.New(sha256.New, nonceKey.Sum(nil))
+	mac.Write(plaintext)
+	nonce := mac.Sum(nil)[:aead.NonceSize()]
+
+	sealed := aead.Seal(nonce, nonce, plaintext, nil)
+	return []byte(prefix + base64.StdEncoding.EncodeToString(sealed)), nil
+}
+
+// Decrypt decrypts the data encrypted by Encrypt, the data not encrypted is returned as is
+func (b *Box) Decrypt(data []byte) ([]byte, error) {
+	if !IsEncrypted(data) {
+		return data, nil
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace(data[len(prefix):])))
+	if err != nil {
+		return nil, fmt.Errorf("decoding encrypted data: %w", err)
+	}
+	aead, err := b.aead()
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return nil, fmt.Errorf("encrypted data is truncated")
+	}
+
+	plaintext, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], nil)
+	if err != nil {
+		return nil, fmt.Errorf("decrypting data, the node key may have changed: %w", err)
+	}
+	return plaintext, nil
+}
+
+// ReadFile reads and decrypts the file
+func (b *Box) ReadFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return b.Decrypt(data)
+}
+
+func (b *Box) aead() (cipher.AEAD, error) {
+	key, err := b.provider.Key()
+	if err != nil {
+		return nil, fmt.Errorf("loading node key: %w", err)
+	}
+	return newAEAD(key)
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,90 @@
+package crypt
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type staticKey []byte
+
+func (s staticKey) Key() ([]byte, error) {
+	return s, nil
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	dir := t.TempDir()
+	box := ForDataDir(dir)
+
+	encrypted, err := box.Encrypt([]byte("K10abc::server:secret\n"))
+	require.NoError(t, err)
+	assert.True(t, IsEncrypted(encrypted))
+	assert.NotContains(t, string(encrypted), "secret")
+
+	info, err := os.Stat(filepath.Join(dir, KeyFile))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	// a new box reads the same node key
+	decrypted, err := ForDataDir(dir).Decrypt(append(encrypted, '\n'))
+	require.NoError(t, err)
+	assert.Equal(t, "K10abc::server:secret\n", string(decrypted))
+
+	// the plain text written by older releases is returned as is
+	plain, err := box.Decrypt([]byte("K10abc::server:secret\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "K10abc::server:secret\n", string(plain))
+
+	_, err = ForDataDir(t.TempDir()).Decrypt(encrypted)
+	assert.ErrorContains(t, err, "node key may have changed")
+}
+
+func TestKeyProvider(t *testing.T) {
+	box := New(staticKey(bytes.Repeat([]byte{1}, keySize)))
+	encrypted, err := box.Encrypt([]byte("token"))
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(path, encrypted, 0600))
+	decrypted, err := New(staticKey(bytes.Repeat([]byte{1}, keySize))).ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "token", string(decrypted))
+}
+
+func TestFileKeyProviderPermissions(t *testing.T) {
+	dir := t.TempDir()
+	provider := &FileKeyProvider{Path: filepath.Join(dir, KeyFile)}
+	_, err := provider.Key()
+	require.NoError(t, err)
+
+	require.NoError(t, os.Chmod(provider.Path, 0644))
+	_, err = provider.Key()
+	assert.ErrorContains(t, err, "must only be accessible by its owner")
+}
+
+func TestEncryptDeterministic(t *testing.T) {
+	box := New(staticKey(bytes.Repeat([]byte{1}, keySize)))
+	first, err := box.EncryptDeterministic([]byte("K10abc::server:secret\n"))
+	require.NoError(t, err)
+	second, err := box.EncryptDeterministic([]byte("K10abc::server:secret\n"))
+	require.NoError(t, err)
+	assert.Equal(t, first, second)
+	assert.NotContains(t, string(first), "secret")
+
+	other, err := box.EncryptDeterministic([]byte("K10abc::server:other\n"))
+	require.NoError(t, err)
+	assert.NotEqual(t, first[:len(prefix)+16], other[:len(prefix)+16], "the nonce depends on the plaintext")
+
+	decrypted, err := box.Decrypt(first)
+	require.NoError(t, err)
+	assert.Equal(t, "K10abc::server:secret\n", string(decrypted))
+
+	otherKey, err := New(staticKey(bytes.Repeat([]byte{2}, keySize))).EncryptDeterministic(
+		[]byte("K10abc::server:secret\n"))
+	require.NoError(t, err)
+	assert.NotEqual(t, first, otherKey)
+}
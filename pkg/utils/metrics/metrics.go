@@ -0,0 +1,138 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "llmos"
+
+// The instruction types of the plan
+const (
+	InstructionOneTime  = "one-time"
+	InstructionPeriodic = "periodic"
+)
+
+// Registry holds only the llmos metrics so that it can be written as a node-exporter textfile
+// without clashing with the go and process metrics of the node-exporter itself
+var Registry = prometheus.NewRegistry()
+
+var (
+	instructionDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "instruction_duration_seconds",
+		Help:      "Duration of the plan instructions.",
+		Buckets:   []float64{0.1, 0.5, 1, 5, 10, 30, 60, 120, 300, 600, 1200},
+	}, []string{"instruction", "type"})
+	instructionExitCode = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "instruction_exit_code",
+		Help:      "Exit code of the last run of the plan instructions, -1 if the command could not be run.",
+	}, []string{"instruction", "type"})
+	instructionFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "instruction_failures_total",
+		Help:      "Number of failed runs of the plan instructions.",
+	}, []string{"instruction", "type"})
+
+	bootstrapAttempts = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "bootstrap_attempts_total",
+		Help:      "Number of bootstrap attempts by result.",
+	}, []string{"result"})
+	bootstrapDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "bootstrap_duration_seconds",
+		Help:      "Duration of the bootstrap attempts.",
+		Buckets:   []float64{10, 30, 60, 120, 300, 600, 1200, 1800, 3600},
+	})
+
+	imagePullBytes = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "image_pull_bytes_total",
+		Help:      "Compressed size of the image layers pulled from the registries.",
+	}, []string{"image"})
+	imagePullDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "image_pull_duration_seconds",
+		Help:      "Duration of pulling and extracting the instruction images from the registries.",
+		Buckets:   []float64{1, 5, 10, 30, 60, 120, 300, 600},
+	}, []string{"image"})
+
+	probeHealthy = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "probe_healthy",
+		Help:      "Whether the probe is healthy (1) or not (0).",
+	}, []string{"probe"})
+)
+
+func init() {
+	Registry.MustRegister(
+		instructionDuration,
+		instructionExitCode,
+		instructionFailures,
+		bootstrapAttempts,
+		bootstrapDuration,
+		imagePullBytes,
+		imagePullDuration,
+		probeHealthy,
+	)
+}
+
+// ObserveInstruction records a run of an instruction, the run failed if the exit code is not zero
+func ObserveInstruction(instruction, instructionType string, duration time.Duration, exitCode int) {
+	instructionDuration.WithLabelValues(instruction, instructionType).Observe(duration.Seconds())
+	instructionExitCode.WithLabelValues(instruction, instructionType).Set(float64(exitCode))
+	if exitCode != 0 {
+		instructionFailures.WithLabelValues(instruction, instructionType).Inc()
+	}
+}
+
+// ObserveBootstrap records a bootstrap attempt
+func ObserveBootstrap(duration time.Duration, err error) {
+	result := "success"
+	if err != nil {
+		result = "failure"
+	}
+	bootstrapAttempts.WithLabelValues(result).Inc()
+	bootstrapDuration.Observe(duration.Seconds())
+}
+
+// ObserveImagePull records an image pulled from a registry
+func ObserveImagePull(image string, bytes int64, duration time.Duration) {
+	imagePullBytes.WithLabelValues(image).Add(float64(bytes))
+	imagePullDuration.WithLabelValues(image).Observe(duration.Seconds())
+}
+
+// SetProbeHealthy records the health of a probe
+func SetProbeHealthy(probe string, healthy bool) {
+	value := 0.0
+	if healthy {
+		value = 1
+	}
+	probeHealthy.WithLabelValues(probe).Set(value)
+}
+
+// Handler serves the llmos metrics along with the go and process metrics of the default registry
+func Handler() http.Handler {
+	return promhttp.HandlerFor(prometheus.Gatherers{Registry, prometheus.DefaultGatherer}, promhttp.HandlerOpts{})
+}
+
+// WriteTextfile writes the llmos metrics to the file in the text format read by the textfile
+// collector of the node-exporter, the file is replaced atomically
+func WriteTextfile(filename string) error {
+	return prometheus.WriteToTextfile(filename, Registry)
+}
+
+// InstructionName returns the name of the instruction in the metrics, the unnamed instructions are
+// identified by their index in the plan
+func InstructionName(name string, index int) string {
+	if name != "" {
+		return name
+	}
+	return strconv.Itoa(index)
+}
@@ -0,0 +1,47 @@
+package metrics
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestObserveInstruction(t *testing.T) {
+	ObserveInstruction("install-k3s", InstructionOneTime, time.Second, 0)
+	ObserveInstruction("sync-images", InstructionPeriodic, time.Second, 2)
+	ObserveInstruction("sync-images", InstructionPeriodic, time.Second, 0)
+
+	assert.Equal(t, 0.0, testutil.ToFloat64(instructionExitCode.WithLabelValues("install-k3s", InstructionOneTime)))
+	assert.Equal(t, 0.0, testutil.ToFloat64(instructionExitCode.WithLabelValues("sync-images", InstructionPeriodic)))
+	assert.Equal(t, 1.0, testutil.ToFloat64(instructionFailures.WithLabelValues("sync-images", InstructionPeriodic)))
+	assert.Equal(t, 2, testutil.CollectAndCount(instructionDuration))
+}
+
+func TestWriteTextfile(t *testing.T) {
+	ObserveBootstrap(time.Minute, errors.New("connection refused"))
+	ObserveBootstrap(time.Minute, nil)
+	ObserveImagePull("docker.io/llmos/system-agent-installer:v0.1.0", 1024, time.Second)
+	SetProbeHealthy("kubelet", true)
+
+	filename := filepath.Join(t.TempDir(), "llmos.prom")
+	require.NoError(t, WriteTextfile(filename))
+	data, err := os.ReadFile(filename)
+	require.NoError(t, err)
+
+	assert.Contains(t, string(data), `llmos_bootstrap_attempts_total{result="failure"} 1`)
+	assert.Contains(t, string(data), `llmos_bootstrap_attempts_total{result="success"} 1`)
+	assert.Contains(t, string(data), `llmos_image_pull_bytes_total{image="docker.io/llmos/system-agent-installer:v0.1.0"} 1024`)
+	assert.Contains(t, string(data), `llmos_probe_healthy{probe="kubelet"} 1`)
+	assert.NotContains(t, string(data), "go_goroutines", "the textfile only holds the llmos metrics")
+}
+
+func TestInstructionName(t *testing.T) {
+	assert.Equal(t, "install", InstructionName("install", 3))
+	assert.Equal(t, "3", InstructionName("", 3))
+}
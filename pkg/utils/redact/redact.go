@@ -0,0 +1,242 @@
+package redact
+
+import (
+	"regexp"
+	"strings"
+	"sync/atomic"
+
+	"sigs.k8s.io/yaml"
+)
+
+// Placeholder replaces the redacted secrets
+const Placeholder = "<redacted>"
+
+var (
+	// insecureDebugSecrets keeps the secrets in the stamps, plan files and logs for local troubleshooting
+	insecureDebugSecrets atomic.Bool
+
+	// sensitiveKeySuffixes matches the normalized keys holding secrets, e.g. token, agent-token,
+	// bootstrapPassword, etcd-s3-secret-key, the registry auth fields and the Authorization, Proxy-Authorization
+	// and X-API-Key headers of the webhooks and the probes
+	sensitiveKeySuffixes = []string{"token", "password", "secret", "secretkey", "passphrase", "authorization",
+		"apikey"}
+	sensitiveKeys = map[string]bool{"auth": true, "identitytoken": true, "cookie": true}
+
+	pemBlock   = regexp.MustCompile(`(?s)-----BEGIN ([A-Z0-9 ]+)-----.*?-----END ([A-Z0-9 ]+)-----`)
+	urlSecret  = regexp.MustCompile(`(://[^:/@\s]+:)[^@\s]+@`)
+	textSecret = regexp.MustCompile(
+		`(?i)((?:token|password|secret|secret[-_]key|auth)["']?\s*[:=]\s*["']?)[^"'\s,}]+`)
+	argSecret = regexp.MustCompile(`(?i)^(--?[a-z0-9-]*(?:token|password|secret|secret-key)=).+$`)
+	separator = regexp.MustCompile(`(?m)^---\s*$`)
+)
+
+// SetInsecureDebugSecrets disables the redaction of the stamps, plan files and logs, the support bundle
+// is always redacted since it is meant to be shared
+func SetInsecureDebugSecrets(enabled bool) {
+	insecureDebugSecrets.Store(enabled)
+}
+
+// Enabled returns whether the secrets are redacted before they are persisted or logged
+func Enabled() bool {
+	return !insecureDebugSecrets.Load()
+}
+
+// IsRedacted returns whether the value is, or contains, a redacted secret
+func IsRedacted(value string) bool {
+	return strings.Contains(value, Placeholder)
+}
+
+func isSensitiveKey(key string) bool {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", "_", "").Replace(key))
+	if sensitiveKeys[normalized] {
+		return true
+	}
+	for _, suffix := range sensitiveKeySuffixes {
+		if strings.HasSuffix(normalized, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Object redacts the values of the sensitive keys and the certificates and keys in the decoded object
+func Object(obj interface{}) interface{} {
+	switch v := obj.(type) {
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		sensitivePair := IsSensitiveName(v["name"])
+		for key, value := range v {
+			if isSensitiveKey(key) || (sensitivePair && key == "value") {
+				result[key] = sensitiveValue(value)
+				continue
+			}
+			result[key] = Object(value)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, 0, len(v))
+		for _, value := range v {
+			result = append(result, Object(value))
+		}
+		return result
+	case string:
+		return redactString(v)
+	default:
+		return obj
+	}
+}
+
+// IsSensitiveName returns whether the name of a name-value pair, e.g. an HTTP probe header like
+// Authorization or an environment variable, holds a secret in its value
+func IsSensitiveName(name interface{}) bool {
+	s, ok := name.(string)
+	return ok && s != "" && isSensitiveKey(s)
+}
+
+// sensitiveValue redacts the strings of a sensitive key and keeps the type of the other values so the
+// redacted object still decodes, maps like the registry auth are redacted by their own keys
+func sensitiveValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case string:
+		if v == "" {
+			return v
+		}
+		return Placeholder
+	case []interface{}:
+		result := make([]interface{}, 0, len(v))
+		for _, item := range v {
+			result = append(result, sensitiveValue(item))
+		}
+		return result
+	default:
+		return Object(value)
+	}
+}
+
+// Text redacts the certificates, keys, URL credentials and key-value secrets in free text like logs
+func Text(text string) string {
+	text = pemBlock.ReplaceAllString(text, Placeholder)
+	text = urlSecret.ReplaceAllString(text, "${1}"+Placeholder+"@")
+	return textSecret.ReplaceAllString(text, "${1}"+Placeholder)
+}
+
+func redactString(s string) string {
+	s = pemBlock.ReplaceAllString(s, Placeholder)
+	if strings.TrimSpace(s) == Placeholder {
+		return Placeholder
+	}
+	return urlSecret.ReplaceAllString(s, "${1}"+Placeholder+"@")
+}
+
+// YAML redacts the YAML or JSON documents, the data is redacted as text if it can not be decoded
+func YAML(data []byte) []byte {
+	docs := separator.Split(string(data), -1)
+	if len(docs) == 1 {
+		return redactDocument(data)
+	}
+
+	result := make([]string, 0, len(docs))
+	for _, doc := range docs {
+		if strings.TrimSpace(doc) == "" {
+			continue
+		}
+		result = append(result, string(redactDocument([]byte(doc))))
+	}
+	return []byte(strings.Join(result, "---\n"))
+}
+
+func redactDocument(data []byte) []byte {
+	var obj interface{}
+	if err := yaml.Unmarshal(data, &obj); err != nil {
+		return []byte(Text(string(data)))
+	}
+	if _, ok := obj.(string); ok || obj == nil {
+		return []byte(Text(string(data)))
+	}
+	out, err := yaml.Marshal(Object(obj))
+	if err != nil {
+		return []byte(Text(string(data)))
+	}
+	return out
+}
+
+// Env redacts the values of the sensitive KEY=VALUE environment variables
+func Env(env []string) []string {
+	result := make([]string, 0, len(env))
+	for _, e := range env {
+		if key, _, ok := strings.Cut(e, "="); ok && isSensitiveKey(key) {
+			e = key + "=" + Placeholder
+		}
+		result = append(result, e)
+	}
+	return result
+}
+
+// Args redacts the values of the sensitive --flag=value arguments and the values following --flag
+func Args(args []string) []string {
+	result := make([]string, 0, len(args))
+	redactNext := false
+	for _, arg := range args {
+		switch {
+		case redactNext:
+			arg = Placeholder
+			redactNext = false
+		case argSecret.MatchString(arg):
+			arg = argSecret.ReplaceAllString(arg, "${1}"+Placeholder)
+		case strings.HasPrefix(arg, "-") && !strings.Contains(arg, "=") && isSensitiveKey(strings.TrimLeft(arg, "-")):
+			redactNext = true
+		default:
+			arg = redactString(arg)
+		}
+		result = append(result, arg)
+	}
+	return result
+}
+
+// LogArgs returns the arguments to log, they are redacted unless the insecure debug secrets are enabled
+func LogArgs(args []string) []string {
+	if !Enabled() {
+		return args
+	}
+	return Args(args)
+}
+
+// LogEnv returns the environment variables to log, they are redacted unless the insecure debug secrets are enabled
+func LogEnv(env []string) []string {
+	if !Enabled() {
+		return env
+	}
+	return Env(env)
+}
+
+// Restore replaces the redacted values of the decoded object with the values at the same path of
+// the source, e.g. to recover the secrets of a stamp from the config files it was generated from
+func Restore(obj, source interface{}) interface{} {
+	switch v := obj.(type) {
+	case map[string]interface{}:
+		src, _ := source.(map[string]interface{})
+		result := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			result[key] = Restore(value, src[key])
+		}
+		return result
+	case []interface{}:
+		src, _ := source.([]interface{})
+		result := make([]interface{}, 0, len(v))
+		for i, value := range v {
+			var item interface{}
+			if i < len(src) {
+				item = src[i]
+			}
+			result = append(result, Restore(value, item))
+		}
+		return result
+	case string:
+		if s, ok := source.(string); ok && IsRedacted(v) {
+			return s
+		}
+		return v
+	default:
+		return obj
+	}
+}
@@ -0,0 +1,140 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/llmos-ai/llmos/pkg/version"
+)
+
+const (
+	tracerName  = "github.com/llmos-ai/llmos"
+	serviceName = "llmos"
+
+	// TraceParentEnv holds the W3C traceparent of the current span, it is passed to the instructions so
+	// that they can add child spans, e.g. with otel-cli, and is read to continue the trace of a caller
+	TraceParentEnv = "TRACEPARENT"
+	// TraceStateEnv holds the W3C tracestate of the current span
+	TraceStateEnv = "TRACESTATE"
+)
+
+// Options configures the span exporters, the spans are exported with OTLP over gRPC to the endpoint,
+// e.g. http://otel-collector:4317, and written as JSON lines to the file. Tracing is disabled when
+// neither is set
+type Options struct {
+	Endpoint string
+	File     string
+}
+
+// Setup installs the global tracer provider exporting to the configured exporters, the returned
+// function flushes the pending spans and must be called before the process exits
+func Setup(ctx context.Context, opts Options) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	if opts.Endpoint == "" && opts.File == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL,
+		semconv.ServiceName(serviceName),
+		semconv.ServiceVersion(version.Version),
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	providerOpts := []sdktrace.TracerProviderOption{sdktrace.WithResource(res)}
+	var closers []func() error
+	if opts.Endpoint != "" {
+		exporter, err := otlptracegrpc.New(ctx, endpointOption(opts.Endpoint))
+		if err != nil {
+			return nil, fmt.Errorf("creating OTLP exporter for %s: %w", opts.Endpoint, err)
+		}
+		providerOpts = append(providerOpts, sdktrace.WithBatcher(exporter))
+	}
+	if opts.File != "" {
+		f, err := os.OpenFile(opts.File, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+		if err != nil {
+			return nil, fmt.Errorf("opening trace file %s: %w", opts.File, err)
+		}
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(f))
+		if err != nil {
+			_ = f.Close()
+			return nil, err
+		}
+		// the spans are written as they end so that they are kept if the process is killed
+		providerOpts = append(providerOpts, sdktrace.WithSyncer(exporter))
+		closers = append(closers, f.Close)
+	}
+
+	provider := sdktrace.NewTracerProvider(providerOpts...)
+	otel.SetTracerProvider(provider)
+	return func(ctx context.Context) error {
+		errs := []error{provider.Shutdown(ctx)}
+		for _, closer := range closers {
+			errs = append(errs, closer())
+		}
+		return errors.Join(errs...)
+	}, nil
+}
+
+// endpointOption accepts the endpoint as a URL, plain http disables TLS, or as host:port using TLS
+func endpointOption(endpoint string) otlptracegrpc.Option {
+	if strings.Contains(endpoint, "://") {
+		return otlptracegrpc.WithEndpointURL(endpoint)
+	}
+	return otlptracegrpc.WithEndpoint(endpoint)
+}
+
+// Start starts a span of the llmos tracer
+func Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name, opts...)
+}
+
+// End records the error, if any, and ends the span
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// ContextFromEnv returns the context continuing the trace of the TRACEPARENT env var, if set
+func ContextFromEnv(ctx context.Context) context.Context {
+	carrier := propagation.MapCarrier{}
+	if traceParent := os.Getenv(TraceParentEnv); traceParent != "" {
+		carrier.Set("traceparent", traceParent)
+	}
+	if traceState := os.Getenv(TraceStateEnv); traceState != "" {
+		carrier.Set("tracestate", traceState)
+	}
+	return otel.GetTextMapPropagator().Extract(ctx, carrier)
+}
+
+// Env returns the env vars propagating the span of the context, none if it is not traced
+func Env(ctx context.Context) []string {
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+
+	var env []string
+	if traceParent := carrier.Get("traceparent"); traceParent != "" {
+		env = append(env, TraceParentEnv+"="+traceParent)
+	}
+	if traceState := carrier.Get("tracestate"); traceState != "" {
+		env = append(env, TraceStateEnv+"="+traceState)
+	}
+	return env
+}
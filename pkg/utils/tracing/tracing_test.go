@@ -0,0 +1,82 @@
+package tracing
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+func TestSetupFile(t *testing.T) {
+	defer otel.SetTracerProvider(noop.NewTracerProvider())
+
+	file := filepath.Join(t.TempDir(), "trace.json")
+	shutdown, err := Setup(context.Background(), Options{File: file})
+	require.NoError(t, err)
+
+	ctx, root := Start(context.Background(), "bootstrap")
+	_, child := Start(ctx, "image.Stage")
+	End(child, errors.New("manifest unknown"))
+	End(root, nil)
+	require.NoError(t, shutdown(context.Background()))
+
+	f, err := os.Open(file)
+	require.NoError(t, err)
+	defer f.Close()
+
+	type span struct {
+		Name        string
+		SpanContext struct{ TraceID string }
+		Parent      struct{ SpanID string }
+		Status      struct{ Code string }
+	}
+	var spans []span
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		s := span{}
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &s))
+		spans = append(spans, s)
+	}
+	require.NoError(t, scanner.Err())
+	require.Len(t, spans, 2)
+
+	assert.Equal(t, "image.Stage", spans[0].Name)
+	assert.Equal(t, "Error", spans[0].Status.Code)
+	assert.Equal(t, root.SpanContext().SpanID().String(), spans[0].Parent.SpanID)
+	assert.Equal(t, "bootstrap", spans[1].Name)
+	assert.Equal(t, spans[0].SpanContext.TraceID, spans[1].SpanContext.TraceID)
+}
+
+func TestSetupDisabled(t *testing.T) {
+	shutdown, err := Setup(context.Background(), Options{})
+	require.NoError(t, err)
+	require.NoError(t, shutdown(context.Background()))
+
+	ctx, span := Start(context.Background(), "bootstrap")
+	defer span.End()
+	assert.False(t, span.SpanContext().IsValid())
+	assert.Empty(t, Env(ctx))
+}
+
+func TestEnvPropagation(t *testing.T) {
+	_, err := Setup(context.Background(), Options{})
+	require.NoError(t, err)
+
+	traceParent := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	t.Setenv(TraceParentEnv, traceParent)
+	ctx := ContextFromEnv(context.Background())
+
+	spanContext := trace.SpanContextFromContext(ctx)
+	assert.True(t, spanContext.IsRemote())
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", spanContext.TraceID().String())
+	assert.Equal(t, []string{TraceParentEnv + "=" + traceParent}, Env(ctx))
+}
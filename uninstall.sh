@@ -65,11 +65,12 @@ setup_env() {
     # --- use service or environment location depending on systemd/openrc ---
 	if [ "${HAS_SYSTEMD}" = true ]; then
 		FILE_LLMOS_SERVICE=${SYSTEMD_DIR}/${SERVICE_LLMOS}
-		FILE_LLMOS_ENV=${SYSTEMD_DIR}/${SERVICE_LLMOS}.env
+		FILE_LLMOS_LEGACY_ENV=${SYSTEMD_DIR}/${SERVICE_LLMOS}.env
     elif [ "${HAS_OPENRC}" = true ]; then
 		FILE_LLMOS_SERVICE=/etc/init.d/${SYSTEM_NAME}
-		FILE_LLMOS_ENV=/etc/llmos/${SYSTEM_NAME}.env
+		FILE_LLMOS_LEGACY_ENV=/etc/llmos/${SYSTEM_NAME}.env
     fi
+    FILE_LLMOS_ENV=/etc/default/${SYSTEM_NAME}
 
     # check k3s or rke2 exist
 
@@ -111,20 +112,26 @@ uninstall_llmos() {
 
 		$SUDO rm -f "${FILE_LLMOS_SERVICE}"
 		$SUDO rm -f "${FILE_LLMOS_ENV}"
+		$SUDO rm -f "${FILE_LLMOS_LEGACY_ENV}"
 	fi
 
-	if [ -n "${KUBE_UNINSTALL}" ]; then
+	if [ -n "${BIN_DIR}" ]; then
+		# remove k8s runtime, generated files and llmos data recorded by the bootstrap
+		info "Uninstalling k8s runtime and llmos data by llmos uninstall"
+		$SUDO "${BIN_DIR}/llmos" uninstall --data-dir "${LLMOS_DATA_DIR}"
+	elif [ -n "${KUBE_UNINSTALL}" ]; then
 		info "Uninstalling k8s runtime by ${KUBE_UNINSTALL}"
 		$SUDO ${KUBE_UNINSTALL}
+	fi
 
+	if [ -n "${KUBE_UNINSTALL}" ]; then
 		$SUDO rm -rf /var/lib/rancher /etc/rancher
 	fi
 
-
 	# remove llmos data and configs
 	$SUDO rm -rf ${LLMOS_DATA_DIR} /var/lib/rook/*llmos
 	if [ -n "${BIN_DIR}" ]; then
-		$SUDO rm -rf "${BIN_DIR}/llmos"
+		$SUDO rm -rf "${BIN_DIR}/llmos" "${BIN_DIR}/llmos-uninstall.sh"
 	fi
 
 	info "Uninstalled ${SYSTEM_NAME} service"